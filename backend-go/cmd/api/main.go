@@ -1,15 +1,32 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/bank-melli/tpa/internal/config"
 	"github.com/bank-melli/tpa/internal/delivery/http/handler"
+	"github.com/bank-melli/tpa/internal/delivery/http/middleware"
+	"github.com/bank-melli/tpa/internal/domain/attachment"
+	"github.com/bank-melli/tpa/internal/domain/duplicate"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/search"
+	"github.com/bank-melli/tpa/internal/domain/sla"
 	"github.com/bank-melli/tpa/internal/infrastructure/database"
+	gormrepo "github.com/bank-melli/tpa/internal/infrastructure/repository/gorm"
+	"github.com/bank-melli/tpa/internal/pkg/health"
+	"github.com/bank-melli/tpa/internal/pkg/metrics"
+	"github.com/bank-melli/tpa/internal/pkg/ratelimit"
+	"github.com/bank-melli/tpa/internal/pkg/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -25,6 +42,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	// The public download route trusts links signed with this key
+	linkKey, err := signingKey(cfg)
+	if err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
 
 	// Initialize database
 	db, err := database.NewDatabase(&cfg.Database)
@@ -33,14 +55,13 @@ func main() {
 	}
 	defer db.Close()
 
-	// Run migrations
-	if err := db.AutoMigrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// Verify schema; migrations are applied by `tpa migrate up`, never at startup
+	schemaReport, err := db.VerifySchema(context.Background())
+	if err != nil {
+		log.Fatalf("Schema check failed: %v", err)
 	}
-
-	// Seed initial data
-	if err := db.Seed(); err != nil {
-		log.Printf("Warning: Failed to seed data: %v", err)
+	for _, issue := range schemaReport.IndexIssues {
+		log.Printf("Warning: index mismatch %s", issue)
 	}
 
 	// Create Fiber app
@@ -72,28 +93,55 @@ func main() {
 		MaxAge:           86400,
 	}))
 
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		if err := db.HealthCheck(); err != nil {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status":  "unhealthy",
-				"message": "database connection failed",
-			})
-		}
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"version": "1.0.0",
-		})
-	})
+	// Rate limiting & quotas
+	limitStore, err := ratelimit.NewStore(&cfg.RateLimit, &cfg.Redis)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limit store: %v", err)
+	}
+	quota := ratelimit.NewQuota(&cfg.RateLimit, limitStore)
+
+	// Attachment storage
+	blobStore, err := storage.NewStore(&cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	attachments := attachment.NewService(
+		gormrepo.NewAttachmentRepository(db.DB),
+		blobStore,
+		storage.NewScanner(cfg.Storage.ClamdAddr),
+		storage.NewURLSigner(linkKey),
+		attachmentConfig(&cfg.Storage),
+	)
+
+	// Per-route database metrics, exposed with other metrics on the metrics listener
+	app.Use(middleware.QueryMetrics(db.Instrumentation()))
+
+	// Health checks: /health, /health/ready, /health/live
+	healthRegistry := health.NewRegistry()
+	registerHealthChecks(healthRegistry, db, limitStore, blobStore, cfg)
+	healthRegistry.Routes(app)
 
 	// API routes
 	api := app.Group(cfg.App.APIPrefix)
 
+	// Buckets key on the token's tenant and user, so protected routes are
+	// limited after auth; public routes are limited by IP
+	limit := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.RateLimit.Enabled {
+		limit = ratelimit.NewLimiter(&cfg.RateLimit, limitStore).Middleware()
+	}
+
+	// Reads go to replicas (if configured) until the request writes
+	api.Use(middleware.ReadYourWrites())
+
 	// Public routes (no auth required)
-	setupPublicRoutes(api, db, cfg)
+	setupPublicRoutes(api, db, cfg, attachments, limit)
+
+	// Background jobs stop with the server
+	jobs, stopJobs := context.WithCancel(context.Background())
 
 	// Protected routes
-	setupProtectedRoutes(api, db, cfg)
+	setupProtectedRoutes(jobs, api, db, cfg, quota, attachments, limit)
 
 	// Start server in a goroutine
 	go func() {
@@ -104,21 +152,80 @@ func main() {
 		}
 	}()
 
+	// Metrics name routes and tables, so they are served on their own
+	// listener (internal by default) instead of the public port
+	var metricsApp *fiber.App
+	if cfg.App.MetricsAddr != "" {
+		metricsApp = fiber.New(fiber.Config{DisableStartupMessage: true})
+		metricsApp.Get("/metrics", metrics.Default.Handler())
+		go func() {
+			log.Printf("Serving metrics on %s", cfg.App.MetricsAddr)
+			if err := metricsApp.Listen(cfg.App.MetricsAddr); err != nil {
+				log.Printf("Metrics server stopped: %v", err)
+			}
+		}()
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down server...")
+	healthRegistry.Drain()
+	stopJobs()
 	if err := app.Shutdown(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
+	if metricsApp != nil {
+		if err := metricsApp.Shutdown(); err != nil {
+			log.Printf("Error during metrics shutdown: %v", err)
+		}
+	}
 	log.Println("Server stopped")
 }
 
-func setupPublicRoutes(api fiber.Router, db *database.Database, cfg *config.Config) {
+// registerHealthChecks registers dependency checks; only the primary database is critical
+func registerHealthChecks(registry *health.Registry, db *database.Database, store ratelimit.Store, blobStore storage.Store, cfg *config.Config) {
+	registry.Register(health.Check{
+		Name:     "database",
+		Critical: true,
+		Run:      db.Ping,
+	})
+
+	if len(cfg.Database.ReplicaDSNs) > 0 {
+		registry.Register(health.Check{Name: "database_replicas", Run: db.ReplicaHealth})
+	}
+
+	// The limiter fails open, so Redis being down degrades but doesn't stop the service
+	if redisStore, ok := store.(*ratelimit.RedisStore); ok {
+		registry.Register(health.Check{Name: "cache", Run: redisStore.Ping})
+	}
+
+	// Uploads fail while storage is down, but everything else keeps working
+	registry.Register(health.Check{Name: "storage", Run: blobStore.Ping})
+
+	// External APIs are reachability checks only
+	externals := map[string]string{
+		"tamin": cfg.External.Tamin.BaseURL,
+		"sepas": cfg.External.Sepas.BaseURL,
+		"irc":   cfg.External.IRC.BaseURL,
+	}
+	for name, baseURL := range externals {
+		if baseURL == "" {
+			continue
+		}
+		registry.Register(health.Check{
+			Name:    "external_" + name,
+			Timeout: 3 * time.Second,
+			Run:     health.TCPCheck(baseURL),
+		})
+	}
+}
+
+func setupPublicRoutes(api fiber.Router, db *database.Database, cfg *config.Config, attachments *attachment.Service, limit fiber.Handler) {
 	// Auth routes
-	auth := api.Group("/auth")
+	auth := api.Group("/auth", limit)
 	{
 		auth.Post("/login", func(c *fiber.Ctx) error {
 			// TODO: Implement login handler
@@ -131,8 +238,11 @@ func setupPublicRoutes(api fiber.Router, db *database.Database, cfg *config.Conf
 		})
 	}
 
+	// Attachment downloads; the signed token in the link is the authorization
+	api.Get("/files/:token", limit, handler.DownloadAttachment(attachments))
+
 	// Public lookups
-	lookup := api.Group("/lookup")
+	lookup := api.Group("/lookup", limit)
 	{
 		lookup.Get("/provinces", func(c *fiber.Ctx) error {
 			// TODO: Implement provinces list
@@ -151,12 +261,11 @@ func setupPublicRoutes(api fiber.Router, db *database.Database, cfg *config.Conf
 	}
 }
 
-func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.Config) {
-	// TODO: Add auth middleware when implemented
-	// protected := api.Use(middleware.AuthMiddleware(&cfg.JWT))
-
-	// For now, use api directly without auth
-	protected := api
+func setupProtectedRoutes(jobs context.Context, api fiber.Router, db *database.Database, cfg *config.Config, quota *ratelimit.Quota, attachments *attachment.Service, limit fiber.Handler) {
+	// Every protected route requires a token; the tenant, user and role come
+	// from its claims, never from request headers. Rate limits and quotas
+	// run after auth so they see them.
+	protected := api.Group("", middleware.AuthMiddleware(&cfg.JWT), limit)
 
 	// Dashboard
 	protected.Get("/dashboard", func(c *fiber.Ctx) error {
@@ -164,6 +273,9 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 		return c.JSON(fiber.Map{"message": "dashboard endpoint"})
 	})
 
+	// Monthly usage quotas for the current tenant
+	protected.Get("/quotas", quota.UsageHandler())
+
 	// Users
 	users := protected.Group("/users")
 	{
@@ -185,35 +297,134 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 	}
 
 	// Claims
+	claimRepo := gormrepo.NewClaimRepository(db.DB)
+	roleRepo := gormrepo.NewRoleRepository(db.DB)
+	settingRepo := gormrepo.NewTenantSettingRepository(db.DB)
+	reasonCodeRepo := gormrepo.NewReasonCodeRepository(db.DB)
+	ruleSetRepo := gormrepo.NewRuleSetRepository(db.DB)
+	ruleEngine := rules.NewEngine()
+	claimHandler := handler.NewClaimHandler(
+		claimRepo,
+		gormrepo.NewEmployeeRepository(db.DB),
+		gormrepo.NewCenterRepository(db.DB),
+		roleRepo,
+		reasonCodeRepo,
+		duplicate.NewDetector(claimRepo, reasonCodeRepo, duplicateConfig(&cfg.Claims)),
+		ruleSetRepo,
+		rules.NewEvaluator(ruleSetRepo, claimRepo, ruleEngine),
+		settingRepo,
+		gormrepo.NewWorkQueueRepository(db.DB),
+	)
+	protected.Get("/reason-codes", claimHandler.ListReasonCodes)
+
+	attachmentHandler := handler.NewAttachmentHandler(
+		attachments,
+		gormrepo.NewAttachmentRepository(db.DB),
+		claimRepo,
+		gormrepo.NewPackageRepository(db.DB),
+		roleRepo,
+		cfg.App.BaseURL+cfg.App.APIPrefix+"/files/",
+	)
+
+	slaPolicyRepo := gormrepo.NewSLAPolicyRepository(db.DB)
+	slaBreachRepo := gormrepo.NewSLABreachRepository(db.DB)
+	slaTracker := sla.NewTracker(slaPolicyRepo, slaBreachRepo, settingRepo, slaConfig(&cfg.Claims))
+	go slaTracker.Run(jobs)
+	slaHandler := handler.NewSLAHandler(slaPolicyRepo, slaBreachRepo, claimRepo, settingRepo, roleRepo, slaTracker)
+
 	claims := protected.Group("/claims")
 	{
-		claims.Get("/", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "list claims"})
-		})
-		claims.Post("/", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "create claim"})
-		})
-		claims.Get("/:id", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "get claim"})
-		})
-		claims.Put("/:id", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "update claim"})
-		})
-		claims.Delete("/:id", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "delete claim"})
-		})
-		claims.Post("/:id/submit", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "submit claim"})
-		})
-		claims.Post("/:id/examine", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "examine claim"})
-		})
-		claims.Post("/:id/approve", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "approve claim"})
-		})
-		claims.Get("/stats", func(c *fiber.Ctx) error {
-			return c.JSON(fiber.Map{"message": "claim stats"})
-		})
+		claims.Get("/", claimHandler.ListClaims)
+		claims.Post("/", claimHandler.CreateClaim)
+		claims.Get("/stats", claimHandler.GetClaimStats)
+		claims.Get("/auto-approval", claimHandler.GetAutoApprovalPolicy)
+		claims.Put("/auto-approval", claimHandler.UpdateAutoApprovalPolicy)
+		claims.Get("/:id", claimHandler.GetClaim)
+		claims.Put("/:id", claimHandler.UpdateClaim)
+		claims.Delete("/:id", claimHandler.DeleteClaim)
+		claims.Get("/:id/history", claimHandler.GetClaimHistory)
+		claims.Get("/:id/duplicates", claimHandler.GetClaimDuplicates)
+		claims.Get("/:id/rule-logs", claimHandler.GetClaimRuleLogs)
+		claims.Post("/:id/submit", claimHandler.SubmitClaim)
+		claims.Post("/:id/examine", claimHandler.ExamineClaim)
+		claims.Post("/:id/approve", claimHandler.ApproveClaim)
+		claims.Post("/:id/reexamine", claimHandler.ReexamineClaim)
+		claims.Post("/:id/return", claimHandler.ReturnClaim)
+		claims.Post("/:id/sample-review", claimHandler.ReviewSampledClaim)
+		claims.Post("/:id/assign", claimHandler.AssignClaim)
+		claims.Get("/:id/assignments", claimHandler.GetClaimAssignments)
+		claims.Get("/:id/sla", slaHandler.GetClaimSLA)
+		claims.Get("/:id/attachments", attachmentHandler.ListClaimAttachments)
+		claims.Post("/:id/attachments", attachmentHandler.UploadClaimAttachment)
+	}
+
+	// Examination work queues
+	queues := protected.Group("/queues")
+	{
+		queues.Get("/", claimHandler.GetQueueOverview)
+		queues.Post("/next", claimHandler.PullNextClaim)
+		queues.Get("/examiners", claimHandler.ListExaminers)
+		queues.Put("/examiners/:userId", claimHandler.UpdateExaminerProfile)
+		queues.Put("/:claimType", claimHandler.UpdateWorkQueue)
+		queues.Post("/:claimType/dispatch", claimHandler.DispatchQueue)
+	}
+
+	// Attachments of claims and packages
+	attachmentRoutes := protected.Group("/attachments")
+	{
+		attachmentRoutes.Get("/types", attachmentHandler.ListAttachmentTypes)
+		attachmentRoutes.Put("/types", attachmentHandler.SaveAttachmentType)
+		attachmentRoutes.Delete("/types/:id", attachmentHandler.DeleteAttachmentType)
+		attachmentRoutes.Get("/:id/url", attachmentHandler.GetAttachmentURL)
+		attachmentRoutes.Delete("/:id", attachmentHandler.DeleteAttachment)
+	}
+
+	// SLA timers and escalation
+	slas := protected.Group("/sla")
+	{
+		slas.Get("/policies", slaHandler.ListSLAPolicies)
+		slas.Put("/policies", slaHandler.SaveSLAPolicy)
+		slas.Delete("/policies/:id", slaHandler.DeleteSLAPolicy)
+		slas.Get("/calendar", slaHandler.GetWorkCalendar)
+		slas.Put("/calendar/work-hours", slaHandler.UpdateWorkHours)
+		slas.Post("/holidays", slaHandler.CreateHoliday)
+		slas.Delete("/holidays/:id", slaHandler.DeleteHoliday)
+		slas.Get("/aging", slaHandler.GetAging)
+		slas.Get("/breaches", slaHandler.ListSLABreaches)
+		slas.Post("/breaches/:id/acknowledge", slaHandler.AcknowledgeSLABreach)
+	}
+
+	// Search over claims, members and centers
+	searcher := search.NewSearcher(gormrepo.NewSearchRepository(db.DB))
+	protected.Get("/search", handler.NewSearchHandler(searcher).Search)
+
+	// Adjudication rules
+	ruleSimulationRepo := gormrepo.NewRuleSimulationRepository(db.DB)
+	ruleSetHandler := handler.NewRuleSetHandler(
+		ruleSetRepo,
+		ruleSimulationRepo,
+		ruleEngine,
+		rules.NewSimulator(ruleSimulationRepo, ruleSetRepo, claimRepo, ruleEngine),
+		roleRepo,
+	)
+	ruleSets := protected.Group("/rules")
+	{
+		ruleSets.Get("/", ruleSetHandler.ListRuleSets)
+		ruleSets.Post("/", ruleSetHandler.CreateRuleSet)
+		ruleSets.Get("/active", ruleSetHandler.GetActiveRuleSet)
+		ruleSets.Post("/validate", ruleSetHandler.ValidateRuleSource)
+		ruleSets.Get("/simulations", ruleSetHandler.ListRuleSimulations)
+		ruleSets.Get("/simulations/:simId", ruleSetHandler.GetRuleSimulation)
+		ruleSets.Get("/simulations/:simId/claims", ruleSetHandler.ListRuleSimulationClaims)
+		ruleSets.Get("/:id", ruleSetHandler.GetRuleSet)
+		ruleSets.Put("/:id", ruleSetHandler.UpdateRuleSet)
+		ruleSets.Delete("/:id", ruleSetHandler.DeleteRuleSet)
+		ruleSets.Post("/:id/tests", ruleSetHandler.CreateRuleTest)
+		ruleSets.Delete("/:id/tests/:testId", ruleSetHandler.DeleteRuleTest)
+		ruleSets.Post("/:id/test", ruleSetHandler.RunRuleTests)
+		ruleSets.Post("/:id/promote", ruleSetHandler.PromoteRuleSet)
+		ruleSets.Post("/:id/rollback", ruleSetHandler.RollbackRuleSet)
+		ruleSets.Post("/:id/simulations", ruleSetHandler.SimulateRuleSet)
 	}
 
 	// Packages
@@ -240,6 +451,8 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 		packages.Post("/:id/approve", func(c *fiber.Ctx) error {
 			return c.JSON(fiber.Map{"message": "approve package"})
 		})
+		packages.Get("/:id/attachments", attachmentHandler.ListPackageAttachments)
+		packages.Post("/:id/attachments", attachmentHandler.UploadPackageAttachment)
 	}
 
 	// Centers
@@ -294,8 +507,8 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 	}
 
 	// Employees - سیستم کارمندان (عین Yii)
-	employeeHandler := handler.NewEmployeeHandler()
-	importHandler := handler.NewEmployeeImportHandler()
+	employeeHandler := handler.NewEmployeeHandler(gormrepo.NewEmployeeRepository(db.DB), searcher)
+	importHandler := handler.NewEmployeeImportHandler(gormrepo.NewImportHistoryRepository(db.DB))
 
 	log.Println("Setting up employee routes...")
 	employees := protected.Group("/employees")
@@ -311,8 +524,8 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 		// Import & Sync Operations (عین Yii)
 		employees.Post("/upload", importHandler.UploadCSV)             // آپلود CSV
 		employees.Post("/process", importHandler.ProcessCSV)           // پردازش CSV
-		employees.Post("/import", importHandler.ImportEmployees)       // Import به جدول اصلی
-		employees.Post("/sync", importHandler.SyncFromHR)              // همگام‌سازی با HR
+		employees.Post("/import", quota.Middleware(ratelimit.OperationImport), importHandler.ImportEmployees) // Import به جدول اصلی
+		employees.Post("/sync", quota.Middleware(ratelimit.OperationImport), importHandler.SyncFromHR)        // همگام‌سازی با HR
 		employees.Post("/validate", importHandler.ValidateCSV)         // اعتبارسنجی CSV
 
 		// Statistics & History
@@ -381,6 +594,10 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 		reports.Get("/performance", func(c *fiber.Ctx) error {
 			return c.JSON(fiber.Map{"message": "performance report"})
 		})
+		// Only exports count against the monthly export quota
+		reports.Get("/:report/export", quota.Middleware(ratelimit.OperationReportExport), func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{"message": "export report"})
+		})
 	}
 
 	// Tariffs
@@ -395,6 +612,51 @@ func setupProtectedRoutes(api fiber.Router, db *database.Database, cfg *config.C
 	}
 }
 
+// duplicateConfig applies the configured look-back windows to the defaults
+func duplicateConfig(cfg *config.ClaimsConfig) duplicate.Config {
+	dc := duplicate.DefaultConfig()
+	dc.DefaultWindow = cfg.DuplicateWindow
+	for claimType, window := range cfg.DuplicateWindows {
+		dc.Windows[entity.ClaimType(claimType)] = window
+	}
+	return dc
+}
+
+func slaConfig(cfg *config.ClaimsConfig) sla.Config {
+	sc := sla.DefaultConfig()
+	sc.Interval = cfg.SLACheckInterval
+	return sc
+}
+
+func attachmentConfig(cfg *config.StorageConfig) attachment.Config {
+	ac := attachment.DefaultConfig()
+	ac.MaxSize = cfg.MaxUploadSize
+	ac.MIMETypes = cfg.AllowedMIMETypes
+	ac.URLExpiry = cfg.URLExpiry
+	return ac
+}
+
+// signingKey returns the key download links are signed with. It must be
+// set, and differ from the JWT secret, outside development; in development
+// an unset key is random, so links stop working on restart.
+func signingKey(cfg *config.Config) (string, error) {
+	key := cfg.Storage.SigningKey
+	if key == "" && cfg.App.Environment == "development" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	}
+	switch key {
+	case "":
+		return "", errors.New("STORAGE_SIGNING_KEY is required outside development")
+	case cfg.JWT.Secret:
+		return "", errors.New("STORAGE_SIGNING_KEY must differ from JWT_SECRET")
+	}
+	return key, nil
+}
+
 func errorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	message := "Internal Server Error"
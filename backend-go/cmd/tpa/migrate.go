@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"github.com/bank-melli/tpa/internal/infrastructure/database"
+	"github.com/bank-melli/tpa/internal/pkg/migration"
+)
+
+const migrateUsageText = `Usage: tpa migrate <up|down|status|pending|verify> [flags]
+
+Subcommands:
+  up       apply pending schema migrations, then pending tenant migrations
+  down     roll back the last tenant batch or schema migration, or down to a target version
+  status   print the schema version and applied migrations per tenant
+  pending  list schema and tenant migrations not yet applied
+  verify   report applied migrations whose definition changed
+
+Run "tpa migrate <subcommand> -h" for flags.`
+
+// migrateOptions holds flags shared by migrate subcommands
+type migrateOptions struct {
+	tenantID        uint
+	scope           string
+	to              string
+	drift           string
+	concurrency     int
+	continueOnError bool
+	dryRun          bool
+}
+
+func runMigrate(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintln(os.Stderr, migrateUsageText)
+		return exitUsage
+	}
+
+	sub := args[0]
+	opts := migrateOptions{}
+	fs := flag.NewFlagSet("migrate "+sub, flag.ContinueOnError)
+	fs.UintVar(&opts.tenantID, "tenant", 0, "only this tenant ID (default: all tenants)")
+
+	switch sub {
+	case "up":
+		fs.StringVar(&opts.scope, "scope", "all", "what to migrate: all, global or tenants")
+		fs.StringVar(&opts.drift, "drift", "", "fail or warn on drifted migrations (default: DB_MIGRATION_DRIFT_MODE)")
+		fs.IntVar(&opts.concurrency, "concurrency", 4, "number of tenants migrated in parallel")
+		fs.BoolVar(&opts.continueOnError, "continue-on-error", false, "keep migrating other tenants after a failure")
+		fs.BoolVar(&opts.dryRun, "dry-run", false, "print what tenant migrations would do without committing anything")
+	case "verify":
+		fs.StringVar(&opts.drift, "drift", "", "fail exits non-zero on drift, warn only reports (default: DB_MIGRATION_DRIFT_MODE)")
+	case "down":
+		fs.StringVar(&opts.scope, "scope", "tenants", "what to roll back: global or tenants")
+		fs.StringVar(&opts.to, "to", "", `roll back every migration newer than this version ("0" rolls back all; default: last batch or schema migration)`)
+	case "status", "pending":
+	default:
+		fmt.Fprintf(os.Stderr, "tpa migrate: unknown subcommand %q\n\n%s\n", sub, migrateUsageText)
+		return exitUsage
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if opts.scope != "" && opts.scope != "all" && opts.scope != "global" && opts.scope != "tenants" {
+		fmt.Fprintf(os.Stderr, "tpa migrate: invalid -scope %q\n", opts.scope)
+		return exitUsage
+	}
+	if sub == "down" && opts.scope == "all" {
+		fmt.Fprintln(os.Stderr, "tpa migrate: down needs -scope global or -scope tenants")
+		return exitUsage
+	}
+	if opts.drift != "" && opts.drift != string(migration.DriftFail) && opts.drift != string(migration.DriftWarn) {
+		fmt.Fprintf(os.Stderr, "tpa migrate: invalid -drift %q\n", opts.drift)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := migrate(ctx, sub, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "tpa migrate %s: %v\n", sub, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func migrate(ctx context.Context, sub string, opts migrateOptions) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := database.NewDatabase(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	driftMode := migration.DriftMode(cfg.Database.MigrationDriftMode)
+	if opts.drift != "" {
+		driftMode = migration.DriftMode(opts.drift)
+	}
+
+	migrator := migration.NewMigrator(db.DB, migration.MigratorConfig{
+		DriftMode:       driftMode,
+		ExecutedBy:      executedBy(),
+		Concurrency:     opts.concurrency,
+		ContinueOnError: opts.continueOnError,
+	})
+	migrator.RegisterMany(migration.GetTenantMigrations())
+
+	switch sub {
+	case "up":
+		return migrateUp(ctx, db, migrator, opts)
+	case "down":
+		if opts.scope == "global" {
+			return migrateDownSchema(ctx, db, opts)
+		}
+		return migrateDown(ctx, migrator, opts)
+	case "status":
+		return migrateStatus(ctx, db, migrator, opts)
+	case "pending":
+		return migratePending(ctx, db, migrator, opts)
+	case "verify":
+		return migrateVerify(ctx, db, migrator, opts, driftMode)
+	}
+	return nil
+}
+
+func migrateUp(ctx context.Context, db *database.Database, migrator *migration.Migrator, opts migrateOptions) error {
+	if opts.dryRun {
+		return migrateDryRun(ctx, migrator, opts)
+	}
+
+	if opts.scope == "all" || opts.scope == "global" {
+		fmt.Println("Applying schema migrations...")
+		applied, err := db.MigrateSchema(ctx)
+		for _, mig := range applied {
+			fmt.Printf("  %06d %s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("schema: %w", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("  schema is up to date")
+		}
+	}
+
+	if opts.scope == "global" {
+		return nil
+	}
+
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	summary := migrator.MigrateTenants(ctx, tenantIDs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TENANT\tRESULT\tAPPLIED\tDURATION\tERROR")
+	for _, r := range summary.Results {
+		result, errText := "ok", ""
+		switch {
+		case r.Skipped:
+			result = "skipped"
+		case r.Err != nil:
+			result, errText = "failed", r.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", r.TenantID, result, r.Applied, r.Duration.Round(time.Millisecond), errText)
+	}
+	w.Flush()
+	fmt.Printf("\n%d succeeded, %d failed, %d skipped in %s\n", summary.Succeeded, summary.Failed, summary.Skipped, summary.Duration.Round(time.Millisecond))
+
+	return summary.Err()
+}
+
+func migrateDryRun(ctx context.Context, migrator *migration.Migrator, opts migrateOptions) error {
+	if opts.scope == "global" {
+		return errors.New("dry run is only supported for tenant migrations")
+	}
+
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, tenantID := range tenantIDs {
+		plan, err := migrator.DryRun(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+
+		fmt.Printf("\nTenant %d: %d pending\n", tenantID, len(plan.Migrations))
+		for _, mig := range plan.Migrations {
+			fmt.Printf("  %s %s\n", mig.Version, mig.Name)
+			for _, stmt := range mig.Statements {
+				fmt.Printf("    [%d rows] %s\n", stmt.RowsAffected, stmt.SQL)
+			}
+			if mig.Error != "" {
+				fmt.Printf("    FAILED: %s\n", mig.Error)
+			}
+		}
+		if plan.HasErrors() {
+			failed++
+		}
+	}
+
+	fmt.Println("\nDry run: all changes were rolled back")
+	if failed > 0 {
+		return fmt.Errorf("%d tenant(s) would fail", failed)
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, migrator *migration.Migrator, opts migrateOptions) error {
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		switch opts.to {
+		case "":
+			err = migrator.Rollback(ctx, tenantID)
+		case "0":
+			err = migrator.RollbackTo(ctx, tenantID, "")
+		default:
+			err = migrator.RollbackTo(ctx, tenantID, opts.to)
+		}
+		if err != nil {
+			return fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+		fmt.Printf("Tenant %d: rolled back\n", tenantID)
+	}
+	return nil
+}
+
+// migrateDownSchema rolls back global schema migrations to -to, or the latest one
+func migrateDownSchema(ctx context.Context, db *database.Database, opts migrateOptions) error {
+	var target uint
+	if opts.to != "" {
+		v, err := strconv.ParseUint(opts.to, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid -to %q: schema versions are numbers", opts.to)
+		}
+		target = uint(v)
+	} else {
+		version, _, err := db.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		migrations, err := database.LoadSchemaMigrations()
+		if err != nil {
+			return err
+		}
+		// Roll back only the latest applied migration
+		for _, mig := range migrations {
+			if mig.Version < version {
+				target = mig.Version
+			}
+		}
+	}
+
+	rolledBack, err := db.RollbackSchema(ctx, target)
+	for _, mig := range rolledBack {
+		fmt.Printf("Schema: rolled back %06d %s\n", mig.Version, mig.Name)
+	}
+	return err
+}
+
+func migrateStatus(ctx context.Context, db *database.Database, migrator *migration.Migrator, opts migrateOptions) error {
+	version, dirty, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("schema version: %w", err)
+	}
+	state := "clean"
+	if dirty {
+		state = "dirty"
+	}
+	fmt.Printf("Schema version: %d (%s)\n", version, state)
+
+	if !db.Migrator().HasTable(&migration.MigrationRecord{}) {
+		return errors.New("migrations table does not exist; run \"tpa migrate up\" first")
+	}
+
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		records, err := migrator.Status(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+
+		// Keep the latest record per version
+		latest := make(map[string]migration.MigrationRecord, len(records))
+		for _, record := range records {
+			if prev, ok := latest[record.Version]; !ok || record.ID > prev.ID {
+				latest[record.Version] = record
+			}
+		}
+
+		fmt.Printf("\nTenant %d\n", tenantID)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tBATCH\tCOMPLETED AT\tERROR")
+		for _, mig := range migrator.Migrations() {
+			record, ok := latest[mig.Version]
+			if !ok {
+				fmt.Fprintf(w, "%s\t%s\t%s\t-\t-\t\n", mig.Version, mig.Name, migration.MigrationPending)
+				continue
+			}
+			delete(latest, mig.Version)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", record.Version, record.Name, record.Status, record.BatchNo, formatTime(record.CompletedAt), record.Error)
+		}
+		// Records whose definition is no longer registered
+		for _, record := range latest {
+			fmt.Fprintf(w, "%s\t%s\t%s (unknown)\t%d\t%s\t%s\n", record.Version, record.Name, record.Status, record.BatchNo, formatTime(record.CompletedAt), record.Error)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+func migratePending(ctx context.Context, db *database.Database, migrator *migration.Migrator, opts migrateOptions) error {
+	schemaPending, err := db.PendingSchemaMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	fmt.Printf("Schema: %d pending\n", len(schemaPending))
+	for _, mig := range schemaPending {
+		fmt.Printf("  %06d %s\n", mig.Version, mig.Name)
+	}
+
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		pending := migrator.Pending(ctx, tenantID)
+		fmt.Printf("\nTenant %d: %d pending\n", tenantID, len(pending))
+		if len(pending) == 0 {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tDESCRIPTION")
+		for _, mig := range pending {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", mig.Version, mig.Name, mig.Description)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+func migrateVerify(ctx context.Context, db *database.Database, migrator *migration.Migrator, opts migrateOptions, mode migration.DriftMode) error {
+	if !db.Migrator().HasTable(&migration.MigrationRecord{}) {
+		return errors.New("migrations table does not exist; run \"tpa migrate up\" first")
+	}
+
+	tenantIDs, err := targetTenants(ctx, migrator, opts)
+	if err != nil {
+		return err
+	}
+
+	report, err := migrator.DriftReport(ctx, tenantIDs)
+	if err != nil {
+		return err
+	}
+
+	blocking := 0
+	for _, tenantID := range tenantIDs {
+		drifts := report[tenantID]
+		if len(drifts) == 0 {
+			fmt.Printf("Tenant %d: ok\n", tenantID)
+			continue
+		}
+
+		fmt.Printf("\nTenant %d: %d drifted\n", tenantID, len(drifts))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tREASON\tRECORDED\tCURRENT")
+		for _, d := range drifts {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Version, d.Name, d.Reason, shortChecksum(d.Recorded), shortChecksum(d.Current))
+			if d.Blocking() {
+				blocking++
+			}
+		}
+		w.Flush()
+	}
+
+	if blocking > 0 && mode != migration.DriftWarn {
+		return fmt.Errorf("%w (%d migration(s))", migration.ErrDrift, blocking)
+	}
+	return nil
+}
+
+// targetTenants returns the tenant selected by -tenant, or all tenants
+func targetTenants(ctx context.Context, migrator *migration.Migrator, opts migrateOptions) ([]uint, error) {
+	if opts.tenantID > 0 {
+		return []uint{opts.tenantID}, nil
+	}
+	return migrator.Tenants(ctx)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// executedBy identifies who ran the CLI in migration records
+func executedBy() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+	return "cli"
+}
+
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	if checksum == "" {
+		return "-"
+	}
+	return checksum
+}
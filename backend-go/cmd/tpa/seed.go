@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"github.com/bank-melli/tpa/internal/infrastructure/database"
+	"github.com/bank-melli/tpa/internal/infrastructure/database/seed"
+)
+
+const seedUsageText = `Usage: tpa seed [flags]
+
+Upserts roles, permissions, lookups and (in development) demo tenants and
+users from fixture files. Safe to run repeatedly.
+
+Flags:`
+
+func runSeed(args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := fs.String("env", "", "environment whose fixtures are loaded (default: APP_ENV)")
+	dir := fs.String("dir", "", "read fixtures from this directory instead of the built-in set")
+	dryRun := fs.Bool("dry-run", false, "apply fixtures in a transaction and roll it back")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, seedUsageText)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := seedDatabase(ctx, *env, *dir, *dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "tpa seed: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func seedDatabase(ctx context.Context, env, dir string, dryRun bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if env == "" {
+		env = cfg.App.Environment
+	}
+
+	db, err := database.NewDatabase(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	seedConfig := seed.SeederConfig{Environment: env, DryRun: dryRun}
+	if dir != "" {
+		seedConfig.Fixtures = os.DirFS(dir)
+	}
+
+	summary, err := seed.NewSeeder(db.DB, seedConfig).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Environment %s: %d file(s)\n", env, len(summary.Files))
+	fmt.Printf("  roles             %d\n", summary.Roles)
+	fmt.Printf("  permissions       %d\n", summary.Permissions)
+	fmt.Printf("  role permissions  %d\n", summary.RolePermissions)
+	fmt.Printf("  lookup rows       %d\n", summary.LookupRows)
+	fmt.Printf("  tenants           %d\n", summary.Tenants)
+	fmt.Printf("  users             %d\n", summary.Users)
+	if dryRun {
+		fmt.Println("Dry run: all changes were rolled back")
+	}
+	return nil
+}
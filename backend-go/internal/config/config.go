@@ -4,17 +4,21 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
-	CORS     CORSConfig
-	External ExternalConfig
+	App       AppConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Redis     RedisConfig
+	CORS      CORSConfig
+	External  ExternalConfig
+	RateLimit RateLimitConfig
+	Claims    ClaimsConfig
+	Storage   StorageConfig
 }
 
 // AppConfig holds application-specific configuration
@@ -25,6 +29,7 @@ type AppConfig struct {
 	Debug       bool
 	BaseURL     string
 	APIPrefix   string
+	MetricsAddr string // internal listener for /metrics; empty disables it
 }
 
 // DatabaseConfig holds database configuration
@@ -38,6 +43,20 @@ type DatabaseConfig struct {
 	MaxIdleConns int
 	MaxOpenConns int
 	MaxLifetime  time.Duration
+
+	// MigrationDriftMode is "fail" or "warn" when an applied migration changed
+	MigrationDriftMode string
+
+	// ReplicaDSNs are optional read replicas; reads fall back to the primary
+	// when none is healthy or a replica lags more than ReplicaMaxLag
+	ReplicaDSNs          []string
+	ReplicaMaxLag        time.Duration
+	ReplicaCheckInterval time.Duration
+
+	// SlowQueryThreshold logs statements slower than this (0 disables);
+	// NPlusOneThreshold flags a request repeating one SELECT this many times
+	SlowQueryThreshold time.Duration
+	NPlusOneThreshold  int
 }
 
 // JWTConfig holds JWT configuration
@@ -56,6 +75,70 @@ type RedisConfig struct {
 	DB       int
 }
 
+// RateLimitConfig holds rate limiting and usage quota configuration
+type RateLimitConfig struct {
+	Enabled bool
+	Backend string // memory, redis
+
+	// Token bucket per scope: Rate is tokens per second, Burst is bucket size (0 disables the scope)
+	TenantRate  float64
+	TenantBurst int
+	UserRate    float64
+	UserBurst   int
+	IPRate      float64
+	IPBurst     int
+
+	// TenantOverrides holds per-tenant "rate/burst" values, e.g. "2=50/100,7=5/10"
+	TenantOverrides map[uint]RateLimitOverride
+
+	// Monthly quotas on expensive operations (0 means unlimited)
+	MonthlyImportQuota       int64
+	MonthlyReportExportQuota int64
+}
+
+// RateLimitOverride is a per-tenant token bucket override
+type RateLimitOverride struct {
+	Rate  float64
+	Burst int
+}
+
+// ClaimsConfig holds claim processing configuration
+type ClaimsConfig struct {
+	// DuplicateWindow is the default look-back window of duplicate detection
+	// (0 means the same day); DuplicateWindows overrides it per claim type,
+	// e.g. "1=168h,5=72h"
+	DuplicateWindow  time.Duration
+	DuplicateWindows map[uint8]time.Duration
+	// SLACheckInterval is how often waiting claims are checked against their
+	// SLA; zero disables the checker
+	SLACheckInterval time.Duration
+}
+
+// StorageConfig holds attachment storage configuration
+type StorageConfig struct {
+	Backend   string // local, s3
+	LocalPath string
+
+	// S3 or an S3-compatible service such as MinIO; Endpoint includes the
+	// scheme, and PathStyle puts the bucket in the path instead of the host
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+
+	MaxUploadSize    int64    // bytes; requests are also capped by the server body limit (50MB)
+	AllowedMIMETypes []string // sniffed types accepted unless an attachment type narrows them
+
+	// Download links are signed with SigningKey, required outside development, and expire after URLExpiry
+	SigningKey string
+	URLExpiry  time.Duration
+
+	// ClamdAddr is the clamd TCP address uploads are scanned with; empty skips scanning
+	ClamdAddr string
+}
+
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
 	AllowedOrigins []string
@@ -103,6 +186,7 @@ func Load() (*Config, error) {
 			Debug:       getEnvAsBool("APP_DEBUG", true),
 			BaseURL:     getEnv("APP_BASE_URL", "http://localhost:8080"),
 			APIPrefix:   getEnv("API_PREFIX", "/api/v1"),
+			MetricsAddr: getEnv("METRICS_ADDR", "127.0.0.1:9090"),
 		},
 		Database: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
@@ -114,6 +198,15 @@ func Load() (*Config, error) {
 			MaxIdleConns: getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
 			MaxOpenConns: getEnvAsInt("DB_MAX_OPEN_CONNS", 100),
 			MaxLifetime:  getEnvAsDuration("DB_MAX_LIFETIME", 1*time.Hour),
+
+			MigrationDriftMode: getEnv("DB_MIGRATION_DRIFT_MODE", "fail"),
+
+			ReplicaDSNs:          getEnvAsSlice("DB_REPLICA_DSNS", nil),
+			ReplicaMaxLag:        getEnvAsDuration("DB_REPLICA_MAX_LAG", 5*time.Second),
+			ReplicaCheckInterval: getEnvAsDuration("DB_REPLICA_CHECK_INTERVAL", 5*time.Second),
+
+			SlowQueryThreshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+			NPlusOneThreshold:  getEnvAsInt("DB_N_PLUS_ONE_THRESHOLD", 10),
 		},
 		JWT: JWTConfig{
 			Secret:           getEnv("JWT_SECRET", "change-this-secret-in-production"),
@@ -133,6 +226,42 @@ func Load() (*Config, error) {
 			AllowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID"},
 			MaxAge:         86400,
 		},
+		RateLimit: RateLimitConfig{
+			Enabled:                  getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Backend:                  getEnv("RATE_LIMIT_BACKEND", "memory"),
+			TenantRate:               getEnvAsFloat("RATE_LIMIT_TENANT_RATE", 50),
+			TenantBurst:              getEnvAsInt("RATE_LIMIT_TENANT_BURST", 100),
+			UserRate:                 getEnvAsFloat("RATE_LIMIT_USER_RATE", 10),
+			UserBurst:                getEnvAsInt("RATE_LIMIT_USER_BURST", 20),
+			IPRate:                   getEnvAsFloat("RATE_LIMIT_IP_RATE", 20),
+			IPBurst:                  getEnvAsInt("RATE_LIMIT_IP_BURST", 40),
+			TenantOverrides:          getEnvAsRateOverrides("RATE_LIMIT_TENANT_OVERRIDES"),
+			MonthlyImportQuota:       int64(getEnvAsInt("QUOTA_MONTHLY_IMPORTS", 0)),
+			MonthlyReportExportQuota: int64(getEnvAsInt("QUOTA_MONTHLY_REPORT_EXPORTS", 0)),
+		},
+		Claims: ClaimsConfig{
+			DuplicateWindow:  getEnvAsDuration("CLAIM_DUPLICATE_WINDOW", 0),
+			DuplicateWindows: getEnvAsDurationMap("CLAIM_DUPLICATE_WINDOWS"),
+			SLACheckInterval: getEnvAsDuration("CLAIM_SLA_CHECK_INTERVAL", 5*time.Minute),
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", "local"),
+			LocalPath:   getEnv("STORAGE_LOCAL_PATH", "./storage"),
+			S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("STORAGE_S3_BUCKET", "tpa-attachments"),
+			S3AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvAsBool("STORAGE_S3_PATH_STYLE", false),
+
+			MaxUploadSize:    int64(getEnvAsInt("STORAGE_MAX_UPLOAD_MB", 10)) << 20,
+			AllowedMIMETypes: getEnvAsSlice("STORAGE_ALLOWED_MIME_TYPES", []string{"application/pdf", "image/jpeg", "image/png", "image/tiff"}),
+
+			SigningKey: getEnv("STORAGE_SIGNING_KEY", ""),
+			URLExpiry:  getEnvAsDuration("STORAGE_URL_EXPIRY", 15*time.Minute),
+
+			ClamdAddr: getEnv("STORAGE_CLAMD_ADDR", ""),
+		},
 		External: ExternalConfig{
 			Tamin: TaminConfig{
 				BaseURL:  getEnv("TAMIN_BASE_URL", ""),
@@ -187,6 +316,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -222,3 +360,53 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getEnvAsRateOverrides parses "tenantID=rate/burst" pairs separated by commas
+func getEnvAsRateOverrides(key string) map[uint]RateLimitOverride {
+	overrides := make(map[uint]RateLimitOverride)
+	for _, pair := range getEnvAsSlice(key, nil) {
+		id, limit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		rate, burst, ok := strings.Cut(limit, "/")
+		if !ok {
+			continue
+		}
+		tenantID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			continue
+		}
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			continue
+		}
+		b, err := strconv.Atoi(burst)
+		if err != nil {
+			continue
+		}
+		overrides[uint(tenantID)] = RateLimitOverride{Rate: r, Burst: b}
+	}
+	return overrides
+}
+
+// getEnvAsDurationMap parses "key=duration" pairs separated by commas
+func getEnvAsDurationMap(key string) map[uint8]time.Duration {
+	durations := make(map[uint8]time.Duration)
+	for _, pair := range getEnvAsSlice(key, nil) {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		id, err := strconv.ParseUint(k, 10, 8)
+		if err != nil {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			continue
+		}
+		durations[uint8(id)] = d
+	}
+	return durations
+}
@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/attachment"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttachmentHandler struct {
+	attachments *attachment.Service
+	repo        repository.AttachmentRepository
+	claims      repository.ClaimRepository
+	packages    repository.PackageRepository
+	roles       repository.RoleRepository
+	downloadURL string // download route prefix the token is appended to
+}
+
+func NewAttachmentHandler(attachments *attachment.Service, repo repository.AttachmentRepository, claims repository.ClaimRepository, packages repository.PackageRepository, roles repository.RoleRepository, downloadURL string) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachments: attachments,
+		repo:        repo,
+		claims:      claims,
+		packages:    packages,
+		roles:       roles,
+		downloadURL: downloadURL,
+	}
+}
+
+// ListAttachmentTypes - انواع پیوست قابل قبول برای پرونده‌های یک نوع یا بسته‌ها
+// GET /api/v1/attachments/types?owner=claim&claim_type=1
+func (h *AttachmentHandler) ListAttachmentTypes(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	owner := entity.AttachmentOwner(c.Query("owner", string(entity.AttachmentOwnerClaim)))
+	if !owner.IsValid() {
+		return fail(c, fiber.StatusBadRequest, "مالک پیوست باید claim یا package باشد")
+	}
+	claimType := entity.ClaimType(c.QueryInt("claim_type"))
+	if owner == entity.AttachmentOwnerPackage {
+		claimType = 0
+	} else if claimType != 0 && !claimType.IsValid() {
+		return fail(c, fiber.StatusBadRequest, "نوع پرونده نامعتبر است")
+	}
+
+	types, err := h.attachments.Types(c.UserContext(), tenantID, owner, claimType)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    types,
+	})
+}
+
+// SaveAttachmentType - تعریف یا تغییر یک نوع پیوست
+// PUT /api/v1/attachments/types
+func (h *AttachmentHandler) SaveAttachmentType(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var req SaveAttachmentTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	req.Title = strings.TrimSpace(req.Title)
+	if msg := validateAttachmentType(&req); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	t := &entity.AttachmentType{
+		TenantID:  tenantID,
+		Owner:     req.Owner,
+		ClaimType: req.ClaimType,
+		Code:      req.Code,
+		Title:     req.Title,
+		Required:  req.Required,
+		MaxSize:   req.MaxSize,
+		MIMETypes: strings.Join(req.MIMETypes, ","),
+		IsActive:  req.IsActive == nil || *req.IsActive,
+	}
+	t.CreatedBy = actor.UserID
+	t.UpdatedBy = actor.UserID
+	if err := h.repo.SaveType(c.UserContext(), t); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "نوع پیوست " + t.Title + " ذخیره شد",
+		"data":    t,
+	})
+}
+
+// DeleteAttachmentType - حذف نوع پیوست
+// DELETE /api/v1/attachments/types/:id
+func (h *AttachmentHandler) DeleteAttachmentType(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid attachment type id")
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	if err := h.repo.DeleteType(c.UserContext(), tenantID, uint(id)); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "نوع پیوست حذف شد",
+	})
+}
+
+// ListClaimAttachments - پیوست‌های پرونده و مدارک الزامی ارسال نشده
+// GET /api/v1/claims/:id/attachments
+func (h *AttachmentHandler) ListClaimAttachments(c *fiber.Ctx) error {
+	return h.list(c, entity.AttachmentOwnerClaim)
+}
+
+// UploadClaimAttachment - بارگذاری پیوست پرونده (multipart: file, type)
+// POST /api/v1/claims/:id/attachments
+func (h *AttachmentHandler) UploadClaimAttachment(c *fiber.Ctx) error {
+	return h.upload(c, entity.AttachmentOwnerClaim)
+}
+
+// ListPackageAttachments - پیوست‌های بسته
+// GET /api/v1/packages/:id/attachments
+func (h *AttachmentHandler) ListPackageAttachments(c *fiber.Ctx) error {
+	return h.list(c, entity.AttachmentOwnerPackage)
+}
+
+// UploadPackageAttachment - بارگذاری پیوست بسته، مثل تصویر نامه ارسال
+// POST /api/v1/packages/:id/attachments
+func (h *AttachmentHandler) UploadPackageAttachment(c *fiber.Ctx) error {
+	return h.upload(c, entity.AttachmentOwnerPackage)
+}
+
+// GetAttachmentURL - لینک موقت دریافت فایل
+// GET /api/v1/attachments/:id/url
+func (h *AttachmentHandler) GetAttachmentURL(c *fiber.Ctx) error {
+	att, err := h.find(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	token, expires := h.attachments.SignURL(att)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"url":        h.downloadURL + token,
+			"expires_at": expires,
+		},
+	})
+}
+
+// DeleteAttachment - حذف پیوست توسط بارگذار یا کاربر دارای مجوز ویرایش
+// DELETE /api/v1/attachments/:id
+func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	att, err := h.find(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if userID(c) == 0 {
+		return respondError(c, errUnauthenticated)
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	perm := entity.PermClaimUpdate
+	if att.Owner == entity.AttachmentOwnerPackage {
+		perm = entity.PermPackageUpdate
+	}
+	if att.CreatedBy != actor.UserID && !actor.Can(perm) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(perm)+" لازم است")
+	}
+
+	if err := h.attachments.Delete(c.UserContext(), att); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پیوست " + att.FileName + " حذف شد",
+	})
+}
+
+// DownloadAttachment serves the file a signed token names. The token carries
+// the tenant, so the route needs neither auth nor X-Tenant-ID.
+// GET /api/v1/files/:token
+func DownloadAttachment(attachments *attachment.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		att, err := attachments.Resolve(ctx, c.Params("token"))
+		if err != nil {
+			return respondError(c, err)
+		}
+		body, err := attachments.Open(ctx, att)
+		if err != nil {
+			return respondError(c, err)
+		}
+
+		c.Set(fiber.HeaderContentType, att.ContentType)
+		c.Set(fiber.HeaderContentDisposition, "inline; filename*=UTF-8''"+url.PathEscape(att.FileName))
+		c.Set(fiber.HeaderETag, `"`+att.Checksum+`"`)
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		// The body is closed once sent
+		return c.SendStream(body, int(att.Size))
+	}
+}
+
+// list returns the attachments of a claim or package with the types it takes
+func (h *AttachmentHandler) list(c *fiber.Ctx, owner entity.AttachmentOwner) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	ownerID, claimType, err := h.owner(c, tenantID, owner)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	ctx := c.UserContext()
+	attachments, err := h.repo.FindByOwner(ctx, tenantID, owner, ownerID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	types, err := h.attachments.Types(ctx, tenantID, owner, claimType)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"attachments": attachments,
+			"types":       types,
+			"missing":     attachment.Missing(types, attachments),
+		},
+	})
+}
+
+func (h *AttachmentHandler) upload(c *fiber.Ctx, owner entity.AttachmentOwner) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	// The uploader may delete the file later, so uploads must name one
+	uploader := userID(c)
+	if uploader == 0 {
+		return respondError(c, errUnauthenticated)
+	}
+	ownerID, claimType, err := h.owner(c, tenantID, owner)
+	if err != nil {
+		return respondError(c, err)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "فایل ارسال نشده است")
+	}
+	typeCode := strings.TrimSpace(c.FormValue("type"))
+	if typeCode == "" {
+		return fail(c, fiber.StatusBadRequest, "نوع پیوست الزامی است")
+	}
+	body, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	att, err := h.attachments.Upload(c.UserContext(), attachment.Upload{
+		TenantID:  tenantID,
+		Owner:     owner,
+		OwnerID:   ownerID,
+		ClaimType: claimType,
+		TypeCode:  typeCode,
+		FileName:  file.Filename,
+		Body:      body,
+		UserID:    uploader,
+	})
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "فایل " + att.FileName + " بارگذاری شد",
+		"data":    att,
+	})
+}
+
+// owner checks the claim or package in the path exists and returns its ID
+// with the claim type its attachment types depend on
+func (h *AttachmentHandler) owner(c *fiber.Ctx, tenantID uint, owner entity.AttachmentOwner) (uint, entity.ClaimType, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "invalid "+string(owner)+" id")
+	}
+	opts := repository.QueryOptions{TenantID: tenantID}
+	if owner == entity.AttachmentOwnerPackage {
+		if _, err := h.packages.FindByID(c.UserContext(), uint(id), opts); err != nil {
+			return 0, 0, err
+		}
+		return uint(id), 0, nil
+	}
+	claim, err := h.claims.FindByID(c.UserContext(), uint(id), opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	return claim.ID, claim.ClaimType, nil
+}
+
+// find returns the attachment in the path
+func (h *AttachmentHandler) find(c *fiber.Ctx) (*entity.Attachment, error) {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid attachment id")
+	}
+	att, err := h.repo.FindByID(c.UserContext(), uint(id), repository.QueryOptions{TenantID: tenantID})
+	if err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// validateAttachmentType returns a user facing message for the first invalid field
+func validateAttachmentType(req *SaveAttachmentTypeRequest) string {
+	switch {
+	case !req.Owner.IsValid():
+		return "مالک پیوست باید claim یا package باشد"
+	case req.Owner == entity.AttachmentOwnerPackage && req.ClaimType != 0:
+		return "انواع پیوست بسته به نوع پرونده وابسته نیستند"
+	case req.ClaimType != 0 && !req.ClaimType.IsValid():
+		return "نوع پرونده نامعتبر است"
+	case req.Code == "" || len(req.Code) > 50:
+		return "کد نوع پیوست الزامی است و حداکثر ۵۰ کاراکتر دارد"
+	case req.Title == "":
+		return "عنوان نوع پیوست الزامی است"
+	case req.MaxSize < 0:
+		return "سقف حجم نمی‌تواند منفی باشد"
+	}
+	for _, t := range req.MIMETypes {
+		if !strings.Contains(t, "/") || strings.ContainsAny(t, ", ") {
+			return "نوع فایل " + t + " نامعتبر است"
+		}
+	}
+	return ""
+}
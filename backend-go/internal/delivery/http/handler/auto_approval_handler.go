@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/autoapprove"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAutoApprovalPolicy - تنظیمات تایید خودکار پرونده‌های کم‌مبلغ
+// GET /api/v1/claims/auto-approval
+func (h *ClaimHandler) GetAutoApprovalPolicy(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	policy, err := autoapprove.LoadPolicy(c.UserContext(), h.settings, tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdateAutoApprovalPolicy - تغییر سقف تایید خودکار و درصد نمونه‌گیری
+// PUT /api/v1/claims/auto-approval
+func (h *ClaimHandler) UpdateAutoApprovalPolicy(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	// The policy decides which claims skip human review
+	if _, err := authorize(c, h.roles, entity.PermSettingsUpdate); err != nil {
+		return respondError(c, err)
+	}
+	var req UpdateAutoApprovalPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+
+	ctx := c.UserContext()
+	policy, err := autoapprove.LoadPolicy(ctx, h.settings, tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if req.Threshold != nil {
+		policy.Threshold = *req.Threshold
+	}
+	if req.Thresholds != nil {
+		policy.Thresholds = req.Thresholds
+	}
+	if req.SampleRate != nil {
+		policy.SampleRate = *req.SampleRate
+	}
+	if msg := policy.Validate(); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	if err := autoapprove.SavePolicy(ctx, h.settings, tenantID, policy); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "تنظیمات تایید خودکار ذخیره شد",
+		"data":    policy,
+	})
+}
+
+// ReviewSampledClaim - ثبت بازبینی پرونده تایید خودکاری که در نمونه قرار گرفته است
+// POST /api/v1/claims/:id/sample-review
+// Pending samples: GET /api/v1/claims?filter[sample_review]=pending
+func (h *ClaimHandler) ReviewSampledClaim(c *fiber.Ctx) error {
+	var req ReviewSampleRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+		}
+	}
+
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimApprove) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimApprove)+" لازم است")
+	}
+	if claim.SampleReview != entity.SampleReviewPending {
+		return fail(c, fiber.StatusConflict, "پرونده در انتظار بازبینی نمونه نیست")
+	}
+
+	now := h.now()
+	reviewer := actor.UserID
+	claim.SampleReview = entity.SampleReviewDone
+	claim.ReviewedBy = &reviewer
+	claim.ReviewedAt = &now
+	claim.ReviewNotes = strings.TrimSpace(req.Notes)
+	if err := h.claims.Update(c.UserContext(), claim); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "بازبینی پرونده ثبت شد",
+		"data":    claim,
+	})
+}
@@ -0,0 +1,426 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/assignment"
+	"github.com/bank-melli/tpa/internal/domain/autoapprove"
+	"github.com/bank-melli/tpa/internal/domain/duplicate"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ClaimHandler struct {
+	claims     repository.ClaimRepository
+	employees  repository.EmployeeRepository
+	centers    repository.CenterRepository
+	roles      repository.RoleRepository
+	reasons    repository.ReasonCodeRepository
+	duplicates *duplicate.Detector
+	ruleSets   repository.RuleSetRepository
+	evaluator  *rules.Evaluator
+	settings   repository.TenantSettingRepository
+	approver   *autoapprove.Approver
+	queues     repository.WorkQueueRepository
+	assigner   *assignment.Assigner
+	machine    *workflow.ClaimMachine
+	now        func() time.Time
+}
+
+func NewClaimHandler(claims repository.ClaimRepository, employees repository.EmployeeRepository, centers repository.CenterRepository, roles repository.RoleRepository, reasons repository.ReasonCodeRepository, duplicates *duplicate.Detector, ruleSets repository.RuleSetRepository, evaluator *rules.Evaluator, settings repository.TenantSettingRepository, queues repository.WorkQueueRepository) *ClaimHandler {
+	machine := workflow.NewClaimMachine()
+	return &ClaimHandler{
+		claims:     claims,
+		employees:  employees,
+		centers:    centers,
+		roles:      roles,
+		reasons:    reasons,
+		duplicates: duplicates,
+		ruleSets:   ruleSets,
+		evaluator:  evaluator,
+		settings:   settings,
+		approver:   autoapprove.NewApprover(settings, duplicates, machine),
+		queues:     queues,
+		assigner:   assignment.NewAssigner(queues),
+		machine:    machine,
+		now:        time.Now,
+	}
+}
+
+// ListClaims - لیست پرونده‌های خسارت
+// GET /api/v1/claims?filter[status][in]=3,4&sort=-created_at&page=1
+// GET /api/v1/claims?cursor=&page_size=50&total=estimate
+func (h *ClaimHandler) ListClaims(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.ClaimFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	claims, pagination, err := listPage(c.UserContext(), h.claims, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"claims":     claims,
+			"pagination": pagination,
+		},
+	})
+}
+
+// GetClaim - جزئیات پرونده با اقلام و تشخیص‌ها
+// GET /api/v1/claims/:id
+func (h *ClaimHandler) GetClaim(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c, repository.ClaimDetails...)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    claim,
+	})
+}
+
+// CreateClaim - ثبت پرونده خسارت جدید
+// POST /api/v1/claims
+func (h *ClaimHandler) CreateClaim(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateClaimRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	req.TrackingCode = strings.TrimSpace(req.TrackingCode)
+	if msg := validateCreateClaim(&req); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+
+	ctx := c.UserContext()
+	scope := repository.QueryOptions{TenantID: tenantID}
+	if _, err := h.employees.FindByID(ctx, req.PolicyMemberID, scope); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fail(c, fiber.StatusUnprocessableEntity, "بیمه‌شده یافت نشد")
+		}
+		return err
+	}
+	if _, err := h.centers.FindByID(ctx, req.CenterID, scope); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fail(c, fiber.StatusUnprocessableEntity, "مرکز درمانی یافت نشد")
+		}
+		return err
+	}
+	if _, err := h.claims.FindByTrackingCode(ctx, tenantID, req.TrackingCode); err == nil {
+		return fail(c, fiber.StatusConflict, "کد رهگیری تکراری است")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	claim := newClaim(tenantID, userID(c), &req)
+	// Duplicates do not block registration; they are reported to the center
+	// now and to the examiner through /claims/:id/duplicates
+	duplicates, err := h.duplicates.Check(ctx, claim)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if err := h.claims.Create(ctx, claim); err != nil {
+		return respondError(c, err)
+	}
+
+	resp := fiber.Map{
+		"success": true,
+		"message": "پرونده با موفقیت ثبت شد",
+		"data":    claim,
+	}
+	if len(duplicates.Findings) > 0 {
+		resp["duplicates"] = duplicates
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetClaimDuplicates - موارد تکراری یا همپوشان پرونده با کسورات پیشنهادی
+// GET /api/v1/claims/:id/duplicates
+func (h *ClaimHandler) GetClaimDuplicates(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c, "Items")
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	report, err := h.duplicates.Check(c.UserContext(), claim)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// UpdateClaim - ویرایش مبالغ پرونده (فقط پیش از ارسال برای ارزیابی)
+// PUT /api/v1/claims/:id
+func (h *ClaimHandler) UpdateClaim(c *fiber.Ctx) error {
+	var req UpdateClaimRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+
+	claim, err := h.findClaim(c, "Items")
+	if err != nil {
+		return respondError(c, err)
+	}
+	if !claim.IsEditable() {
+		return fail(c, fiber.StatusConflict, "پرونده در وضعیت "+claim.Status.String()+" قابل ویرایش نیست")
+	}
+
+	if req.RequestAmount != nil {
+		claim.RequestAmount = *req.RequestAmount
+	}
+	if req.BasicInsShare != nil {
+		claim.BasicInsShare = *req.BasicInsShare
+	}
+	if msg := validateAmounts(claim.RequestAmount, claim.BasicInsShare); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	if len(claim.Items) > 0 {
+		var itemsTotal int64
+		for _, item := range claim.Items {
+			itemsTotal += item.RequestAmount
+		}
+		if claim.RequestAmount != itemsTotal {
+			return fail(c, fiber.StatusBadRequest, "مبلغ درخواستی با جمع اقلام برابر نیست")
+		}
+	}
+	claim.UpdatedBy = userID(c)
+
+	// Only the amounts are written, and only while the claim is in the
+	// status it was loaded in; a concurrent submit gets a 409 here
+	if err := h.claims.UpdateAmounts(c.UserContext(), claim, claim.Status); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پرونده با موفقیت به‌روزرسانی شد",
+		"data":    claim,
+	})
+}
+
+// DeleteClaim - حذف (soft delete) پرونده
+// DELETE /api/v1/claims/:id
+func (h *ClaimHandler) DeleteClaim(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if !claim.IsEditable() {
+		return fail(c, fiber.StatusConflict, "پرونده در وضعیت "+claim.Status.String()+" قابل حذف نیست")
+	}
+
+	if err := h.claims.Delete(c.UserContext(), claim.TenantID, claim.ID); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پرونده با موفقیت حذف شد",
+	})
+}
+
+// GetClaimStats - آمار پرونده‌ها بر اساس وضعیت، نوع و مبالغ
+// GET /api/v1/claims/stats?filter[service_date][gte]=2024-03-20
+func (h *ClaimHandler) GetClaimStats(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.ClaimFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	ctx := c.UserContext()
+	byStatus, err := h.claims.GetStatsByStatus(ctx, tenantID, opts.Filters)
+	if err != nil {
+		return respondError(c, err)
+	}
+	byType, err := h.claims.GetStatsByType(ctx, tenantID, opts.Filters)
+	if err != nil {
+		return respondError(c, err)
+	}
+	requested, approved, deduction, err := h.claims.GetTotalAmounts(ctx, tenantID, opts.Filters)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	stats := ClaimStatsDTO{
+		ByStatus:        make(map[string]int64, len(byStatus)),
+		ByType:          make(map[string]int64, len(byType)),
+		PendingReview:   byStatus[entity.ClaimStatusWaitCheck] + byStatus[entity.ClaimStatusWaitCheckAgain],
+		PendingApproval: byStatus[entity.ClaimStatusWaitCheckConfirm],
+	}
+	for status, n := range byStatus {
+		stats.ByStatus[strconv.Itoa(int(status))] = n
+		stats.Total += n
+	}
+	for claimType, n := range byType {
+		stats.ByType[strconv.Itoa(int(claimType))] = n
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"claims": stats,
+			"amounts": AmountStatsDTO{
+				TotalRequested: requested,
+				TotalApproved:  approved,
+				TotalDeduction: deduction,
+			},
+		},
+	})
+}
+
+// findClaim loads the :id claim of the current tenant
+func (h *ClaimHandler) findClaim(c *fiber.Ctx, preloads ...string) (*entity.Claim, error) {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid claim id")
+	}
+	return h.claims.FindByID(c.UserContext(), uint(id), repository.QueryOptions{
+		TenantID: tenantID,
+		Preloads: preloads,
+	})
+}
+
+// validateCreateClaim returns a user facing message for the first invalid field
+func validateCreateClaim(req *CreateClaimRequest) string {
+	switch {
+	case req.TrackingCode == "":
+		return "کد رهگیری الزامی است"
+	case req.PolicyMemberID == 0:
+		return "بیمه‌شده الزامی است"
+	case req.CenterID == 0:
+		return "مرکز درمانی الزامی است"
+	case !req.ClaimType.IsValid():
+		return "نوع پرونده نامعتبر است"
+	case req.AdmissionDate.IsZero():
+		return "تاریخ پذیرش الزامی است"
+	case req.ServiceDate.IsZero():
+		return "تاریخ خدمت الزامی است"
+	case req.DischargeDate != nil && req.DischargeDate.Before(req.AdmissionDate):
+		return "تاریخ ترخیص نمی‌تواند قبل از تاریخ پذیرش باشد"
+	}
+
+	var itemsTotal int64
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.Quantity == 0 {
+			item.Quantity = 1
+		}
+		if item.RequestAmount == 0 {
+			item.RequestAmount = int64(item.Quantity) * item.UnitPrice
+		}
+		if item.Quantity < 0 || item.UnitPrice < 0 || item.RequestAmount <= 0 {
+			return "مبلغ قلم " + strconv.Itoa(i+1) + " نامعتبر است"
+		}
+		if msg := validateAmounts(item.RequestAmount, item.BasicInsShare); msg != "" {
+			return "قلم " + strconv.Itoa(i+1) + ": " + msg
+		}
+		itemsTotal += item.RequestAmount
+	}
+	if len(req.Items) > 0 {
+		if req.RequestAmount == 0 {
+			req.RequestAmount = itemsTotal
+		} else if req.RequestAmount != itemsTotal {
+			return "مبلغ درخواستی با جمع اقلام برابر نیست"
+		}
+	}
+
+	for i, d := range req.Diagnoses {
+		if strings.TrimSpace(d.ICD10Code) == "" {
+			return "کد ICD-10 تشخیص " + strconv.Itoa(i+1) + " الزامی است"
+		}
+	}
+	return validateAmounts(req.RequestAmount, req.BasicInsShare)
+}
+
+func validateAmounts(request, basicInsShare int64) string {
+	switch {
+	case request < 0 || basicInsShare < 0:
+		return "مبالغ نمی‌توانند منفی باشند"
+	case basicInsShare > request:
+		return "سهم بیمه پایه نمی‌تواند بیشتر از مبلغ درخواستی باشد"
+	}
+	return ""
+}
+
+// newClaim builds a WaitRegister claim with its items and diagnoses from a validated request
+func newClaim(tenantID, userID uint, req *CreateClaimRequest) *entity.Claim {
+	claim := &entity.Claim{
+		TrackingCode:   req.TrackingCode,
+		HID:            req.HID,
+		MRN:            req.MRN,
+		PolicyMemberID: req.PolicyMemberID,
+		CenterID:       req.CenterID,
+		ClaimType:      req.ClaimType,
+		Status:         entity.ClaimStatusWaitRegister,
+		AdmissionType:  req.AdmissionType,
+		AdmissionDate:  req.AdmissionDate,
+		DischargeDate:  req.DischargeDate,
+		ServiceDate:    req.ServiceDate,
+		RequestAmount:  req.RequestAmount,
+		BasicInsShare:  req.BasicInsShare,
+		Notes:          req.Notes,
+	}
+	claim.TenantID = tenantID
+	claim.CreatedBy = userID
+	claim.UpdatedBy = userID
+
+	for _, item := range req.Items {
+		claim.Items = append(claim.Items, entity.ClaimItem{
+			TenantID:      tenantID,
+			ItemID:        item.ItemID,
+			BodySiteID:    item.BodySiteID,
+			ServiceCode:   item.ServiceCode,
+			Title:         item.Title,
+			ServiceDate:   item.ServiceDate,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			RequestAmount: item.RequestAmount,
+			BasicInsShare: item.BasicInsShare,
+			Dosage:        item.Dosage,
+			Frequency:     item.Frequency,
+			Duration:      item.Duration,
+			Notes:         item.Notes,
+		})
+	}
+	for _, d := range req.Diagnoses {
+		claim.Diagnoses = append(claim.Diagnoses, entity.ClaimDiagnosis{
+			TenantID:  tenantID,
+			ICD10Code: strings.ToUpper(strings.TrimSpace(d.ICD10Code)),
+			Title:     d.Title,
+			IsPrimary: d.IsPrimary,
+		})
+	}
+	return claim
+}
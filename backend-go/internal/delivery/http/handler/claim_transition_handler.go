@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"errors"
+	"log"
+
+	"github.com/bank-melli/tpa/internal/domain/autoapprove"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// factPreloads are the relations the rules' claim fact is built from
+var factPreloads = []string{"Items", "Diagnoses", "PolicyMember"}
+
+// SubmitClaim - ارسال پرونده برای ارزیابی
+// POST /api/v1/claims/:id/submit
+func (h *ClaimHandler) SubmitClaim(c *fiber.Ctx) error {
+	var req TransitionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+		}
+	}
+	return h.transition(c, workflow.ActionSubmit, req.Reason)
+}
+
+// ExamineClaim - ثبت نتیجه ارزیابی اقلام (مبلغ تایید شده، کسورات و کد علت)
+// POST /api/v1/claims/:id/examine
+func (h *ClaimHandler) ExamineClaim(c *fiber.Ctx) error {
+	var req CompleteExaminationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	exam := workflow.Examination{
+		ApprovedAmount:  req.ApprovedAmount,
+		Deduction:       req.Deduction,
+		DeductionReason: req.DeductionReason,
+		ReasonCodeIDs:   req.ReasonCodeIDs,
+	}
+	for _, item := range req.Items {
+		exam.Items = append(exam.Items, workflow.ItemExamination{
+			ItemID:         item.ItemID,
+			ConfirmedPrice: item.ConfirmedPrice,
+			Deduction:      item.Deduction,
+			ReasonCodeIDs:  item.ReasonCodeIDs,
+			Notes:          item.Notes,
+		})
+	}
+
+	claim, err := h.findClaim(c, factPreloads...)
+	if err != nil {
+		return respondError(c, err)
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	// Status and permission come first, so a claim in the wrong status is
+	// refused as such rather than for its examination
+	if err := h.machine.Check(claim, workflow.ActionExamine, actor); err != nil {
+		return respondError(c, err)
+	}
+
+	// The tenant's rules deduct from the examiner's confirmed amounts
+	ctx := c.UserContext()
+	evaluation, err := h.evaluator.Evaluate(ctx, claim, &exam)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if evaluation != nil {
+		ids, err := h.reasonCodeIDs(c, claim.TenantID, evaluation.ReasonCodes())
+		if err != nil {
+			return respondError(c, err)
+		}
+		if err := evaluation.Apply(&exam, ids); err != nil {
+			return respondError(c, err)
+		}
+	}
+
+	found, err := h.reasons.FindActiveByIDs(ctx, claim.TenantID, exam.AllReasonCodeIDs())
+	if err != nil {
+		return respondError(c, err)
+	}
+	codes := make(map[uint]entity.ReasonCode, len(found))
+	for _, rc := range found {
+		codes[rc.ID] = rc
+	}
+
+	from := claim.Status
+	summary, err := workflow.ApplyExamination(claim, exam, codes)
+	if err != nil {
+		return respondError(c, err)
+	}
+	history, err := h.machine.Fire(claim, workflow.ActionExamine, workflow.Input{Actor: actor, Reason: req.Notes})
+	if err != nil {
+		return respondError(c, err)
+	}
+	if err := h.claims.Examine(ctx, claim, from, history); err != nil {
+		return respondError(c, err)
+	}
+
+	data := fiber.Map{
+		"claim":       claim,
+		"examination": summary,
+		"transition":  history,
+	}
+	if evaluation != nil {
+		data["rules"] = evaluation
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "نتیجه ارزیابی ثبت شد",
+		"data":    data,
+	})
+}
+
+// ApproveClaim - تایید ارزیابی و ارسال به مالی
+// POST /api/v1/claims/:id/approve
+func (h *ClaimHandler) ApproveClaim(c *fiber.Ctx) error {
+	var req TransitionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+		}
+	}
+	return h.transition(c, workflow.ActionApprove, req.Reason)
+}
+
+// ReexamineClaim - ارجاع پرونده برای ارزیابی مجدد
+// POST /api/v1/claims/:id/reexamine
+func (h *ClaimHandler) ReexamineClaim(c *fiber.Ctx) error {
+	var req RejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	return h.transition(c, workflow.ActionReexamine, req.Reason)
+}
+
+// ReturnClaim - عودت پرونده به مرکز درمانی
+// POST /api/v1/claims/:id/return
+func (h *ClaimHandler) ReturnClaim(c *fiber.Ctx) error {
+	var req RejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	return h.transition(c, workflow.ActionReturn, req.Reason)
+}
+
+// ListReasonCodes - کدهای علت کسورات بیمه‌گر
+// GET /api/v1/reason-codes?filter[is_active]=true
+func (h *ClaimHandler) ListReasonCodes(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.ReasonCodeFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	codes, pagination, err := listPage(c.UserContext(), h.reasons, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reason_codes": codes,
+			"pagination":   pagination,
+		},
+	})
+}
+
+// GetClaimHistory - سابقه تغییر وضعیت پرونده و اقدامات مجاز کاربر
+// GET /api/v1/claims/:id/history
+func (h *ClaimHandler) GetClaimHistory(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := h.claims.FindStatusHistory(c.UserContext(), claim.TenantID, claim.ID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"status":            claim.Status,
+			"history":           history,
+			"available_actions": h.machine.Available(claim, actor),
+		},
+	})
+}
+
+// transition fires action on the :id claim. Submitted claims are also run
+// through the tenant's rules, whose deductions only guide the examiner, and
+// low-value ones may be approved by the system right away; the others are
+// pushed to an examiner when their queue is in push mode.
+func (h *ClaimHandler) transition(c *fiber.Ctx, action workflow.Action, reason string) error {
+	submit := action == workflow.ActionSubmit
+	var preloads []string
+	if submit {
+		preloads = factPreloads
+	}
+	claim, err := h.findClaim(c, preloads...)
+	if err != nil {
+		return respondError(c, err)
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+
+	from := claim.Status
+	history, err := h.machine.Fire(claim, action, workflow.Input{Actor: actor, Reason: reason})
+	if err != nil {
+		return respondError(c, err)
+	}
+	ctx := c.UserContext()
+	var evaluation *rules.Evaluation
+	var decision *autoapprove.Decision
+	var approval []*entity.ClaimStatusHistory
+	if submit {
+		if evaluation, err = h.evaluator.Evaluate(ctx, claim, nil); err != nil {
+			return respondError(c, err)
+		}
+		if decision, approval, err = h.approver.Approve(ctx, claim, evaluation); err != nil {
+			return respondError(c, err)
+		}
+	}
+	if len(approval) > 0 {
+		err = h.claims.Examine(ctx, claim, from, append([]*entity.ClaimStatusHistory{history}, approval...)...)
+	} else {
+		err = h.claims.Transition(ctx, claim, from, history)
+	}
+	if err != nil {
+		return respondError(c, err)
+	}
+	// Claims left for an examiner enter their work queue; a failed push
+	// leaves the claim there to be pulled, so it does not fail the submission
+	var assigned *entity.ClaimAssignment
+	if submit && claim.Status == entity.ClaimStatusWaitCheck && claim.HandlerUserID == nil {
+		if assigned, err = h.assigner.Push(ctx, claim); err != nil {
+			log.Printf("claims: push claim %d to its work queue: %v", claim.ID, err)
+		}
+	}
+
+	data := fiber.Map{
+		"claim":      claim,
+		"transition": history,
+	}
+	if evaluation != nil {
+		data["rules"] = evaluation
+	}
+	if decision != nil {
+		data["auto_approval"] = decision
+	}
+	if len(approval) > 0 {
+		data["auto_transitions"] = approval
+	}
+	if assigned != nil {
+		data["assignment"] = assigned
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "وضعیت پرونده به " + claim.Status.String() + " تغییر کرد",
+		"data":    data,
+	})
+}
+
+// GetClaimRuleLogs - سابقه اجرای قوانین ارزیابی خودکار روی پرونده
+// GET /api/v1/claims/:id/rule-logs
+func (h *ClaimHandler) GetClaimRuleLogs(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	logs, err := h.ruleSets.FindExecutionLogs(c.UserContext(), claim.TenantID, claim.ID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    logs,
+	})
+}
+
+// reasonCodeIDs maps the tenant's active codes among codes to their IDs
+func (h *ClaimHandler) reasonCodeIDs(c *fiber.Ctx, tenantID uint, codes []string) (map[string]uint, error) {
+	ids := make(map[string]uint, len(codes))
+	for _, code := range codes {
+		rc, err := h.reasons.FindByCode(c.UserContext(), tenantID, code)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rc.IsActive {
+			ids[code] = rc.ID
+		}
+	}
+	return ids, nil
+}
+
+// actor resolves the permissions of the authenticated user's role
+func (h *ClaimHandler) actor(c *fiber.Ctx) (workflow.Actor, error) {
+	return resolveActor(c, h.roles)
+}
+
+// resolveActor resolves the permissions of the authenticated user's role.
+// A token without a role holds no permission.
+func resolveActor(c *fiber.Ctx, roles repository.RoleRepository) (workflow.Actor, error) {
+	role, _ := c.Locals("role_name").(string)
+	if role == "" {
+		return workflow.NewActor(userID(c)), nil
+	}
+	perms, err := roles.PermissionsOf(c.UserContext(), entity.RoleName(role))
+	if err != nil {
+		return workflow.Actor{}, err
+	}
+	return workflow.NewActor(userID(c), perms...), nil
+}
+
+// authorize resolves the signed-in user and refuses anonymous requests and
+// users whose role lacks perm
+func authorize(c *fiber.Ctx, roles repository.RoleRepository, perm entity.PermissionName) (workflow.Actor, error) {
+	if userID(c) == 0 {
+		return workflow.Actor{}, errUnauthenticated
+	}
+	actor, err := resolveActor(c, roles)
+	if err != nil {
+		return workflow.Actor{}, err
+	}
+	if !actor.Can(perm) {
+		return workflow.Actor{}, &permissionError{Permission: perm}
+	}
+	return actor, nil
+}
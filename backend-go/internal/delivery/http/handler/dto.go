@@ -20,6 +20,34 @@ type CreateClaimRequest struct {
 	ServiceDate    time.Time           `json:"service_date" validate:"required"`
 	RequestAmount  int64               `json:"request_amount"`
 	BasicInsShare  int64               `json:"basic_ins_share"`
+	Notes          string              `json:"notes"`
+
+	Items     []ClaimItemRequest      `json:"items"`
+	Diagnoses []ClaimDiagnosisRequest `json:"diagnoses"`
+}
+
+// ClaimItemRequest represents a service or drug line of a new claim
+type ClaimItemRequest struct {
+	ItemID        *uint      `json:"item_id"`
+	BodySiteID    *uint      `json:"body_site_id"`
+	ServiceCode   string     `json:"service_code"`
+	Title         string     `json:"title"`
+	ServiceDate   *time.Time `json:"service_date"`
+	Quantity      int        `json:"quantity"`
+	UnitPrice     int64      `json:"unit_price"`
+	RequestAmount int64      `json:"request_amount"` // defaults to quantity × unit_price
+	BasicInsShare int64      `json:"basic_ins_share"`
+	Dosage        string     `json:"dosage"`
+	Frequency     string     `json:"frequency"`
+	Duration      string     `json:"duration"`
+	Notes         string     `json:"notes"`
+}
+
+// ClaimDiagnosisRequest represents an ICD-10 diagnosis of a new claim
+type ClaimDiagnosisRequest struct {
+	ICD10Code string `json:"icd10_code"`
+	Title     string `json:"title"`
+	IsPrimary bool   `json:"is_primary"`
 }
 
 // UpdateClaimRequest represents claim update request
@@ -28,18 +56,21 @@ type UpdateClaimRequest struct {
 	BasicInsShare *int64 `json:"basic_ins_share"`
 }
 
-// CompleteExaminationRequest represents examination completion request
+// CompleteExaminationRequest represents examination completion request.
+// Claims with items are examined through Items; the claim-level amounts and
+// reason codes apply to claims without items.
 type CompleteExaminationRequest struct {
 	ApprovedAmount  int64                       `json:"approved_amount"`
 	Deduction       int64                       `json:"deduction"`
 	DeductionReason string                      `json:"deduction_reason"`
+	ReasonCodeIDs   []uint                      `json:"reason_code_ids"`
 	Notes           string                      `json:"notes"`
 	Items           []ItemExaminationRequestDTO `json:"items"`
 }
 
 // ItemExaminationRequestDTO represents item examination request
 type ItemExaminationRequestDTO struct {
-	ItemID         uint   `json:"item_id"`
+	ItemID         uint   `json:"item_id"` // claim item (claim_items.id)
 	ConfirmedPrice int64  `json:"confirmed_price"`
 	Deduction      int64  `json:"deduction"`
 	ReasonCodeIDs  []uint `json:"reason_code_ids"`
@@ -51,6 +82,125 @@ type RejectRequest struct {
 	Reason string `json:"reason" validate:"required"`
 }
 
+// TransitionRequest represents a status change with an optional note
+type TransitionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// UpdateAutoApprovalPolicyRequest represents auto-approval settings; fields
+// left out keep their value. Thresholds replace all per claim type thresholds.
+type UpdateAutoApprovalPolicyRequest struct {
+	Threshold  *int64                     `json:"threshold"`
+	Thresholds map[entity.ClaimType]int64 `json:"thresholds"`
+	SampleRate *float64                   `json:"sample_rate"`
+}
+
+// ReviewSampleRequest represents the review of a sampled auto-approved claim
+type ReviewSampleRequest struct {
+	Notes string `json:"notes"`
+}
+
+// UpdateWorkQueueRequest represents the settings of a claim type's work queue;
+// fields left out keep their value
+type UpdateWorkQueueRequest struct {
+	Role     *entity.RoleName  `json:"role"`
+	Mode     *entity.QueueMode `json:"mode"`
+	IsActive *bool             `json:"is_active"`
+}
+
+// UpdateExaminerProfileRequest represents an examiner's skills and
+// availability; fields left out keep their value
+type UpdateExaminerProfileRequest struct {
+	Skills    map[entity.ClaimType]int `json:"skills"`
+	Capacity  *int                     `json:"capacity"`
+	Available *bool                    `json:"available"`
+	AwayUntil *time.Time               `json:"away_until"`
+}
+
+// AssignClaimRequest represents a supervisor's reassignment; a null user_id
+// puts the claim back in its queue
+type AssignClaimRequest struct {
+	UserID *uint  `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// SaveSLAPolicyRequest represents the SLA of claims of a type in a status;
+// claim_type 0 covers the types without a policy of their own
+type SaveSLAPolicyRequest struct {
+	Status      entity.ClaimStatus `json:"status" validate:"required"`
+	ClaimType   entity.ClaimType   `json:"claim_type"`
+	TargetHours int                `json:"target_hours" validate:"required"`
+	EscalateTo  entity.RoleName    `json:"escalate_to"`
+	IsActive    *bool              `json:"is_active"`
+}
+
+// UpdateWorkHoursRequest represents the work hours SLA timers count in
+type UpdateWorkHoursRequest struct {
+	Start string         `json:"start" validate:"required"` // HH:MM
+	End   string         `json:"end" validate:"required"`   // HH:MM
+	Days  []time.Weekday `json:"days" validate:"required"`  // 0 Sunday .. 6 Saturday
+}
+
+// CreateHolidayRequest represents a holiday of the work calendar
+type CreateHolidayRequest struct {
+	Date  string `json:"date" validate:"required"` // YYYY-MM-DD
+	Title string `json:"title"`
+}
+
+// SaveAttachmentTypeRequest represents a document type claims of a type
+// (0 for all) or packages take
+type SaveAttachmentTypeRequest struct {
+	Owner     entity.AttachmentOwner `json:"owner" validate:"required"`
+	ClaimType entity.ClaimType       `json:"claim_type"`
+	Code      string                 `json:"code" validate:"required"`
+	Title     string                 `json:"title" validate:"required"`
+	Required  bool                   `json:"required"`
+	MaxSize   int64                  `json:"max_size"`   // bytes; 0 keeps the global limit
+	MIMETypes []string               `json:"mime_types"` // empty keeps the global list
+	IsActive  *bool                  `json:"is_active"`
+}
+
+// CreateRuleSetRequest represents a new draft rule set. With BaseID the
+// source and tests of that version are copied unless Source is given.
+type CreateRuleSetRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	BaseID      *uint  `json:"base_id"`
+}
+
+// UpdateRuleSetRequest represents draft rule set update request
+type UpdateRuleSetRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Source      *string `json:"source"`
+}
+
+// ValidateRuleSourceRequest represents a GRL syntax check request
+type ValidateRuleSourceRequest struct {
+	Source string `json:"source" validate:"required"`
+}
+
+// CreateRuleTestRequest represents a sample claim and its expected outcome
+type CreateRuleTestRequest struct {
+	Name     string                     `json:"name" validate:"required"`
+	Claim    entity.RuleTestClaim       `json:"claim"`
+	Expected entity.RuleTestExpectation `json:"expected"`
+}
+
+// PromoteRuleSetRequest represents draft activation; EffectiveFrom defaults to now
+type PromoteRuleSetRequest struct {
+	EffectiveFrom *time.Time `json:"effective_from"`
+}
+
+// SimulateRuleSetRequest represents a simulation over claims with service
+// dates in [ServiceFrom, ServiceTo), optionally of some claim types only
+type SimulateRuleSetRequest struct {
+	ServiceFrom time.Time          `json:"service_from" validate:"required"`
+	ServiceTo   time.Time          `json:"service_to" validate:"required"`
+	ClaimTypes  []entity.ClaimType `json:"claim_types"`
+}
+
 // CreatePackageRequest represents package creation request
 type CreatePackageRequest struct {
 	CenterID          uint       `json:"center_id" validate:"required"`
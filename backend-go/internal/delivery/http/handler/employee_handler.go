@@ -1,84 +1,66 @@
 package handler
 
 import (
-	"strconv"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/search"
 )
 
 type EmployeeHandler struct {
-	// TODO: Add usecase dependency
+	employees repository.EmployeeRepository
+	searcher  *search.Searcher
 }
 
-func NewEmployeeHandler() *EmployeeHandler {
-	return &EmployeeHandler{}
+func NewEmployeeHandler(employees repository.EmployeeRepository, searcher *search.Searcher) *EmployeeHandler {
+	return &EmployeeHandler{employees: employees, searcher: searcher}
 }
 
 // GetEmployees - لیست کارمندان (عین actionAdmin در Yii)
-// GET /api/v1/employees
+// GET /api/v1/employees?filter[status]=active&sort=-created_at&page=1&page_size=20
+// GET /api/v1/employees?cursor=&page_size=50&total=estimate
+// GET /api/v1/employees?search=علي احمدي&filter[status]=active
 func (h *EmployeeHandler) GetEmployees(c *fiber.Ctx) error {
-	// Query parameters
-	_ = c.Query("search", "")     // TODO: implement search
-	_ = c.Query("status", "all")  // TODO: implement status filter
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	// Mock data برای تست
-	employees := []fiber.Map{
-		{
-			"id":             1,
-			"personnel_code": "12345",
-			"national_code":  "1234567890",
-			"first_name":     "علی",
-			"last_name":      "احمدی",
-			"parent_id":      nil,
-			"relation_type":  "کارمند اصلی",
-			"is_active":      true,
-			"status":         "active",
-		},
-		{
-			"id":             2,
-			"personnel_code": "12346",
-			"national_code":  "1234567891",
-			"first_name":     "فاطمه",
-			"last_name":      "محمدی",
-			"parent_id":      1,
-			"relation_type":  "همسر",
-			"is_active":      true,
-			"status":         "active",
-		},
-		{
-			"id":             3,
-			"personnel_code": "12347",
-			"national_code":  "1234567892",
-			"first_name":     "محمد",
-			"last_name":      "رضایی",
-			"parent_id":      nil,
-			"relation_type":  "کارمند اصلی",
-			"is_active":      true,
-			"status":         "active",
-		},
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.EmployeeFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	// Legacy ?status= from the employees page
+	if status := c.Query("status", "all"); status != "all" {
+		opts.Filters = append(opts.Filters, repository.Filter{Field: "status", Operator: repository.OpEq, Value: status})
+	}
+	// ?search= narrows the list to the best matching members, in any spelling
+	if text := c.Query("search"); text != "" {
+		q, err := search.Query(tenantID, text, search.MaxLimit)
+		if err != nil {
+			return respondError(c, err)
+		}
+		hits, err := h.searcher.Employees(c.UserContext(), q)
+		if err != nil {
+			return respondError(c, err)
+		}
+		ids := make([]uint, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.ID
+		}
+		opts.Filters = append(opts.Filters, repository.Filter{Field: "id", Operator: repository.OpIn, Value: ids})
 	}
 
-	// Statistics
-	stats := fiber.Map{
-		"total":          150,
-		"active":         142,
-		"family_members": 234,
-		"retired":        8,
+	employees, pagination, err := listPage(c.UserContext(), h.employees, opts)
+	if err != nil {
+		return respondError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
-			"employees": employees,
-			"stats":     stats,
-			"pagination": fiber.Map{
-				"page":  page,
-				"limit": limit,
-				"total": len(employees),
-			},
+			"employees":  employees,
+			"pagination": pagination,
 		},
 	})
 }
@@ -179,22 +161,28 @@ func (h *EmployeeHandler) DeleteEmployee(c *fiber.Ctx) error {
 }
 
 // AutoCompleteLookup - جستجوی autocomplete کارمندان
-// GET /api/v1/employees/autocomplete
+// GET /api/v1/employees/autocomplete?q=علی&limit=10
 func (h *EmployeeHandler) AutoCompleteLookup(c *fiber.Ctx) error {
-	_ = c.Query("q", "") // TODO: use query to search database
-
-	// Mock results
-	results := []fiber.Map{
-		{
-			"id":    1,
-			"label": "علی احمدی - 12345",
-			"value": 1,
-		},
-		{
-			"id":    2,
-			"label": "محمد رضایی - 12347",
-			"value": 2,
-		},
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	q, err := search.Query(tenantID, c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return respondError(c, err)
+	}
+	hits, err := h.searcher.Employees(c.UserContext(), q)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	results := make([]fiber.Map, len(hits))
+	for i, hit := range hits {
+		results[i] = fiber.Map{
+			"id":    hit.ID,
+			"label": hit.FirstName + " " + hit.LastName + " - " + hit.PersonnelCode,
+			"value": hit.ID,
+		}
 	}
 
 	return c.JSON(fiber.Map{
@@ -4,19 +4,20 @@ import (
 	"encoding/csv"
 	"fmt"
 	"path/filepath"
-	"strconv"
 	"time"
 
+	"github.com/bank-melli/tpa/internal/domain/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 type EmployeeImportHandler struct {
 	// TODO: Add usecase dependency
+	history repository.ImportHistoryRepository
 }
 
-func NewEmployeeImportHandler() *EmployeeImportHandler {
-	return &EmployeeImportHandler{}
+func NewEmployeeImportHandler(history repository.ImportHistoryRepository) *EmployeeImportHandler {
+	return &EmployeeImportHandler{history: history}
 }
 
 // UploadCSV - آپلود فایل CSV (عین actionUploader در Yii)
@@ -158,60 +159,27 @@ func (h *EmployeeImportHandler) SyncFromHR(c *fiber.Ctx) error {
 }
 
 // GetImportHistory - تاریخچه import ها
-// GET /api/v1/employees/import/history
+// GET /api/v1/employees/import/history?filter[status]=completed&cursor=&page_size=20
 func (h *EmployeeImportHandler) GetImportHistory(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	// Mock data
-	history := []fiber.Map{
-		{
-			"id":              1,
-			"batch_id":        "BATCH-2024-001",
-			"import_date":     "1403/10/25 14:30",
-			"source":          "csv_file",
-			"total_records":   150,
-			"new_records":     25,
-			"updated_records": 120,
-			"failed_records":  5,
-			"status":          "completed",
-			"notes":           "به‌روزرسانی موفق اطلاعات کارمندان",
-		},
-		{
-			"id":              2,
-			"batch_id":        "BATCH-2024-002",
-			"import_date":     "1403/10/18 10:15",
-			"source":          "hr_server",
-			"total_records":   200,
-			"new_records":     30,
-			"updated_records": 165,
-			"failed_records":  5,
-			"status":          "completed",
-			"notes":           "همگام‌سازی خودکار از سرور منابع انسانی",
-		},
-		{
-			"id":              3,
-			"batch_id":        "BATCH-2024-003",
-			"import_date":     "1403/10/11 09:00",
-			"source":          "manual",
-			"total_records":   50,
-			"new_records":     10,
-			"updated_records": 38,
-			"failed_records":  2,
-			"status":          "completed",
-			"notes":           "",
-		},
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.ImportHistoryFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	history, pagination, err := listPage(c.UserContext(), h.history, opts)
+	if err != nil {
+		return respondError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
-			"history": history,
-			"pagination": fiber.Map{
-				"page":  page,
-				"limit": limit,
-				"total": len(history),
-			},
+			"history":    history,
+			"pagination": pagination,
 		},
 	})
 }
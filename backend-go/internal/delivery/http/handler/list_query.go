@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListQuery reads the list query language shared by list endpoints:
+//
+//	filter[status][in]=3,4   filter[tracking_code]=ABC (eq)   sort=-created_at
+//	page=2   page_size=50 (or limit=50)
+//	cursor=<next_cursor>   total=none|estimate|exact   (keyset mode, see below)
+//
+// Every field and operator is checked against fields; values are parsed by
+// the field's type and always bound as SQL parameters. A cursor parameter,
+// even empty for the first page, switches to keyset pagination ordered by
+// created_at (sort=created_at or -created_at only).
+func parseListQuery(c *fiber.Ctx, tenantID uint, fields repository.FieldSet) (repository.QueryOptions, error) {
+	opts := repository.QueryOptions{TenantID: tenantID}
+	var parseErr error
+
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		if parseErr != nil {
+			return
+		}
+		key := string(k)
+		if !strings.HasPrefix(key, "filter[") {
+			return
+		}
+		name, op, ok := splitFilterKey(key)
+		if !ok {
+			parseErr = &repository.QueryError{Param: key, Message: "expected filter[field] or filter[field][operator]"}
+			return
+		}
+		field, err := fields.Filterable(name, op)
+		if err != nil {
+			parseErr = err
+			return
+		}
+		value, err := parseFilterValue(field, op, string(v))
+		if err != nil {
+			parseErr = &repository.QueryError{Param: key, Message: err.Error()}
+			return
+		}
+		opts.Filters = append(opts.Filters, repository.Filter{Field: name, Operator: op, Value: value})
+	})
+	if parseErr != nil {
+		return opts, parseErr
+	}
+
+	size := c.Query("page_size", c.Query("limit"))
+	if c.Context().QueryArgs().Has("cursor") {
+		return opts, parseCursorQuery(c, &opts, size)
+	}
+
+	page := &repository.Pagination{}
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return opts, &repository.QueryError{Param: "page", Message: "must be a positive integer"}
+		}
+		page.Page = n
+	}
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return opts, &repository.QueryError{Param: "page_size", Message: "must be a positive integer"}
+		}
+		page.PageSize = n
+	}
+	if sort := c.Query("sort"); sort != "" {
+		if strings.Contains(sort, ",") {
+			return opts, &repository.QueryError{Param: "sort", Message: "only one sort field is supported"}
+		}
+		page.Order = "asc"
+		if name, ok := strings.CutPrefix(sort, "-"); ok {
+			sort, page.Order = name, "desc"
+		}
+		if _, err := fields.Sortable(sort); err != nil {
+			return opts, err
+		}
+		page.Sort = sort
+	}
+	page.Normalize()
+	opts.Pagination = page
+
+	return opts, nil
+}
+
+// parseCursorQuery fills opts.Cursor from cursor, sort, total and the page size
+func parseCursorQuery(c *fiber.Ctx, opts *repository.QueryOptions, size string) error {
+	page := &repository.CursorPage{After: c.Query("cursor"), Desc: true}
+	if c.Query("page") != "" {
+		return &repository.QueryError{Param: "page", Message: "cannot be combined with cursor"}
+	}
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return &repository.QueryError{Param: "page_size", Message: "must be a positive integer"}
+		}
+		page.Limit = n
+	}
+	switch sort := c.Query("sort"); sort {
+	case "", "-" + repository.CursorColumn:
+	case repository.CursorColumn:
+		page.Desc = false
+	default:
+		return &repository.QueryError{Param: "sort", Message: "cursor pagination sorts by created_at or -created_at only"}
+	}
+	switch total := repository.TotalMode(c.Query("total")); total {
+	case "", repository.TotalNone, repository.TotalEstimate, repository.TotalExact:
+		page.Total = total
+	default:
+		return &repository.QueryError{Param: "total", Message: "must be none, estimate or exact"}
+	}
+	page.Normalize()
+	opts.Cursor = page
+	return nil
+}
+
+// listPage runs opts in page or cursor mode and returns the items with their pagination metadata
+func listPage[T any](ctx context.Context, repo repository.Repository[T], opts repository.QueryOptions) ([]T, fiber.Map, error) {
+	if opts.Cursor != nil {
+		result, err := repo.FindWithCursor(ctx, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta := fiber.Map{
+			"mode":        "cursor",
+			"limit":       result.Limit,
+			"has_more":    result.HasMore,
+			"next_cursor": result.NextCursor,
+		}
+		if result.Total != nil {
+			meta["total"] = *result.Total
+			meta["total_estimated"] = result.TotalEstimated
+		}
+		return result.Items, meta, nil
+	}
+
+	result, err := repo.FindWithPagination(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, fiber.Map{
+		"mode":        "page",
+		"page":        result.Page,
+		"limit":       result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	}, nil
+}
+
+// splitFilterKey splits filter[field] and filter[field][op]
+func splitFilterKey(key string) (name, op string, ok bool) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok = strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, repository.OpEq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	op = rest[1 : len(rest)-1]
+	return name, op, op != ""
+}
+
+func parseFilterValue(field repository.Field, op, raw string) (interface{}, error) {
+	if op != repository.OpIn {
+		return parseScalar(field.Type, raw)
+	}
+	parts := strings.Split(raw, ",")
+	values := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		v, err := parseScalar(field.Type, strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func parseScalar(t repository.FieldType, raw string) (interface{}, error) {
+	switch t {
+	case repository.FieldInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		return n, nil
+	case repository.FieldBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", raw)
+		}
+		return b, nil
+	case repository.FieldTime:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if ts, err := time.Parse(layout, raw); err == nil {
+				return ts, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a date (YYYY-MM-DD) or RFC 3339 time, got %q", raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/assignment"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetQueueOverview - وضعیت صف‌های ارزیابی: پرونده‌های منتظر و بار ارزیاب‌ها
+// GET /api/v1/queues
+func (h *ClaimHandler) GetQueueOverview(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	overview, err := h.assigner.Overview(c.UserContext(), tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    overview,
+	})
+}
+
+// UpdateWorkQueue - تنظیم نقش ارزیاب‌ها و شیوه تخصیص صف یک نوع پرونده
+// PUT /api/v1/queues/:claimType
+func (h *ClaimHandler) UpdateWorkQueue(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	claimType, err := claimTypeParam(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateWorkQueueRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimAssign) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimAssign)+" لازم است")
+	}
+
+	ctx := c.UserContext()
+	queue, err := h.assigner.Queue(ctx, tenantID, claimType)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if req.Role != nil {
+		if !isExaminerRole(*req.Role) {
+			return fail(c, fiber.StatusBadRequest, "نقش صف باید ارزیاب پرونده یا ارزیاب دارو باشد")
+		}
+		queue.Role = *req.Role
+	}
+	if req.Mode != nil {
+		if !req.Mode.IsValid() {
+			return fail(c, fiber.StatusBadRequest, "شیوه تخصیص باید pull یا push باشد")
+		}
+		queue.Mode = *req.Mode
+	}
+	if req.IsActive != nil {
+		queue.IsActive = *req.IsActive
+	}
+	if queue.ID == 0 {
+		queue.CreatedBy = actor.UserID
+	}
+	queue.UpdatedBy = actor.UserID
+	if err := h.queues.SaveQueue(ctx, queue); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "تنظیمات صف " + claimType.String() + " ذخیره شد",
+		"data":    queue,
+	})
+}
+
+// PullNextClaim - برداشتن قدیمی‌ترین پرونده منتظر از صف‌های ارزیاب
+// POST /api/v1/queues/next
+func (h *ClaimHandler) PullNextClaim(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimExamine) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimExamine)+" لازم است")
+	}
+
+	claim, err := h.assigner.Next(c.UserContext(), tenantID, actor.UserID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if claim == nil {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "پرونده‌ای در صف نیست",
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پرونده " + claim.TrackingCode + " به شما تخصیص یافت",
+		"data":    claim,
+	})
+}
+
+// DispatchQueue - تخصیص پرونده‌های منتظر یک صف به ارزیاب‌ها تا تکمیل ظرفیت
+// POST /api/v1/queues/:claimType/dispatch
+func (h *ClaimHandler) DispatchQueue(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	claimType, err := claimTypeParam(c)
+	if err != nil {
+		return err
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimAssign) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimAssign)+" لازم است")
+	}
+
+	made, err := h.assigner.Dispatch(c.UserContext(), tenantID, claimType, actor.UserID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": strconv.Itoa(len(made)) + " پرونده تخصیص یافت",
+		"data":    made,
+	})
+}
+
+// ListExaminers - ارزیاب‌ها با مهارت، ظرفیت، حضور و پرونده‌های باز
+// GET /api/v1/queues/examiners
+func (h *ClaimHandler) ListExaminers(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	examiners, err := h.assigner.Examiners(c.UserContext(), tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    examiners,
+	})
+}
+
+// UpdateExaminerProfile - تنظیم مهارت، ظرفیت و حضور ارزیاب
+// PUT /api/v1/queues/examiners/:userId
+func (h *ClaimHandler) UpdateExaminerProfile(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("userId")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid user id")
+	}
+	var req UpdateExaminerProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimAssign) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimAssign)+" لازم است")
+	}
+
+	ctx := c.UserContext()
+	examiners, err := h.assigner.Examiners(ctx, tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	var profile *entity.ExaminerProfile
+	for i := range examiners {
+		if examiners[i].UserID == uint(id) {
+			profile = &examiners[i].ExaminerProfile
+			break
+		}
+	}
+	if profile == nil {
+		return fail(c, fiber.StatusNotFound, "ارزیاب فعالی با این شناسه یافت نشد")
+	}
+
+	if req.Skills != nil {
+		profile.Skills = req.Skills
+	}
+	if req.Capacity != nil {
+		profile.Capacity = *req.Capacity
+	}
+	if req.Available != nil {
+		profile.Available = *req.Available
+	}
+	if req.AwayUntil != nil {
+		profile.AwayUntil = req.AwayUntil
+		if req.AwayUntil.IsZero() {
+			profile.AwayUntil = nil
+		}
+	}
+	if msg := validateExaminerProfile(profile); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	if err := h.queues.SaveProfile(ctx, profile); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "مشخصات ارزیاب ذخیره شد",
+		"data":    profile,
+	})
+}
+
+// AssignClaim - تخصیص مجدد پرونده به ارزیاب دیگر یا بازگرداندن آن به صف
+// POST /api/v1/claims/:id/assign
+func (h *ClaimHandler) AssignClaim(c *fiber.Ctx) error {
+	var req AssignClaimRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	actor, err := h.actor(c)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimAssign) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimAssign)+" لازم است")
+	}
+	if !isPending(claim.Status) {
+		return fail(c, fiber.StatusConflict, "پرونده در وضعیت "+claim.Status.String()+" در صف ارزیابی نیست")
+	}
+	if sameAssignee(claim.HandlerUserID, req.UserID) {
+		return fail(c, fiber.StatusConflict, "پرونده هم‌اکنون به همین ارزیاب تخصیص دارد")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return fail(c, fiber.StatusBadRequest, "ذکر علت الزامی است")
+	}
+
+	record, err := h.assigner.Reassign(c.UserContext(), claim, req.UserID, actor.UserID, req.Reason)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	message := "پرونده به صف بازگشت"
+	if req.UserID != nil {
+		message = "پرونده به ارزیاب تخصیص یافت"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data": fiber.Map{
+			"claim":      claim,
+			"assignment": record,
+		},
+	})
+}
+
+// GetClaimAssignments - سابقه تخصیص پرونده به ارزیاب‌ها
+// GET /api/v1/claims/:id/assignments
+func (h *ClaimHandler) GetClaimAssignments(c *fiber.Ctx) error {
+	claim, err := h.findClaim(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	assignments, err := h.queues.FindAssignments(c.UserContext(), claim.TenantID, claim.ID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    assignments,
+	})
+}
+
+// claimTypeParam reads and validates the :claimType route parameter
+func claimTypeParam(c *fiber.Ctx) (entity.ClaimType, error) {
+	v, err := strconv.ParseUint(c.Params("claimType"), 10, 8)
+	if err != nil || !entity.ClaimType(v).IsValid() {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid claim type")
+	}
+	return entity.ClaimType(v), nil
+}
+
+// validateExaminerProfile returns a user facing message for the first invalid field
+func validateExaminerProfile(p *entity.ExaminerProfile) string {
+	if p.Capacity < 1 {
+		return "ظرفیت ارزیاب باید حداقل یک پرونده باشد"
+	}
+	for t, level := range p.Skills {
+		if !t.IsValid() {
+			return "نوع پرونده " + strconv.Itoa(int(t)) + " نامعتبر است"
+		}
+		if level < 0 || level > 5 {
+			return "سطح مهارت " + t.String() + " باید بین ۰ و ۵ باشد"
+		}
+	}
+	return ""
+}
+
+func isExaminerRole(role entity.RoleName) bool {
+	for _, r := range assignment.ExaminerRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func isPending(status entity.ClaimStatus) bool {
+	for _, s := range assignment.PendingStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func sameAssignee(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bank-melli/tpa/internal/domain/assignment"
+	"github.com/bank-melli/tpa/internal/domain/attachment"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/search"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"github.com/bank-melli/tpa/internal/pkg/storage"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// tenantID reads the tenant from JWT locals. The X-Tenant-ID header is not
+// trusted: anyone could name another insurer's tenant in it.
+func tenantID(c *fiber.Ctx) (uint, error) {
+	if id, ok := c.Locals("tenant_id").(uint); ok && id > 0 {
+		return id, nil
+	}
+	return 0, fiber.NewError(fiber.StatusBadRequest, "tenant is required")
+}
+
+// errUnauthenticated refuses anonymous requests to routes that record who acted
+var errUnauthenticated = errors.New("handler: authentication required")
+
+// permissionError refuses a user whose role lacks Permission
+type permissionError struct {
+	Permission entity.PermissionName
+}
+
+func (e *permissionError) Error() string {
+	return "handler: permission " + string(e.Permission) + " required"
+}
+
+// respondError maps repository and workflow errors to HTTP responses; anything else goes to the error handler
+func respondError(c *fiber.Ctx, err error) error {
+	var queryErr *repository.QueryError
+	var transitionErr *workflow.TransitionError
+	var examErr *workflow.ExaminationError
+	var rulesErr *rules.EvaluationError
+	var ineligibleErr *assignment.IneligibleError
+	var tooLargeErr *attachment.TooLargeError
+	var contentTypeErr *attachment.ContentTypeError
+	var infectedErr *storage.InfectedError
+	var permErr *permissionError
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		return fail(c, fiber.StatusUnauthorized, "ورود به سامانه لازم است")
+	case errors.As(err, &permErr):
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(permErr.Permission)+" لازم است")
+	case errors.As(err, &queryErr):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": queryErr.Error(),
+			"error": ErrorResponse{
+				Code:    "INVALID_QUERY",
+				Message: queryErr.Message,
+				Details: fiber.Map{"param": queryErr.Param},
+			},
+		})
+	case errors.Is(err, repository.ErrTenantRequired):
+		return fiber.NewError(fiber.StatusBadRequest, "tenant is required")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		// A concurrent request won the race past the handler's own check
+		return fail(c, fiber.StatusConflict, "این مورد قبلا ثبت شده است")
+	case errors.As(err, &transitionErr):
+		status := fiber.StatusUnprocessableEntity
+		switch {
+		case errors.Is(err, workflow.ErrForbidden):
+			status = fiber.StatusForbidden
+		case errors.Is(err, workflow.ErrInvalidTransition):
+			status = fiber.StatusConflict
+		}
+		return fail(c, status, transitionErr.Message)
+	case errors.As(err, &examErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"message": examErr.Message,
+			"error": ErrorResponse{
+				Code:    "INVALID_EXAMINATION",
+				Message: examErr.Message,
+				Details: fiber.Map{"item_id": examErr.ItemID},
+			},
+		})
+	case errors.As(err, &rulesErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"message": "اجرای قوانین ارزیابی خودکار با خطا مواجه شد",
+			"error": ErrorResponse{
+				Code:    "RULES_FAILED",
+				Message: rulesErr.Message,
+				Details: fiber.Map{"rule_set_id": rulesErr.RuleSetID, "version": rulesErr.Version},
+			},
+		})
+	case errors.Is(err, rules.ErrTooManyClaims):
+		return fail(c, fiber.StatusUnprocessableEntity, "تعداد پرونده‌های بازه بیش از حد مجاز شبیه‌سازی است؛ بازه را کوچک‌تر کنید")
+	case errors.Is(err, rules.ErrSimulationRunning):
+		return fail(c, fiber.StatusConflict, "شبیه‌سازی دیگری در حال اجراست")
+	case errors.As(err, &ineligibleErr):
+		return fail(c, fiber.StatusUnprocessableEntity, ineligibleErr.Message)
+	case errors.Is(err, assignment.ErrNotExaminer):
+		return fail(c, fiber.StatusForbidden, "شما ارزیاب هیچ صف فعالی نیستید")
+	case errors.Is(err, assignment.ErrUnavailable):
+		return fail(c, fiber.StatusConflict, "وضعیت شما در دسترس نیست؛ ابتدا حضور خود را فعال کنید")
+	case errors.Is(err, assignment.ErrAtCapacity):
+		return fail(c, fiber.StatusConflict, "ظرفیت پرونده‌های باز شما تکمیل است")
+	case errors.Is(err, repository.ErrAlreadyAssigned):
+		return fail(c, fiber.StatusConflict, "تخصیص پرونده همزمان تغییر کرده است، دوباره تلاش کنید")
+	case errors.Is(err, repository.ErrAlreadyAcknowledged):
+		return fail(c, fiber.StatusConflict, "این تخطی قبلا پیگیری شده است")
+	case errors.Is(err, attachment.ErrUnknownType):
+		return fail(c, fiber.StatusUnprocessableEntity, "این نوع پیوست برای پرونده تعریف نشده است")
+	case errors.Is(err, attachment.ErrEmpty):
+		return fail(c, fiber.StatusBadRequest, "فایل خالی است")
+	case errors.As(err, &tooLargeErr):
+		return fail(c, fiber.StatusRequestEntityTooLarge, fmt.Sprintf("حجم فایل نباید بیشتر از %.1f مگابایت باشد", float64(tooLargeErr.Limit)/(1<<20)))
+	case errors.As(err, &contentTypeErr):
+		return fail(c, fiber.StatusUnsupportedMediaType, "نوع فایل ("+contentTypeErr.ContentType+") مجاز نیست")
+	case errors.As(err, &infectedErr):
+		return fail(c, fiber.StatusUnprocessableEntity, "فایل آلوده است و پذیرفته نشد")
+	case errors.Is(err, storage.ErrInvalidToken):
+		return fail(c, fiber.StatusForbidden, "لینک دریافت فایل نامعتبر است")
+	case errors.Is(err, storage.ErrExpiredToken):
+		return fail(c, fiber.StatusGone, "لینک دریافت فایل منقضی شده است")
+	case errors.Is(err, storage.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "not found")
+	case errors.Is(err, search.ErrQueryTooShort):
+		return fail(c, fiber.StatusBadRequest, fmt.Sprintf("عبارت جستجو باید دست‌کم %d حرف باشد", search.MinQueryLength))
+	case errors.Is(err, repository.ErrStatusChanged):
+		return fail(c, fiber.StatusConflict, "وضعیت پرونده همزمان تغییر کرده است، دوباره تلاش کنید")
+	case errors.Is(err, workflow.ErrUnknownAction):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+// userID returns the authenticated user, or 0 on public routes
+func userID(c *fiber.Ctx) uint {
+	id, _ := c.Locals("user_id").(uint)
+	return id
+}
+
+// fail writes the {"success": false} envelope used by the handlers
+func fail(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"message": message,
+	})
+}
@@ -0,0 +1,526 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RuleSetHandler lets each insurer author its adjudication rules: drafts are
+// validated and tested, then promoted to take effect from a date; a rollback
+// brings back an earlier version. Simulations show what a version would
+// have changed on past claims.
+type RuleSetHandler struct {
+	sets      repository.RuleSetRepository
+	sims      repository.RuleSimulationRepository
+	engine    *rules.Engine
+	simulator *rules.Simulator
+	roles     repository.RoleRepository
+	now       func() time.Time
+}
+
+func NewRuleSetHandler(sets repository.RuleSetRepository, sims repository.RuleSimulationRepository, engine *rules.Engine, simulator *rules.Simulator, roles repository.RoleRepository) *RuleSetHandler {
+	return &RuleSetHandler{sets: sets, sims: sims, engine: engine, simulator: simulator, roles: roles, now: time.Now}
+}
+
+// ListRuleSets - نسخه‌های قوانین ارزیابی خودکار
+// GET /api/v1/rules?filter[status]=draft&sort=-version
+func (h *RuleSetHandler) ListRuleSets(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.RuleSetFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	sets, pagination, err := listPage(c.UserContext(), h.sets, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"rule_sets":  sets,
+			"pagination": pagination,
+		},
+	})
+}
+
+// GetActiveRuleSet - نسخه در حال اجرا و نسخه‌های زمان‌بندی شده
+// GET /api/v1/rules/active
+func (h *RuleSetHandler) GetActiveRuleSet(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	now := h.now()
+	current, err := h.sets.FindInEffect(ctx, tenantID, now)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return respondError(c, err)
+	}
+	scheduled, err := h.sets.FindScheduled(ctx, tenantID, now)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"current":   current,
+			"scheduled": scheduled,
+		},
+	})
+}
+
+// GetRuleSet - جزئیات نسخه قوانین با آزمون‌ها
+// GET /api/v1/rules/:id
+func (h *RuleSetHandler) GetRuleSet(c *fiber.Ctx) error {
+	set, err := h.findRuleSet(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	tests, err := h.sets.FindTests(c.UserContext(), set.TenantID, set.ID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"rule_set": set,
+			"tests":    tests,
+		},
+	})
+}
+
+// CreateRuleSet - ایجاد پیش‌نویس جدید (خالی یا از روی نسخه دیگر)
+// POST /api/v1/rules
+func (h *RuleSetHandler) CreateRuleSet(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	actor, err := h.editor(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	var req CreateRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fail(c, fiber.StatusBadRequest, "نام نسخه قوانین الزامی است")
+	}
+
+	ctx := c.UserContext()
+	var tests []entity.RuleTest
+	if req.BaseID != nil {
+		base, err := h.sets.FindByID(ctx, *req.BaseID, repository.QueryOptions{TenantID: tenantID})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fail(c, fiber.StatusUnprocessableEntity, "نسخه مبنا یافت نشد")
+			}
+			return respondError(c, err)
+		}
+		if req.Source == "" {
+			req.Source = base.Source
+		}
+		if tests, err = h.sets.FindTests(ctx, tenantID, base.ID); err != nil {
+			return respondError(c, err)
+		}
+	}
+
+	version, err := h.sets.NextVersion(ctx, tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	set := &entity.RuleSet{
+		TenantID:    tenantID,
+		Version:     version,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      entity.RuleSetDraft,
+		Source:      req.Source,
+		Checksum:    rules.Checksum(req.Source),
+	}
+	set.CreatedBy = actor.UserID
+	set.UpdatedBy = actor.UserID
+	if err := h.sets.CreateWithTests(ctx, set, tests); err != nil {
+		return respondError(c, err)
+	}
+
+	// Drafts may be saved while still invalid; the check is reported back
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "پیش‌نویس قوانین ایجاد شد",
+		"data": fiber.Map{
+			"rule_set":   set,
+			"tests":      len(tests),
+			"validation": h.engine.Validate(ctx, set.Source),
+		},
+	})
+}
+
+// UpdateRuleSet - ویرایش پیش‌نویس قوانین
+// PUT /api/v1/rules/:id
+func (h *RuleSetHandler) UpdateRuleSet(c *fiber.Ctx) error {
+	actor, err := h.editor(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	var req UpdateRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+
+	set, err := h.findDraft(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if req.Name != nil {
+		if set.Name = strings.TrimSpace(*req.Name); set.Name == "" {
+			return fail(c, fiber.StatusBadRequest, "نام نسخه قوانین الزامی است")
+		}
+	}
+	if req.Description != nil {
+		set.Description = *req.Description
+	}
+	if req.Source != nil {
+		set.Source = *req.Source
+		set.Checksum = rules.Checksum(set.Source)
+	}
+	set.UpdatedBy = actor.UserID
+
+	ctx := c.UserContext()
+	if err := h.sets.Update(ctx, set); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پیش‌نویس قوانین به‌روزرسانی شد",
+		"data": fiber.Map{
+			"rule_set":   set,
+			"validation": h.engine.Validate(ctx, set.Source),
+		},
+	})
+}
+
+// DeleteRuleSet - حذف پیش‌نویس قوانین
+// DELETE /api/v1/rules/:id
+func (h *RuleSetHandler) DeleteRuleSet(c *fiber.Ctx) error {
+	if _, err := h.editor(c); err != nil {
+		return respondError(c, err)
+	}
+	set, err := h.findDraft(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if err := h.sets.Delete(c.UserContext(), set.TenantID, set.ID); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پیش‌نویس قوانین حذف شد",
+	})
+}
+
+// ValidateRuleSource - بررسی نحو GRL بدون ذخیره
+// POST /api/v1/rules/validate
+func (h *RuleSetHandler) ValidateRuleSource(c *fiber.Ctx) error {
+	var req ValidateRuleSourceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.engine.Validate(c.UserContext(), req.Source),
+	})
+}
+
+// CreateRuleTest - افزودن آزمون (پرونده نمونه و نتیجه مورد انتظار) به پیش‌نویس
+// POST /api/v1/rules/:id/tests
+func (h *RuleSetHandler) CreateRuleTest(c *fiber.Ctx) error {
+	if _, err := h.editor(c); err != nil {
+		return respondError(c, err)
+	}
+	var req CreateRuleTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if msg := validateRuleTest(&req); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+
+	set, err := h.findDraft(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	test := &entity.RuleTest{
+		TenantID:  set.TenantID,
+		RuleSetID: set.ID,
+		Name:      req.Name,
+		Claim:     req.Claim,
+		Expected:  req.Expected,
+	}
+	if err := h.sets.CreateTest(c.UserContext(), test); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "آزمون افزوده شد",
+		"data":    test,
+	})
+}
+
+// DeleteRuleTest - حذف آزمون پیش‌نویس
+// DELETE /api/v1/rules/:id/tests/:testId
+func (h *RuleSetHandler) DeleteRuleTest(c *fiber.Ctx) error {
+	if _, err := h.editor(c); err != nil {
+		return respondError(c, err)
+	}
+	set, err := h.findDraft(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	testID, err := c.ParamsInt("testId")
+	if err != nil || testID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid test id")
+	}
+	if err := h.sets.DeleteTest(c.UserContext(), set.TenantID, set.ID, uint(testID)); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "آزمون حذف شد",
+	})
+}
+
+// RunRuleTests - اجرای آزمون‌های یک نسخه
+// POST /api/v1/rules/:id/test
+func (h *RuleSetHandler) RunRuleTests(c *fiber.Ctx) error {
+	set, err := h.findRuleSet(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	validation, results, passed, err := h.check(c, set)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"validation": validation,
+			"results":    results,
+			"passed":     passed,
+		},
+	})
+}
+
+// PromoteRuleSet - فعال‌سازی پیش‌نویس از تاریخ اجرا؛ همه آزمون‌ها باید موفق باشند
+// POST /api/v1/rules/:id/promote
+func (h *RuleSetHandler) PromoteRuleSet(c *fiber.Ctx) error {
+	actor, err := h.editor(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	var req PromoteRuleSetRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+		}
+	}
+
+	set, err := h.findDraft(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	now := h.now()
+	effective := now
+	if req.EffectiveFrom != nil {
+		effective = *req.EffectiveFrom
+	}
+	// A minute of slack absorbs clock skew of clients sending "now"
+	if effective.Before(now.Add(-time.Minute)) {
+		return fail(c, fiber.StatusUnprocessableEntity, "تاریخ اجرا نمی‌تواند در گذشته باشد")
+	}
+	if effective.Before(now) {
+		effective = now
+	}
+
+	ctx := c.UserContext()
+	scheduled, err := h.sets.FindScheduled(ctx, set.TenantID, now)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if n := len(scheduled); n > 0 && effective.Before(*scheduled[n-1].EffectiveFrom) {
+		last := scheduled[n-1]
+		return fail(c, fiber.StatusConflict, "تاریخ اجرا باید پس از تاریخ اجرای نسخه زمان‌بندی شده "+last.Name+" باشد")
+	}
+
+	validation, results, passed, err := h.check(c, set)
+	if err != nil {
+		return respondError(c, err)
+	}
+	switch {
+	case !validation.Valid:
+		return refusePromotion(c, "متن قوانین معتبر نیست", validation, results)
+	case len(results) == 0:
+		return refusePromotion(c, "برای فعال‌سازی حداقل یک آزمون لازم است", validation, results)
+	case !passed:
+		return refusePromotion(c, "همه آزمون‌ها باید موفق باشند", validation, results)
+	}
+
+	set.Status = entity.RuleSetActive
+	set.EffectiveFrom = &effective
+	set.ActivatedAt = &now
+	set.ActivatedBy = &actor.UserID
+	set.UpdatedBy = actor.UserID
+	if err := h.sets.Update(ctx, set); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "نسخه قوانین فعال شد",
+		"data": fiber.Map{
+			"rule_set": set,
+			"results":  results,
+		},
+	})
+}
+
+// RollbackRuleSet - بازگشت به نسخه‌ای که قبلا اجرا شده است؛ نسخه‌های جدیدتر کنار گذاشته می‌شوند
+// POST /api/v1/rules/:id/rollback
+func (h *RuleSetHandler) RollbackRuleSet(c *fiber.Ctx) error {
+	actor, err := h.editor(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	set, err := h.findRuleSet(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	ctx := c.UserContext()
+	now := h.now()
+	if set.ActivatedAt == nil || set.EffectiveFrom == nil || set.EffectiveFrom.After(now) {
+		return fail(c, fiber.StatusConflict, "فقط به نسخه‌ای که قبلا اجرا شده است می‌توان بازگشت")
+	}
+	current, err := h.sets.FindInEffect(ctx, set.TenantID, now)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return respondError(c, err)
+	}
+	if current != nil && current.ID == set.ID {
+		return fail(c, fiber.StatusConflict, "این نسخه هم‌اکنون در حال اجراست")
+	}
+
+	set.Status = entity.RuleSetActive
+	set.ActivatedAt = &now
+	set.ActivatedBy = &actor.UserID
+	set.UpdatedBy = actor.UserID
+	if err := h.sets.Rollback(ctx, set); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "قوانین به نسخه " + set.Name + " بازگشت",
+		"data":    set,
+	})
+}
+
+// editor resolves the signed-in user, who must be allowed to change the
+// tenant's settings to author, promote or roll back its rules
+func (h *RuleSetHandler) editor(c *fiber.Ctx) (workflow.Actor, error) {
+	return authorize(c, h.roles, entity.PermSettingsUpdate)
+}
+
+// check validates the source of set and runs its tests
+func (h *RuleSetHandler) check(c *fiber.Ctx, set *entity.RuleSet) (*rules.Validation, []rules.TestResult, bool, error) {
+	ctx := c.UserContext()
+	validation := h.engine.Validate(ctx, set.Source)
+	tests, err := h.sets.FindTests(ctx, set.TenantID, set.ID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !validation.Valid {
+		return validation, []rules.TestResult{}, false, nil
+	}
+	results, passed := h.engine.RunTests(ctx, set, tests)
+	return validation, results, passed, nil
+}
+
+// findRuleSet loads the :id rule set of the current tenant
+func (h *RuleSetHandler) findRuleSet(c *fiber.Ctx) (*entity.RuleSet, error) {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid rule set id")
+	}
+	return h.sets.FindByID(c.UserContext(), uint(id), repository.QueryOptions{TenantID: tenantID})
+}
+
+// findDraft loads the :id rule set and refuses versions past draft, which are immutable
+func (h *RuleSetHandler) findDraft(c *fiber.Ctx) (*entity.RuleSet, error) {
+	set, err := h.findRuleSet(c)
+	if err != nil {
+		return nil, err
+	}
+	if set.Status != entity.RuleSetDraft {
+		return nil, fiber.NewError(fiber.StatusConflict, "only draft rule sets can be changed")
+	}
+	return set, nil
+}
+
+// refusePromotion reports why a draft cannot be promoted, with the checks behind it
+func refusePromotion(c *fiber.Ctx, message string, validation *rules.Validation, results []rules.TestResult) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"success": false,
+		"message": message,
+		"data": fiber.Map{
+			"validation": validation,
+			"results":    results,
+		},
+	})
+}
+
+// validateRuleTest returns a user facing message for the first invalid field
+func validateRuleTest(req *CreateRuleTestRequest) string {
+	switch {
+	case req.Name == "":
+		return "نام آزمون الزامی است"
+	case req.Claim.Stage != "" && req.Claim.Stage != string(rules.StageSubmit) && req.Claim.Stage != string(rules.StageExamine):
+		return "مرحله پرونده نمونه باید submit یا examine باشد"
+	case req.Claim.ClaimType != 0 && !req.Claim.ClaimType.IsValid():
+		return "نوع پرونده نمونه نامعتبر است"
+	case req.Expected.Deduction == nil && req.Expected.FiredRules == nil && req.Expected.ReasonCodes == nil && req.Expected.Flags == nil:
+		return "حداقل یکی از نتایج مورد انتظار لازم است"
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SimulateRuleSet - شبیه‌سازی اثر مالی یک نسخه قوانین روی پرونده‌های گذشته
+// POST /api/v1/rules/:id/simulations
+func (h *RuleSetHandler) SimulateRuleSet(c *fiber.Ctx) error {
+	actor, err := h.editor(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	var req SimulateRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	if req.ServiceFrom.IsZero() || req.ServiceTo.IsZero() {
+		return fail(c, fiber.StatusBadRequest, "بازه تاریخ خدمت الزامی است")
+	}
+	if !req.ServiceTo.After(req.ServiceFrom) {
+		return fail(c, fiber.StatusBadRequest, "پایان بازه باید بعد از شروع آن باشد")
+	}
+	for _, t := range req.ClaimTypes {
+		if !t.IsValid() {
+			return fail(c, fiber.StatusBadRequest, "نوع پرونده نامعتبر است")
+		}
+	}
+
+	set, err := h.findRuleSet(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+	sim := &entity.RuleSimulation{
+		TenantID:    set.TenantID,
+		ServiceFrom: req.ServiceFrom,
+		ServiceTo:   req.ServiceTo,
+		ClaimTypes:  req.ClaimTypes,
+		CreatedBy:   actor.UserID,
+	}
+	if err := h.simulator.Start(c.UserContext(), set, sim); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "شبیه‌سازی آغاز شد",
+		"data":    sim,
+	})
+}
+
+// ListRuleSimulations - فهرست شبیه‌سازی‌ها
+// GET /api/v1/rules/simulations?filter[rule_set_id]=3&sort=-created_at
+func (h *RuleSetHandler) ListRuleSimulations(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.RuleSimulationFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	sims, pagination, err := listPage(c.UserContext(), h.sims, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"simulations": sims,
+			"pagination":  pagination,
+		},
+	})
+}
+
+// GetRuleSimulation - پیشرفت و خلاصه نتیجه شبیه‌سازی
+// GET /api/v1/rules/simulations/:simId
+func (h *RuleSetHandler) GetRuleSimulation(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("simId")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid simulation id")
+	}
+
+	sim, err := h.sims.FindByID(c.UserContext(), uint(id), repository.QueryOptions{
+		TenantID: tenantID,
+		Preloads: []string{"RuleSet", "Baseline"},
+	})
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sim,
+	})
+}
+
+// ListRuleSimulationClaims - نتیجه شبیه‌سازی به تفکیک پرونده
+// GET /api/v1/rules/simulations/:simId/claims?filter[changed]=true&sort=approved_diff
+func (h *RuleSetHandler) ListRuleSimulationClaims(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("simId")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid simulation id")
+	}
+	ctx := c.UserContext()
+	if _, err := h.sims.FindByID(ctx, uint(id), repository.QueryOptions{TenantID: tenantID}); err != nil {
+		return respondError(c, err)
+	}
+
+	opts, err := parseListQuery(c, tenantID, repository.RuleSimulationClaimFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+	// Results have no created_at to keep a cursor on
+	if opts.Cursor != nil {
+		return respondError(c, &repository.QueryError{Param: "cursor", Message: "simulation results are paged by page number"})
+	}
+	result, err := h.sims.FindClaims(ctx, uint(id), opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"claims": result.Items,
+			"pagination": fiber.Map{
+				"mode":        "page",
+				"page":        result.Page,
+				"limit":       result.PageSize,
+				"total":       result.Total,
+				"total_pages": result.TotalPages,
+			},
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/search"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchHandler struct {
+	searcher *search.Searcher
+}
+
+func NewSearchHandler(searcher *search.Searcher) *SearchHandler {
+	return &SearchHandler{searcher: searcher}
+}
+
+// Search - جستجوی پرونده‌ها (کد رهگیری، نام بیمه‌شده)، بیمه‌شدگان و مراکز
+// GET /api/v1/search?q=علی احمدی&scope=claims,employees&limit=10
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var scopes []search.Scope
+	if raw := c.Query("scope"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			scope, err := search.ParseScope(strings.TrimSpace(name))
+			if err != nil {
+				return fail(c, fiber.StatusBadRequest, "محدوده جستجو نامعتبر است")
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+
+	results, err := h.searcher.Search(c.UserContext(), tenantID, c.Query("q"), scopes, c.QueryInt("limit"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    results,
+	})
+}
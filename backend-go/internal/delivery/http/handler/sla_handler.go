@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/sla"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SLAHandler struct {
+	policies repository.SLAPolicyRepository
+	breaches repository.SLABreachRepository
+	claims   repository.ClaimRepository
+	settings repository.TenantSettingRepository
+	roles    repository.RoleRepository
+	tracker  *sla.Tracker
+	now      func() time.Time
+}
+
+func NewSLAHandler(policies repository.SLAPolicyRepository, breaches repository.SLABreachRepository, claims repository.ClaimRepository, settings repository.TenantSettingRepository, roles repository.RoleRepository, tracker *sla.Tracker) *SLAHandler {
+	return &SLAHandler{
+		policies: policies,
+		breaches: breaches,
+		claims:   claims,
+		settings: settings,
+		roles:    roles,
+		tracker:  tracker,
+		now:      time.Now,
+	}
+}
+
+// ListSLAPolicies - مهلت‌های تعریف شده برای ماندن پرونده در هر وضعیت
+// GET /api/v1/sla/policies?filter[status]=3
+func (h *SLAHandler) ListSLAPolicies(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.SLAPolicyFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	policies, pagination, err := listPage(c.UserContext(), h.policies, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"policies":   policies,
+			"pagination": pagination,
+		},
+	})
+}
+
+// SaveSLAPolicy - تعریف یا تغییر مهلت یک وضعیت برای یک نوع پرونده
+// PUT /api/v1/sla/policies
+func (h *SLAHandler) SaveSLAPolicy(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var req SaveSLAPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	if msg := validateSLAPolicy(&req); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	policy := &entity.SLAPolicy{
+		TenantID:    tenantID,
+		Status:      req.Status,
+		ClaimType:   req.ClaimType,
+		TargetHours: req.TargetHours,
+		EscalateTo:  req.EscalateTo,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+	if policy.EscalateTo == "" {
+		policy.EscalateTo = entity.RoleSupervisor
+	}
+	policy.CreatedBy = actor.UserID
+	policy.UpdatedBy = actor.UserID
+	if err := h.policies.SavePolicy(c.UserContext(), policy); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "مهلت وضعیت " + policy.Status.String() + " ذخیره شد",
+		"data":    policy,
+	})
+}
+
+// DeleteSLAPolicy - حذف مهلت
+// DELETE /api/v1/sla/policies/:id
+func (h *SLAHandler) DeleteSLAPolicy(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid policy id")
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	if err := h.policies.Delete(c.UserContext(), tenantID, uint(id)); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "مهلت حذف شد",
+	})
+}
+
+// GetWorkCalendar - ساعت کاری و تعطیلات بیمه‌گر
+// GET /api/v1/sla/calendar?from=2024-03-20
+func (h *SLAHandler) GetWorkCalendar(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	from := time.Date(h.now().In(h.tracker.Location()).Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return fail(c, fiber.StatusBadRequest, "تاریخ باید به شکل YYYY-MM-DD باشد")
+		}
+	}
+
+	ctx := c.UserContext()
+	hours, err := sla.LoadWorkHours(ctx, h.settings, tenantID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	holidays, err := h.policies.FindHolidays(ctx, tenantID, from)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"work_hours": hours,
+			"holidays":   holidays,
+		},
+	})
+}
+
+// UpdateWorkHours - تغییر ساعت و روزهای کاری
+// PUT /api/v1/sla/calendar/work-hours
+func (h *SLAHandler) UpdateWorkHours(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var req UpdateWorkHoursRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	hours := &sla.WorkHours{Start: strings.TrimSpace(req.Start), End: strings.TrimSpace(req.End), Days: req.Days}
+	if msg := hours.Validate(); msg != "" {
+		return fail(c, fiber.StatusBadRequest, msg)
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	if err := sla.SaveWorkHours(c.UserContext(), h.settings, tenantID, hours); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "ساعت کاری ذخیره شد",
+		"data":    hours,
+	})
+}
+
+// CreateHoliday - افزودن روز تعطیل
+// POST /api/v1/sla/holidays
+func (h *SLAHandler) CreateHoliday(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var req CreateHolidayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, fiber.StatusBadRequest, "داده‌های ورودی نامعتبر است")
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(req.Date))
+	if err != nil {
+		return fail(c, fiber.StatusBadRequest, "تاریخ باید به شکل YYYY-MM-DD باشد")
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	ctx := c.UserContext()
+	existing, err := h.policies.FindHolidays(ctx, tenantID, date)
+	if err != nil {
+		return respondError(c, err)
+	}
+	if len(existing) > 0 && existing[0].Date.UTC().Equal(date) {
+		return fail(c, fiber.StatusConflict, "این روز قبلا تعطیل ثبت شده است")
+	}
+
+	holiday := &entity.Holiday{TenantID: tenantID, Date: date, Title: strings.TrimSpace(req.Title)}
+	if err := h.policies.CreateHoliday(ctx, holiday); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "روز تعطیل ثبت شد",
+		"data":    holiday,
+	})
+}
+
+// DeleteHoliday - حذف روز تعطیل
+// DELETE /api/v1/sla/holidays/:id
+func (h *SLAHandler) DeleteHoliday(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid holiday id")
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermSettingsUpdate) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermSettingsUpdate)+" لازم است")
+	}
+
+	if err := h.policies.DeleteHoliday(c.UserContext(), tenantID, uint(id)); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "روز تعطیل حذف شد",
+	})
+}
+
+// GetAging - پرونده‌های منتظر به تفکیک وضعیت، نوع و مدت انتظار (روز کاری)
+// GET /api/v1/sla/aging?status=3,8
+func (h *SLAHandler) GetAging(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	var statuses []entity.ClaimStatus
+	if v := c.Query("status"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			s, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+			if err != nil || !isOpenStatus(entity.ClaimStatus(s)) {
+				return fail(c, fiber.StatusBadRequest, "وضعیت "+part+" نامعتبر است")
+			}
+			statuses = append(statuses, entity.ClaimStatus(s))
+		}
+	}
+
+	report, err := h.tracker.Aging(c.UserContext(), tenantID, statuses)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// ListSLABreaches - تخطی‌های ثبت شده از مهلت‌ها
+// GET /api/v1/sla/breaches?open=true&acknowledged=false&filter[escalated_to]=supervisor
+func (h *SLAHandler) ListSLABreaches(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	opts, err := parseListQuery(c, tenantID, repository.SLABreachFields)
+	if err != nil {
+		return respondError(c, err)
+	}
+	// A nil value matches NULL, which the query string cannot express
+	for param, field := range map[string]string{"open": "resolved_at", "acknowledged": "acknowledged_at"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		set, err := strconv.ParseBool(v)
+		if err != nil {
+			return fail(c, fiber.StatusBadRequest, "مقدار "+param+" باید true یا false باشد")
+		}
+		op := repository.OpEq
+		if set == (param == "acknowledged") {
+			op = repository.OpNe
+		}
+		opts.Filters = append(opts.Filters, repository.Filter{Field: field, Operator: op, Value: nil})
+	}
+	opts.Preloads = []string{"Claim"}
+
+	breaches, pagination, err := listPage(c.UserContext(), h.breaches, opts)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"breaches":   breaches,
+			"pagination": pagination,
+		},
+	})
+}
+
+// AcknowledgeSLABreach - پذیرش پیگیری تخطی توسط سرپرست
+// POST /api/v1/sla/breaches/:id/acknowledge
+func (h *SLAHandler) AcknowledgeSLABreach(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid breach id")
+	}
+	actor, err := resolveActor(c, h.roles)
+	if err != nil {
+		return err
+	}
+	if !actor.Can(entity.PermClaimAssign) {
+		return fail(c, fiber.StatusForbidden, "مجوز "+string(entity.PermClaimAssign)+" لازم است")
+	}
+
+	ctx := c.UserContext()
+	breach, err := h.breaches.FindByID(ctx, uint(id), repository.QueryOptions{TenantID: tenantID})
+	if err != nil {
+		return respondError(c, err)
+	}
+	if err := h.breaches.Acknowledge(ctx, breach, actor.UserID, h.now()); err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "پیگیری تخطی ثبت شد",
+		"data":    breach,
+	})
+}
+
+// GetClaimSLA - مهلت وضعیت فعلی پرونده و تخطی‌های آن
+// GET /api/v1/claims/:id/sla
+func (h *SLAHandler) GetClaimSLA(c *fiber.Ctx) error {
+	tenantID, err := tenantID(c)
+	if err != nil {
+		return err
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid claim id")
+	}
+
+	ctx := c.UserContext()
+	claim, err := h.claims.FindByID(ctx, uint(id), repository.QueryOptions{TenantID: tenantID})
+	if err != nil {
+		return respondError(c, err)
+	}
+	clock, err := h.tracker.Clock(ctx, claim)
+	if err != nil {
+		return respondError(c, err)
+	}
+	breaches, err := h.breaches.FindByClaim(ctx, tenantID, claim.ID)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"clock":    clock,
+			"breaches": breaches,
+		},
+	})
+}
+
+// validateSLAPolicy returns a user facing message for the first invalid field
+func validateSLAPolicy(req *SaveSLAPolicyRequest) string {
+	switch {
+	case !isOpenStatus(req.Status):
+		return "برای این وضعیت نمی‌توان مهلت تعریف کرد"
+	case req.ClaimType != 0 && !req.ClaimType.IsValid():
+		return "نوع پرونده نامعتبر است"
+	case req.TargetHours <= 0:
+		return "مهلت باید حداقل یک ساعت کاری باشد"
+	case req.EscalateTo != "" && req.EscalateTo != entity.RoleSupervisor && req.EscalateTo != entity.RoleInsurerAdmin:
+		return "ارجاع تخطی فقط به سرپرست یا مدیر بیمه‌گر ممکن است"
+	}
+	return ""
+}
+
+func isOpenStatus(status entity.ClaimStatus) bool {
+	for _, s := range sla.OpenStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/bank-melli/tpa/internal/infrastructure/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// QueryMetrics attributes database statements issued by a request to its route
+func QueryMetrics(instrumentation *database.Instrumentation) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, queries := database.WithQueryStats(c.UserContext(), c.Method(), utils.CopyString(c.Path()))
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// The matched route is only known once the handler chain has run
+		instrumentation.Finish(c.Route().Path, queries)
+		return err
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/bank-melli/tpa/internal/infrastructure/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadYourWrites lets a request's reads use replicas until it writes, after
+// which the rest of the request reads from the primary
+func ReadYourWrites() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(database.TrackWrites(c.UserContext()))
+		return c.Next()
+	}
+}
@@ -3,11 +3,13 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/bank-melli/tpa/internal/delivery/http/handler"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/search"
 )
 
-func SetupEmployeeRoutes(api fiber.Router) {
-	employeeHandler := handler.NewEmployeeHandler()
-	importHandler := handler.NewEmployeeImportHandler()
+func SetupEmployeeRoutes(api fiber.Router, employeeRepo repository.EmployeeRepository, historyRepo repository.ImportHistoryRepository, searcher *search.Searcher) {
+	employeeHandler := handler.NewEmployeeHandler(employeeRepo, searcher)
+	importHandler := handler.NewEmployeeImportHandler(historyRepo)
 
 	employees := api.Group("/employees")
 	{
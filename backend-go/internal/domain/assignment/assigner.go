@@ -0,0 +1,416 @@
+// Package assignment routes claims waiting for examination to examiners.
+// Each claim type has a work queue served by one examiner role. In pull mode
+// examiners ask for their next claim; in push mode a submitted claim goes
+// straight to the best eligible examiner: the most skilled in the claim type,
+// then the least loaded. Examiners who are away or at capacity are skipped,
+// and supervisors may move a claim to someone else at any time.
+package assignment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// ExaminerRoles are the roles work queues may be served by
+var ExaminerRoles = []entity.RoleName{entity.RoleClaimExaminer, entity.RoleDrugExaminer}
+
+// PendingStatuses are the statuses in which a claim sits in its work queue
+// and counts towards its examiner's load
+var PendingStatuses = []entity.ClaimStatus{entity.ClaimStatusWaitCheck, entity.ClaimStatusWaitCheckAgain}
+
+var (
+	// ErrNotExaminer is returned when a user pulling claims serves no queue
+	ErrNotExaminer = errors.New("assignment: user is not an examiner")
+	// ErrUnavailable is returned when an examiner pulling claims is marked away
+	ErrUnavailable = errors.New("assignment: examiner is unavailable")
+	// ErrAtCapacity is returned when an examiner pulling claims has a full desk
+	ErrAtCapacity = errors.New("assignment: examiner is at capacity")
+)
+
+// IneligibleError is returned when a claim cannot be given to the chosen examiner
+type IneligibleError struct {
+	UserID  uint
+	Message string // user facing
+}
+
+func (e *IneligibleError) Error() string {
+	return fmt.Sprintf("assignment: user %d is not eligible: %s", e.UserID, e.Message)
+}
+
+// Candidate is an examiner of a queue with their standing for one claim type
+type Candidate struct {
+	UserID    uint            `json:"user_id"`
+	Name      string          `json:"name"`
+	Role      entity.RoleName `json:"role"`
+	Level     int             `json:"level"` // مهارت در نوع پرونده؛ صفر یعنی فاقد مهارت
+	Load      int64           `json:"load"`  // پرونده‌های باز
+	Capacity  int             `json:"capacity"`
+	Available bool            `json:"available"`
+}
+
+// Eligible reports whether the candidate may be given another claim
+func (c *Candidate) Eligible() bool {
+	return c.Available && c.Level > 0 && c.Load < int64(c.Capacity)
+}
+
+// QueueOverview is one queue with its backlog and examiners
+type QueueOverview struct {
+	Queue     entity.WorkQueue        `json:"queue"`
+	Backlog   repository.QueueBacklog `json:"backlog"`
+	Examiners []Candidate             `json:"examiners"`
+}
+
+// Examiner is an examiner with their profile and open claims
+type Examiner struct {
+	entity.ExaminerProfile
+	Role entity.RoleName `json:"role"`
+	Load int64           `json:"load"`
+}
+
+// DefaultQueue is the queue of claim types without one of their own: drug
+// claims go to drug examiners, the others to claim examiners, by pull
+func DefaultQueue(tenantID uint, claimType entity.ClaimType) *entity.WorkQueue {
+	role := entity.RoleClaimExaminer
+	if claimType == entity.ClaimTypeDrug {
+		role = entity.RoleDrugExaminer
+	}
+	return &entity.WorkQueue{TenantID: tenantID, ClaimType: claimType, Role: role, Mode: entity.QueueModePull, IsActive: true}
+}
+
+// DefaultProfile is the profile of examiners who have not been given one
+func DefaultProfile(tenantID, userID uint) entity.ExaminerProfile {
+	return entity.ExaminerProfile{TenantID: tenantID, UserID: userID, Capacity: 20, Available: true}
+}
+
+// Assigner assigns claims to examiners
+type Assigner struct {
+	queues repository.WorkQueueRepository
+	now    func() time.Time
+}
+
+// NewAssigner creates an assigner
+func NewAssigner(queues repository.WorkQueueRepository) *Assigner {
+	return &Assigner{queues: queues, now: time.Now}
+}
+
+// Queue returns the queue of claimType, or its default
+func (a *Assigner) Queue(ctx context.Context, tenantID uint, claimType entity.ClaimType) (*entity.WorkQueue, error) {
+	q, err := a.queues.FindByClaimType(ctx, tenantID, claimType)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultQueue(tenantID, claimType), nil
+	}
+	return q, err
+}
+
+// Candidates ranks the examiners of queue for its claim type, best first
+func (a *Assigner) Candidates(ctx context.Context, queue *entity.WorkQueue) ([]Candidate, error) {
+	candidates, err := a.candidates(ctx, queue.TenantID, []entity.RoleName{queue.Role}, queue.ClaimType)
+	if err != nil {
+		return nil, err
+	}
+	rank(candidates)
+	return candidates, nil
+}
+
+// Push assigns claim, just submitted, to the best eligible examiner when its
+// queue is an active push queue. It returns the assignment, or nil when the
+// claim stays in the queue.
+func (a *Assigner) Push(ctx context.Context, claim *entity.Claim) (*entity.ClaimAssignment, error) {
+	queue, err := a.Queue(ctx, claim.TenantID, claim.ClaimType)
+	if err != nil {
+		return nil, err
+	}
+	if !queue.IsActive || queue.Mode != entity.QueueModePush {
+		return nil, nil
+	}
+	candidates, err := a.Candidates(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 || !candidates[0].Eligible() {
+		return nil, nil
+	}
+
+	to := candidates[0].UserID
+	record := a.record(claim, &to, entity.AssignmentPush, 0, "")
+	if err := a.queues.Assign(ctx, claim, PendingStatuses, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Dispatch pushes the unassigned claims of claimType's queue to its
+// examiners, oldest first, until they are all at capacity. It returns the
+// assignments made.
+func (a *Assigner) Dispatch(ctx context.Context, tenantID uint, claimType entity.ClaimType, by uint) ([]entity.ClaimAssignment, error) {
+	queue, err := a.Queue(ctx, tenantID, claimType)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := a.Candidates(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	var made []entity.ClaimAssignment
+	for len(candidates) > 0 && candidates[0].Eligible() {
+		to := candidates[0].UserID
+		record := &entity.ClaimAssignment{UserID: &to, Mode: entity.AssignmentPush, AssignedBy: by, CreatedAt: a.now()}
+		_, err := a.queues.AssignNext(ctx, tenantID, []entity.ClaimType{claimType}, PendingStatuses, record)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return made, err
+		}
+		made = append(made, *record)
+
+		// Re-rank: the examiner just given a claim may no longer come first
+		candidates[0].Load++
+		rank(candidates)
+	}
+	return made, nil
+}
+
+// Next gives the examiner the oldest unassigned claim of the active queues
+// they serve and are skilled in. It returns nil when those queues are empty.
+func (a *Assigner) Next(ctx context.Context, tenantID, userID uint) (*entity.Claim, error) {
+	examiners, err := a.load(ctx, tenantID, ExaminerRoles)
+	if err != nil {
+		return nil, err
+	}
+	var me *Examiner
+	for i := range examiners {
+		if examiners[i].UserID == userID {
+			me = &examiners[i]
+			break
+		}
+	}
+	switch {
+	case me == nil:
+		return nil, ErrNotExaminer
+	case !me.IsAvailable(a.now()):
+		return nil, ErrUnavailable
+	case me.Load >= int64(me.Capacity):
+		return nil, ErrAtCapacity
+	}
+
+	queues, err := a.all(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	var claimTypes []entity.ClaimType
+	for _, q := range queues {
+		if q.IsActive && q.Role == me.Role && me.SkillFor(q.ClaimType) > 0 {
+			claimTypes = append(claimTypes, q.ClaimType)
+		}
+	}
+	if len(claimTypes) == 0 {
+		return nil, ErrNotExaminer
+	}
+
+	record := &entity.ClaimAssignment{UserID: &userID, Mode: entity.AssignmentPull, AssignedBy: userID, CreatedAt: a.now()}
+	claim, err := a.queues.AssignNext(ctx, tenantID, claimTypes, PendingStatuses, record)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return claim, err
+}
+
+// Reassign moves claim to the examiner to, or back to its queue when to is
+// nil. The examiner must serve the claim's queue, be skilled in its type and
+// be available; supervisors may go over capacity.
+func (a *Assigner) Reassign(ctx context.Context, claim *entity.Claim, to *uint, by uint, reason string) (*entity.ClaimAssignment, error) {
+	if to != nil {
+		queue, err := a.Queue(ctx, claim.TenantID, claim.ClaimType)
+		if err != nil {
+			return nil, err
+		}
+		candidates, err := a.candidates(ctx, claim.TenantID, []entity.RoleName{queue.Role}, claim.ClaimType)
+		if err != nil {
+			return nil, err
+		}
+		if msg := eligibleForReassign(candidates, *to); msg != "" {
+			return nil, &IneligibleError{UserID: *to, Message: msg}
+		}
+	}
+
+	record := a.record(claim, to, entity.AssignmentReassign, by, strings.TrimSpace(reason))
+	if err := a.queues.Assign(ctx, claim, PendingStatuses, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Examiners lists the tenant's claim and drug examiners with their profiles,
+// defaults included, and loads
+func (a *Assigner) Examiners(ctx context.Context, tenantID uint) ([]Examiner, error) {
+	return a.load(ctx, tenantID, ExaminerRoles)
+}
+
+// Overview reports every claim type's queue with its backlog and examiners
+func (a *Assigner) Overview(ctx context.Context, tenantID uint) ([]QueueOverview, error) {
+	backlog, err := a.queues.Backlog(ctx, tenantID, PendingStatuses)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[entity.ClaimType]repository.QueueBacklog, len(backlog))
+	for _, b := range backlog {
+		byType[b.ClaimType] = b
+	}
+
+	queues, err := a.all(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	overview := make([]QueueOverview, len(queues))
+	for i := range queues {
+		candidates, err := a.Candidates(ctx, &queues[i])
+		if err != nil {
+			return nil, err
+		}
+		b, ok := byType[queues[i].ClaimType]
+		if !ok {
+			b = repository.QueueBacklog{ClaimType: queues[i].ClaimType}
+		}
+		overview[i] = QueueOverview{Queue: queues[i], Backlog: b, Examiners: candidates}
+	}
+	return overview, nil
+}
+
+// rank orders candidates best first: eligible ones, then by skill, then by load
+func rank(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.Eligible() != cj.Eligible() {
+			return ci.Eligible()
+		}
+		if ci.Level != cj.Level {
+			return ci.Level > cj.Level
+		}
+		return ci.Load < cj.Load
+	})
+}
+
+// all returns the queue of every claim type, defaults included
+func (a *Assigner) all(ctx context.Context, tenantID uint) ([]entity.WorkQueue, error) {
+	rows, err := a.queues.FindAll(ctx, repository.QueryOptions{TenantID: tenantID})
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[entity.ClaimType]entity.WorkQueue, len(rows))
+	for _, q := range rows {
+		byType[q.ClaimType] = q
+	}
+	queues := make([]entity.WorkQueue, len(allClaimTypes))
+	for i, ct := range allClaimTypes {
+		q, ok := byType[ct]
+		if !ok {
+			q = *DefaultQueue(tenantID, ct)
+		}
+		queues[i] = q
+	}
+	return queues, nil
+}
+
+// load lists the tenant's users of roles with their profiles and loads
+func (a *Assigner) load(ctx context.Context, tenantID uint, roles []entity.RoleName) ([]Examiner, error) {
+	users, err := a.queues.FindExaminers(ctx, tenantID, roles)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	profiles, err := a.queues.FindProfiles(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	loads, err := a.queues.Loads(ctx, tenantID, PendingStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	examiners := make([]Examiner, len(users))
+	for i := range users {
+		u := &users[i]
+		p, ok := profiles[u.ID]
+		if !ok {
+			p = DefaultProfile(tenantID, u.ID)
+		}
+		p.User = u
+		examiners[i] = Examiner{ExaminerProfile: p, Load: loads[u.ID]}
+		if u.Role != nil {
+			examiners[i].Role = u.Role.Name
+		}
+	}
+	return examiners, nil
+}
+
+// candidates lists the tenant's users of roles with their standing for claimType
+func (a *Assigner) candidates(ctx context.Context, tenantID uint, roles []entity.RoleName, claimType entity.ClaimType) ([]Candidate, error) {
+	examiners, err := a.load(ctx, tenantID, roles)
+	if err != nil {
+		return nil, err
+	}
+	now := a.now()
+	candidates := make([]Candidate, len(examiners))
+	for i, e := range examiners {
+		candidates[i] = Candidate{
+			UserID:    e.UserID,
+			Name:      strings.TrimSpace(e.User.FirstName + " " + e.User.LastName),
+			Role:      e.Role,
+			Level:     e.SkillFor(claimType),
+			Load:      e.Load,
+			Capacity:  e.Capacity,
+			Available: e.IsAvailable(now),
+		}
+	}
+	return candidates, nil
+}
+
+func (a *Assigner) record(claim *entity.Claim, to *uint, mode entity.AssignmentMode, by uint, reason string) *entity.ClaimAssignment {
+	return &entity.ClaimAssignment{
+		TenantID:       claim.TenantID,
+		ClaimID:        claim.ID,
+		UserID:         to,
+		PreviousUserID: claim.HandlerUserID,
+		Mode:           mode,
+		AssignedBy:     by,
+		Reason:         reason,
+		CreatedAt:      a.now(),
+	}
+}
+
+// eligibleForReassign returns why user may not take a reassigned claim, or
+// "" if they may
+func eligibleForReassign(candidates []Candidate, user uint) string {
+	for _, c := range candidates {
+		if c.UserID != user {
+			continue
+		}
+		switch {
+		case c.Level == 0:
+			return "کاربر مهارت ارزیابی این نوع پرونده را ندارد"
+		case !c.Available:
+			return "کاربر در حال حاضر در دسترس نیست"
+		}
+		return ""
+	}
+	return "کاربر ارزیاب فعال صف این نوع پرونده نیست"
+}
+
+var allClaimTypes = []entity.ClaimType{
+	entity.ClaimTypeDrug, entity.ClaimTypeHospitalization, entity.ClaimTypeDental, entity.ClaimTypeDoctorVisit,
+	entity.ClaimTypeLabTest, entity.ClaimTypeImaging, entity.ClaimTypePhysiotherapy, entity.ClaimTypeOutpatientSurgery,
+	entity.ClaimTypeEmergency, entity.ClaimTypeMedicalEquipment, entity.ClaimTypeInjection, entity.ClaimTypeClinic,
+}
@@ -0,0 +1,335 @@
+// Package attachment keeps the documents of claims and packages: scanned
+// invoices, prescriptions, medical reports and letters. An upload must be of
+// an attachment type of its claim type; its content type is sniffed from its
+// bytes rather than trusted from the client, and checked with its size
+// against the type. It is hashed with SHA-256, scanned for malware when a
+// scanner is configured, and kept in the blob store under a key prefixed
+// with its tenant. Files are downloaded through short-lived links signed
+// for one file of one tenant.
+package attachment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownType is returned for an upload of a type its owner does not accept
+	ErrUnknownType = errors.New("attachment: unknown attachment type")
+	// ErrEmpty is returned for an upload without content
+	ErrEmpty = errors.New("attachment: empty file")
+)
+
+// TooLargeError is returned for an upload over the size limit of its type
+type TooLargeError struct {
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("attachment: file exceeds %d bytes", e.Limit)
+}
+
+// ContentTypeError is returned for an upload whose content is of a type not allowed
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return "attachment: content type not allowed: " + e.ContentType
+}
+
+// Config limits uploads
+type Config struct {
+	MaxSize   int64         // bytes; attachment types may lower it
+	MIMETypes []string      // sniffed types accepted by types without a list of their own
+	URLExpiry time.Duration // lifetime of download links
+}
+
+// DefaultConfig returns the default upload limits
+func DefaultConfig() Config {
+	return Config{
+		MaxSize:   10 << 20,
+		MIMETypes: []string{"application/pdf", "image/jpeg", "image/png", "image/tiff"},
+		URLExpiry: 15 * time.Minute,
+	}
+}
+
+// extensions name stored blobs after their sniffed type
+var extensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/tiff":      ".tif",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+}
+
+// DefaultTypes are the attachment types of tenants that have not defined
+// their own for owner: invoices for every claim, prescriptions for drug
+// claims, discharge summaries for hospitalization, letters for packages
+func DefaultTypes(tenantID uint, owner entity.AttachmentOwner, claimType entity.ClaimType) []entity.AttachmentType {
+	if owner == entity.AttachmentOwnerPackage {
+		return []entity.AttachmentType{
+			{TenantID: tenantID, Owner: owner, Code: "letter", Title: "نامه ارسال", Required: true, IsActive: true},
+			{TenantID: tenantID, Owner: owner, Code: "list", Title: "فهرست اسناد", IsActive: true},
+		}
+	}
+
+	types := []entity.AttachmentType{
+		{TenantID: tenantID, Owner: owner, Code: "invoice", Title: "فاکتور", Required: true, IsActive: true},
+	}
+	switch claimType {
+	case entity.ClaimTypeDrug:
+		types = append(types, entity.AttachmentType{TenantID: tenantID, Owner: owner, ClaimType: claimType, Code: "prescription", Title: "نسخه", Required: true, IsActive: true})
+	case entity.ClaimTypeHospitalization:
+		types = append(types, entity.AttachmentType{TenantID: tenantID, Owner: owner, ClaimType: claimType, Code: "discharge_summary", Title: "خلاصه پرونده بستری", Required: true, IsActive: true})
+	}
+	return append(types,
+		entity.AttachmentType{TenantID: tenantID, Owner: owner, Code: "medical_report", Title: "گزارش پزشکی", IsActive: true},
+		entity.AttachmentType{TenantID: tenantID, Owner: owner, Code: "other", Title: "سایر مدارک", IsActive: true},
+	)
+}
+
+// Upload is a file to attach
+type Upload struct {
+	TenantID  uint
+	Owner     entity.AttachmentOwner
+	OwnerID   uint
+	ClaimType entity.ClaimType // of the claim; zero for packages
+	TypeCode  string
+	FileName  string
+	Body      io.Reader
+	UserID    uint
+}
+
+// Service stores and serves attachments
+type Service struct {
+	repo    repository.AttachmentRepository
+	store   storage.Store
+	scanner storage.Scanner
+	signer  *storage.URLSigner
+	config  Config
+	now     func() time.Time
+}
+
+// NewService creates a service; a nil scanner skips malware scanning
+func NewService(repo repository.AttachmentRepository, store storage.Store, scanner storage.Scanner, signer *storage.URLSigner, config ...Config) *Service {
+	cfg := DefaultConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return &Service{repo: repo, store: store, scanner: scanner, signer: signer, config: cfg, now: time.Now}
+}
+
+// Types returns the active attachment types for owner and claimType. A type
+// of the claim type replaces the one of all claim types with its code.
+func (s *Service) Types(ctx context.Context, tenantID uint, owner entity.AttachmentOwner, claimType entity.ClaimType) ([]entity.AttachmentType, error) {
+	rows, err := s.repo.FindTypes(ctx, tenantID, owner, claimType)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return DefaultTypes(tenantID, owner, claimType), nil
+	}
+
+	// Rows come ordered by claim type, so specific ones come last
+	byCode := make(map[string]int, len(rows))
+	var types []entity.AttachmentType
+	for _, t := range rows {
+		if i, ok := byCode[t.Code]; ok {
+			types[i] = t
+			continue
+		}
+		byCode[t.Code] = len(types)
+		types = append(types, t)
+	}
+	active := types[:0]
+	for _, t := range types {
+		if t.IsActive {
+			active = append(active, t)
+		}
+	}
+	return active, nil
+}
+
+// Missing returns the required types without an attachment
+func Missing(types []entity.AttachmentType, attachments []entity.Attachment) []entity.AttachmentType {
+	have := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		have[a.TypeCode] = true
+	}
+	missing := []entity.AttachmentType{}
+	for _, t := range types {
+		if t.Required && !have[t.Code] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+// Upload checks, scans and stores a file and records it
+func (s *Service) Upload(ctx context.Context, in Upload) (*entity.Attachment, error) {
+	t, err := s.typeOf(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	limit := s.config.MaxSize
+	if t.MaxSize > 0 && t.MaxSize < limit {
+		limit = t.MaxSize
+	}
+
+	// Spooled to disk so the content can be sniffed, hashed and scanned
+	// before anything reaches the store
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(in.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > limit {
+		return nil, &TooLargeError{Limit: limit}
+	}
+	if size == 0 {
+		return nil, ErrEmpty
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	contentType := storage.DetectContentType(head[:n])
+	if !allowed(contentType, t, s.config.MIMETypes) {
+		return nil, &ContentTypeError{ContentType: contentType}
+	}
+
+	scanStatus := entity.ScanStatusSkipped
+	if s.scanner != nil {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := s.scanner.Scan(ctx, tmp); err != nil {
+			return nil, err
+		}
+		scanStatus = entity.ScanStatusClean
+	}
+
+	att := &entity.Attachment{
+		TenantID:    in.TenantID,
+		Owner:       in.Owner,
+		OwnerID:     in.OwnerID,
+		TypeCode:    t.Code,
+		FileName:    fileName(in.FileName, contentType),
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  fmt.Sprintf("tenants/%d/%ss/%d/%s%s", in.TenantID, in.Owner, in.OwnerID, uuid.NewString(), extensions[contentType]),
+		ScanStatus:  scanStatus,
+	}
+	att.CreatedBy = in.UserID
+	att.UpdatedBy = in.UserID
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	obj := storage.Object{Key: att.StorageKey, Size: size, ContentType: contentType, SHA256: att.Checksum}
+	if err := s.store.Put(ctx, obj, tmp); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, att); err != nil {
+		// Nothing refers to the blob; removed even if the request was cancelled
+		if derr := s.store.Delete(context.WithoutCancel(ctx), att.StorageKey); derr != nil {
+			err = errors.Join(err, derr)
+		}
+		return nil, err
+	}
+	return att, nil
+}
+
+// Open returns the content of att
+func (s *Service) Open(ctx context.Context, att *entity.Attachment) (io.ReadCloser, error) {
+	return s.store.Open(ctx, att.StorageKey)
+}
+
+// Delete removes att from its owner. The blob is kept, so a deleted
+// document can still be recovered for an audit.
+func (s *Service) Delete(ctx context.Context, att *entity.Attachment) error {
+	return s.repo.Delete(ctx, att.TenantID, att.ID)
+}
+
+// SignURL returns a download token for att and when it expires
+func (s *Service) SignURL(att *entity.Attachment) (string, time.Time) {
+	expires := s.now().Add(s.config.URLExpiry)
+	return s.signer.Sign(att.TenantID, att.ID, expires), expires
+}
+
+// Resolve returns the attachment a download token was signed for
+func (s *Service) Resolve(ctx context.Context, token string) (*entity.Attachment, error) {
+	tenantID, id, err := s.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, id, repository.QueryOptions{TenantID: tenantID})
+}
+
+// typeOf returns the active type of the upload
+func (s *Service) typeOf(ctx context.Context, in Upload) (*entity.AttachmentType, error) {
+	types, err := s.Types(ctx, in.TenantID, in.Owner, in.ClaimType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range types {
+		if types[i].Code == in.TypeCode {
+			return &types[i], nil
+		}
+	}
+	return nil, ErrUnknownType
+}
+
+// allowed reports whether contentType is accepted by t, or by defaults if t has no list
+func allowed(contentType string, t *entity.AttachmentType, defaults []string) bool {
+	accepted := defaults
+	if t.MIMETypes != "" {
+		accepted = strings.Split(t.MIMETypes, ",")
+	}
+	for _, a := range accepted {
+		if strings.TrimSpace(a) == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// fileName returns the base name of the client's file name, or a name after
+// the content type, no longer than the column
+func fileName(name, contentType string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "attachment" + extensions[contentType]
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
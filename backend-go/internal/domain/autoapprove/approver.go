@@ -0,0 +1,130 @@
+package autoapprove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/bank-melli/tpa/internal/domain/duplicate"
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/rules"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+)
+
+// Decision explains what happened to a submitted claim under its tenant's policy
+type Decision struct {
+	Approved  bool     `json:"approved"`
+	Threshold int64    `json:"threshold"`
+	Sampled   bool     `json:"sampled"`           // برای بازبینی انتخاب شد
+	Reasons   []string `json:"reasons,omitempty"` // علت ارجاع به ارزیاب
+}
+
+// Approver decides on and carries out auto-approvals
+type Approver struct {
+	settings   repository.TenantSettingRepository
+	duplicates *duplicate.Detector
+	machine    *workflow.ClaimMachine
+	sample     func() float64 // uniform in [0, 100)
+}
+
+// NewApprover creates an approver firing transitions through machine
+func NewApprover(settings repository.TenantSettingRepository, duplicates *duplicate.Detector, machine *workflow.ClaimMachine) *Approver {
+	return &Approver{
+		settings:   settings,
+		duplicates: duplicates,
+		machine:    machine,
+		sample:     func() float64 { return rand.Float64() * 100 },
+	}
+}
+
+// Approve examines and approves claim, just submitted and loaded with its
+// Items, as the system when the tenant's policy allows it. eval is the
+// submission's rule evaluation, nil when the tenant has no rules in effect.
+// It returns nil when auto-approval is off for the claim type; otherwise the
+// decision and, if approved, the history of the examine and approve
+// transitions, which the caller persists with the claim.
+func (a *Approver) Approve(ctx context.Context, claim *entity.Claim, eval *rules.Evaluation) (*Decision, []*entity.ClaimStatusHistory, error) {
+	policy, err := LoadPolicy(ctx, a.settings, claim.TenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	threshold := policy.ThresholdFor(claim.ClaimType)
+	if threshold <= 0 {
+		return nil, nil, nil
+	}
+
+	d := &Decision{Threshold: threshold}
+	if claim.RequestAmount >= threshold {
+		d.Reasons = append(d.Reasons, "مبلغ درخواستی کمتر از سقف تایید خودکار نیست")
+		return d, nil, nil
+	}
+	if eval != nil && (eval.Deduction > 0 || len(eval.Flags) > 0) {
+		d.Reasons = append(d.Reasons, "قوانین ارزیابی برای پرونده کسورات یا هشدار دارند")
+		return d, nil, nil
+	}
+	report, err := a.duplicates.Check(ctx, claim)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(report.Findings) > 0 {
+		d.Reasons = append(d.Reasons, "پرونده خدمت تکراری یا بستری همپوشان دارد")
+		return d, nil, nil
+	}
+
+	// Work on a copy, so that a claim the system cannot approve is left as submitted
+	approved := *claim
+	approved.Items = append([]entity.ClaimItem(nil), claim.Items...)
+	history, err := a.examineAndApprove(&approved, threshold)
+	var examErr *workflow.ExaminationError
+	var transitionErr *workflow.TransitionError
+	switch {
+	case errors.As(err, &examErr):
+		d.Reasons = append(d.Reasons, examErr.Message)
+		return d, nil, nil
+	case errors.As(err, &transitionErr):
+		d.Reasons = append(d.Reasons, transitionErr.Message)
+		return d, nil, nil
+	case err != nil:
+		return nil, nil, err
+	}
+
+	approved.AutoApproved = true
+	if policy.SampleRate > 0 && a.sample() < policy.SampleRate {
+		approved.SampleReview = entity.SampleReviewPending
+		d.Sampled = true
+	}
+	*claim = approved
+	d.Approved = true
+	return d, history, nil
+}
+
+// examineAndApprove confirms the full payable amount of the claim and fires
+// examine and approve as the system
+func (a *Approver) examineAndApprove(claim *entity.Claim, threshold int64) ([]*entity.ClaimStatusHistory, error) {
+	exam := workflow.Examination{ApprovedAmount: claim.PayableAmount()}
+	if len(claim.Items) > 0 {
+		exam.ApprovedAmount = 0
+	}
+	for _, item := range claim.Items {
+		exam.Items = append(exam.Items, workflow.ItemExamination{ItemID: item.ID, ConfirmedPrice: item.PayableAmount()})
+	}
+	if _, err := workflow.ApplyExamination(claim, exam, nil); err != nil {
+		return nil, err
+	}
+
+	in := workflow.Input{
+		Actor:  workflow.SystemActor(),
+		Reason: fmt.Sprintf("تایید خودکار: مبلغ درخواستی کمتر از سقف %d ریال", threshold),
+	}
+	var history []*entity.ClaimStatusHistory
+	for _, action := range []workflow.Action{workflow.ActionExamine, workflow.ActionApprove} {
+		h, err := a.machine.Fire(claim, action, in)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
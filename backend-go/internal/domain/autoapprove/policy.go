@@ -0,0 +1,104 @@
+// Package autoapprove lets low-value claims skip the examiner. A submitted
+// claim whose requested amount is under the tenant's threshold, on which the
+// rules deduct and flag nothing and which repeats no earlier service, is
+// examined and approved by the system actor right away. A random share of
+// these claims is marked for a person to review later.
+package autoapprove
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+)
+
+// Tenant settings holding the policy
+const (
+	SettingThreshold  = "claim_auto_approve_threshold"   // ریال؛ صفر یعنی غیرفعال
+	SettingThresholds = "claim_auto_approve_thresholds"  // JSON: نوع پرونده -> سقف
+	SettingSampleRate = "claim_auto_approve_sample_rate" // درصد
+)
+
+// Policy is a tenant's auto-approval setup. Amounts are in rials.
+type Policy struct {
+	// Threshold applies to claim types without their own threshold; zero
+	// turns auto-approval off for them
+	Threshold int64 `json:"threshold"`
+	// Thresholds override Threshold per claim type; zero turns it off for the type
+	Thresholds map[entity.ClaimType]int64 `json:"thresholds"`
+	// SampleRate is the percentage of auto-approved claims marked for review
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// ThresholdFor returns the threshold of claimType, zero when it is not auto-approved
+func (p *Policy) ThresholdFor(claimType entity.ClaimType) int64 {
+	if t, ok := p.Thresholds[claimType]; ok {
+		return t
+	}
+	return p.Threshold
+}
+
+// Validate returns a user facing message for the first invalid value
+func (p *Policy) Validate() string {
+	if p.Threshold < 0 {
+		return "سقف تایید خودکار نمی‌تواند منفی باشد"
+	}
+	for t, amount := range p.Thresholds {
+		if !t.IsValid() {
+			return fmt.Sprintf("نوع پرونده %d نامعتبر است", t)
+		}
+		if amount < 0 {
+			return "سقف تایید خودکار " + t.String() + " نمی‌تواند منفی باشد"
+		}
+	}
+	if p.SampleRate < 0 || p.SampleRate > 100 {
+		return "درصد نمونه‌گیری باید بین ۰ و ۱۰۰ باشد"
+	}
+	return ""
+}
+
+// LoadPolicy reads the policy of a tenant; missing settings leave auto-approval off
+func LoadPolicy(ctx context.Context, settings repository.TenantSettingRepository, tenantID uint) (*Policy, error) {
+	values, err := settings.FindValues(ctx, tenantID, SettingThreshold, SettingThresholds, SettingSampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{Thresholds: map[entity.ClaimType]int64{}}
+	if v := values[SettingThreshold]; v != "" {
+		if p.Threshold, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("autoapprove: setting %s: %w", SettingThreshold, err)
+		}
+	}
+	if v := values[SettingThresholds]; v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Thresholds); err != nil {
+			return nil, fmt.Errorf("autoapprove: setting %s: %w", SettingThresholds, err)
+		}
+	}
+	if v := values[SettingSampleRate]; v != "" {
+		if p.SampleRate, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("autoapprove: setting %s: %w", SettingSampleRate, err)
+		}
+	}
+	return p, nil
+}
+
+// SavePolicy stores p as the tenant's settings
+func SavePolicy(ctx context.Context, settings repository.TenantSettingRepository, tenantID uint, p *Policy) error {
+	thresholds := p.Thresholds
+	if thresholds == nil {
+		thresholds = map[entity.ClaimType]int64{}
+	}
+	encoded, err := json.Marshal(thresholds)
+	if err != nil {
+		return err
+	}
+	return settings.Upsert(ctx, tenantID, []entity.TenantSetting{
+		{SettingKey: SettingThreshold, SettingValue: strconv.FormatInt(p.Threshold, 10), SettingType: entity.SettingTypeInt},
+		{SettingKey: SettingThresholds, SettingValue: string(encoded), SettingType: entity.SettingTypeJSON},
+		{SettingKey: SettingSampleRate, SettingValue: strconv.FormatFloat(p.SampleRate, 'f', -1, 64), SettingType: entity.SettingTypeFloat},
+	})
+}
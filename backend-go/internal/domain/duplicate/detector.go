@@ -0,0 +1,346 @@
+// Package duplicate flags claims and claim items that repeat an earlier
+// service to the same insured person: the same service or drug on (nearly)
+// the same date, the same claim registered twice, or hospital stays that
+// overlap across centers. Findings are advisory; examiners decide whether to
+// deduct them under the suggested reason code (R004).
+package duplicate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+)
+
+// Kind classifies a finding
+type Kind string
+
+const (
+	KindItem        Kind = "duplicate_item"   // same service/drug within the look-back window
+	KindClaim       Kind = "duplicate_claim"  // same claim registered twice
+	KindOverlapStay Kind = "overlapping_stay" // hospitalization overlapping another stay
+)
+
+// Config holds the look-back windows. A window of zero matches the same
+// calendar day only.
+type Config struct {
+	DefaultWindow time.Duration
+	Windows       map[entity.ClaimType]time.Duration
+
+	// ReasonCode is suggested for deductions of duplicates
+	ReasonCode string
+
+	// Location decides where calendar days start
+	Location *time.Location
+}
+
+// DefaultConfig returns the default look-back windows
+func DefaultConfig() Config {
+	loc, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		loc = time.FixedZone("IRST", 3*3600+1800)
+	}
+	day := 24 * time.Hour
+	return Config{
+		DefaultWindow: 0,
+		Windows: map[entity.ClaimType]time.Duration{
+			entity.ClaimTypeDrug:             7 * day,
+			entity.ClaimTypeLabTest:          3 * day,
+			entity.ClaimTypeImaging:          7 * day,
+			entity.ClaimTypeMedicalEquipment: 30 * day,
+		},
+		ReasonCode: "R004",
+		Location:   loc,
+	}
+}
+
+// Window returns the look-back window of claimType
+func (c Config) Window(claimType entity.ClaimType) time.Duration {
+	if w, ok := c.Windows[claimType]; ok {
+		return w
+	}
+	return c.DefaultWindow
+}
+
+// Finding is one suspected duplicate. ItemID is 0 for claim level findings.
+type Finding struct {
+	Kind    Kind   `json:"kind"`
+	ItemID  uint   `json:"item_id,omitempty"`
+	Message string `json:"message"`
+
+	MatchClaimID      uint      `json:"match_claim_id"`
+	MatchTrackingCode string    `json:"match_tracking_code"`
+	MatchItemID       uint      `json:"match_item_id,omitempty"`
+	MatchDate         time.Time `json:"match_date"`
+
+	SuggestedDeduction int64 `json:"suggested_deduction"`
+}
+
+// Report lists the findings of a claim and the deduction they suggest
+type Report struct {
+	Findings           []Finding          `json:"findings"`
+	SuggestedDeduction int64              `json:"suggested_deduction"`
+	ReasonCode         *entity.ReasonCode `json:"reason_code,omitempty"`
+}
+
+// Detector loads a member's earlier claims and compares them with a claim
+type Detector struct {
+	claims  repository.ClaimRepository
+	reasons repository.ReasonCodeRepository
+	config  Config
+}
+
+// NewDetector creates a detector
+func NewDetector(claims repository.ClaimRepository, reasons repository.ReasonCodeRepository, config ...Config) *Detector {
+	cfg := DefaultConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.Location == nil {
+			cfg.Location = DefaultConfig().Location
+		}
+	}
+	return &Detector{claims: claims, reasons: reasons, config: cfg}
+}
+
+// Check returns the findings of claim (loaded with its Items) against the
+// member's other claims. The claim need not be saved yet.
+func (d *Detector) Check(ctx context.Context, claim *entity.Claim) (*Report, error) {
+	from, to := d.span(claim)
+	candidates, err := d.claims.FindByMemberBetween(ctx, claim.TenantID, claim.PolicyMemberID, from, to, claim.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := Detect(d.config, claim, candidates)
+	if len(report.Findings) > 0 && d.config.ReasonCode != "" {
+		rc, err := d.reasons.FindByCode(ctx, claim.TenantID, d.config.ReasonCode)
+		if err == nil && rc.IsActive {
+			report.ReasonCode = rc
+		}
+	}
+	return report, nil
+}
+
+// span is the service period of claim widened by its look-back window
+func (d *Detector) span(claim *entity.Claim) (time.Time, time.Time) {
+	from, to := claim.ServiceDate, claim.ServiceDate
+	widen := func(t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if from.IsZero() || t.Before(from) {
+			from = t
+		}
+		if t.After(to) {
+			to = t
+		}
+	}
+	widen(claim.AdmissionDate)
+	if claim.DischargeDate != nil {
+		widen(*claim.DischargeDate)
+	}
+	for _, item := range claim.Items {
+		if item.ServiceDate != nil {
+			widen(*item.ServiceDate)
+		}
+	}
+	w := d.config.Window(claim.ClaimType) + 24*time.Hour
+	return from.Add(-w), to.Add(w)
+}
+
+// Detect compares claim with candidates, the member's other claims loaded
+// with their Items. Each item and the claim itself are reported at most once
+// per kind, against the closest match. The suggested deduction never exceeds
+// the claim's payable amount.
+func Detect(cfg Config, claim *entity.Claim, candidates []entity.Claim) *Report {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	window := daysOf(cfg.Window(claim.ClaimType))
+	report := &Report{Findings: []Finding{}}
+
+	// Same claim registered twice; claims with items are compared per item below
+	for i := range candidates {
+		other := &candidates[i]
+		if len(claim.Items) > 0 {
+			break
+		}
+		if other.ClaimType != claim.ClaimType || other.CenterID != claim.CenterID || other.RequestAmount != claim.RequestAmount {
+			continue
+		}
+		if dayDiff(cfg.Location, claim.ServiceDate, other.ServiceDate) > window {
+			continue
+		}
+		report.add(Finding{
+			Kind:               KindClaim,
+			Message:            fmt.Sprintf("پرونده با مبلغ و تاریخ مشابه قبلا با کد رهگیری %s ثبت شده است", other.TrackingCode),
+			MatchClaimID:       other.ID,
+			MatchTrackingCode:  other.TrackingCode,
+			MatchDate:          other.ServiceDate,
+			SuggestedDeduction: claim.PayableAmount(),
+		})
+		break
+	}
+
+	// Same service or drug within the window, in another claim or earlier in this one
+	for i := range claim.Items {
+		item := &claim.Items[i]
+		key := itemKey(item)
+		if key == "" {
+			continue
+		}
+		date := itemDate(claim, item)
+
+		var best *Finding
+		consider := func(other *entity.Claim, o *entity.ClaimItem) {
+			if itemKey(o) != key {
+				return
+			}
+			otherDate := itemDate(other, o)
+			diff := dayDiff(cfg.Location, date, otherDate)
+			if diff > window {
+				return
+			}
+			if best != nil && diff >= dayDiff(cfg.Location, date, best.MatchDate) {
+				return
+			}
+			best = &Finding{
+				Kind:               KindItem,
+				ItemID:             item.ID,
+				MatchClaimID:       other.ID,
+				MatchTrackingCode:  other.TrackingCode,
+				MatchItemID:        o.ID,
+				MatchDate:          otherDate,
+				SuggestedDeduction: item.PayableAmount(),
+			}
+			if other == claim {
+				best.Message = fmt.Sprintf("خدمت %s در همین پرونده تکرار شده است", key)
+			} else {
+				best.Message = fmt.Sprintf("خدمت %s با فاصله %d روز در پرونده %s نیز ثبت شده است", key, diff, other.TrackingCode)
+			}
+		}
+		for j := 0; j < i; j++ {
+			consider(claim, &claim.Items[j])
+		}
+		for c := range candidates {
+			for j := range candidates[c].Items {
+				consider(&candidates[c], &candidates[c].Items[j])
+			}
+		}
+		if best != nil {
+			report.add(*best)
+		}
+	}
+
+	// Hospital stays overlapping another stay, at any center
+	if claim.ClaimType == entity.ClaimTypeHospitalization && !claim.AdmissionDate.IsZero() {
+		start, end := stay(cfg.Location, claim)
+		for i := range candidates {
+			other := &candidates[i]
+			if other.ClaimType != entity.ClaimTypeHospitalization || other.AdmissionDate.IsZero() {
+				continue
+			}
+			otherStart, otherEnd := stay(cfg.Location, other)
+			overlap := overlapDays(start, end, otherStart, otherEnd)
+			if overlap <= 0 {
+				continue
+			}
+			nights := int64(end.Sub(start).Hours()/24 + 0.5)
+			if nights < 1 {
+				nights = 1
+			}
+			report.add(Finding{
+				Kind:               KindOverlapStay,
+				Message:            fmt.Sprintf("بستری با پرونده %s به مدت %d روز همپوشانی دارد", other.TrackingCode, overlap),
+				MatchClaimID:       other.ID,
+				MatchTrackingCode:  other.TrackingCode,
+				MatchDate:          other.AdmissionDate,
+				SuggestedDeduction: claim.PayableAmount() * overlap / nights,
+			})
+		}
+	}
+
+	if payable := claim.PayableAmount(); report.SuggestedDeduction > payable {
+		report.SuggestedDeduction = payable
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].ItemID < report.Findings[j].ItemID
+	})
+	return report
+}
+
+func (r *Report) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+	r.SuggestedDeduction += f.SuggestedDeduction
+}
+
+// itemKey identifies the service or drug of an item
+func itemKey(item *entity.ClaimItem) string {
+	if item.ServiceCode != "" {
+		return item.ServiceCode
+	}
+	if item.ItemID != nil {
+		return fmt.Sprintf("#%d", *item.ItemID)
+	}
+	return ""
+}
+
+func itemDate(claim *entity.Claim, item *entity.ClaimItem) time.Time {
+	if item.ServiceDate != nil && !item.ServiceDate.IsZero() {
+		return *item.ServiceDate
+	}
+	return claim.ServiceDate
+}
+
+// stay returns the admission and discharge days; open stays end on admission day
+func stay(loc *time.Location, claim *entity.Claim) (time.Time, time.Time) {
+	start := dayOf(loc, claim.AdmissionDate)
+	end := start
+	if claim.DischargeDate != nil && claim.DischargeDate.After(claim.AdmissionDate) {
+		end = dayOf(loc, *claim.DischargeDate)
+	}
+	return start, end
+}
+
+// overlapDays counts the nights two stays share; a discharge and an admission
+// on the same day (a transfer) do not overlap
+func overlapDays(aStart, aEnd, bStart, bEnd time.Time) int64 {
+	if aEnd.Equal(aStart) {
+		aEnd = aEnd.Add(24 * time.Hour)
+	}
+	if bEnd.Equal(bStart) {
+		bEnd = bEnd.Add(24 * time.Hour)
+	}
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return int64(end.Sub(start).Hours()/24 + 0.5)
+}
+
+func dayOf(loc *time.Location, t time.Time) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayDiff is the absolute number of calendar days between a and b
+func dayDiff(loc *time.Location, a, b time.Time) int64 {
+	d := dayOf(loc, a).Sub(dayOf(loc, b))
+	if d < 0 {
+		d = -d
+	}
+	return int64(d.Hours() / 24)
+}
+
+func daysOf(d time.Duration) int64 {
+	return int64(d / (24 * time.Hour))
+}
@@ -26,3 +26,8 @@ type TenantModel struct {
 	AuditModel
 	TenantID uint `gorm:"index" json:"tenant_id"` // Insurer ID for multi-tenancy
 }
+
+// GetTenantID returns the owning tenant
+func (m TenantModel) GetTenantID() uint {
+	return m.TenantID
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"github.com/bank-melli/tpa/internal/pkg/persian"
+	"gorm.io/gorm"
+)
+
+// Center - مرکز درمانی طرف قرارداد بیمه‌گر
+type Center struct {
+	TenantModel
+
+	// اطلاعات پایه
+	Title  string     `gorm:"size:255;not null" json:"title"`
+	SiamID string     `gorm:"size:50;not null" json:"siam_id"` // شناسه سیام
+	Code   string     `gorm:"size:50" json:"code"`
+	Type   CenterType `gorm:"not null" json:"type"`
+	Level  int        `json:"level"` // درجه مرکز
+
+	// آدرس و تماس
+	ProvinceID *uint  `json:"province_id"`
+	CityID     *uint  `json:"city_id"`
+	Address    string `gorm:"type:text" json:"address"`
+	PostalCode string `gorm:"size:10" json:"postal_code"`
+	Phone      string `gorm:"size:20" json:"phone"`
+	Fax        string `gorm:"size:20" json:"fax"`
+	Email      string `gorm:"size:100" json:"email"`
+	Website    string `gorm:"size:200" json:"website"`
+
+	// مسئولین
+	OwnerName      string `gorm:"size:255" json:"owner_name"`
+	ManagerName    string `gorm:"size:255" json:"manager_name"`
+	ManagerPhone   string `gorm:"size:20" json:"manager_phone"`
+	DependencyType uint8  `json:"dependency_type"` // دولتی، خصوصی، خیریه ...
+
+	// اطلاعات مالی
+	PaymentID     string `gorm:"size:50" json:"payment_id"`
+	AccountNumber string `gorm:"size:50" json:"account_number"`
+	ShebaNumber   string `gorm:"size:26" json:"sheba_number"`
+	EconomicCode  string `gorm:"size:14" json:"economic_code"`
+	NationalID    string `gorm:"size:11" json:"national_id"`
+
+	IsActive bool `gorm:"default:true" json:"is_active"`
+}
+
+// TableName specifies the table name
+func (Center) TableName() string {
+	return "centers"
+}
+
+// BeforeSave stores names in standard Persian spelling
+func (c *Center) BeforeSave(tx *gorm.DB) error {
+	c.Title = persian.Canonical(c.Title)
+	c.OwnerName = persian.Canonical(c.OwnerName)
+	c.ManagerName = persian.Canonical(c.ManagerName)
+	c.Code = persian.Canonical(c.Code)
+	return nil
+}
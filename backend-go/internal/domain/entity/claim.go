@@ -0,0 +1,151 @@
+package entity
+
+import "time"
+
+// Claim - پرونده (سند) خسارت درمانی
+type Claim struct {
+	TenantModel
+
+	TrackingCode string `gorm:"size:50;not null;uniqueIndex:idx_claims_tenant_tracking_code,where:deleted_at IS NULL" json:"tracking_code"` // کد رهگیری
+	HID          string `gorm:"size:50;index:idx_claims_hid,where:deleted_at IS NULL" json:"hid"`                                           // شناسه سلامت (سپاس)
+	MRN          string `gorm:"size:50" json:"mrn"`                                                                                         // شماره پرونده پزشکی
+
+	PolicyMemberID uint  `gorm:"not null;index:idx_claims_policy_member,where:deleted_at IS NULL" json:"policy_member_id"` // بیمه‌شده (کارمند یا فرد تحت تکفل)
+	PackageID      *uint `gorm:"index:idx_claims_package,where:deleted_at IS NULL" json:"package_id"`
+	CenterID       uint  `gorm:"not null;index:idx_claims_center,where:deleted_at IS NULL" json:"center_id"`
+
+	ClaimType       ClaimType   `gorm:"not null" json:"claim_type"`
+	Status          ClaimStatus `gorm:"not null;index:idx_claims_status,where:deleted_at IS NULL" json:"status"`
+	StatusChangedAt time.Time   `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_claims_status_changed,where:deleted_at IS NULL" json:"status_changed_at"` // ورود به وضعیت فعلی
+	HandlerUserID   *uint       `gorm:"index:idx_claims_handler,where:deleted_at IS NULL" json:"handler_user_id"`                                             // ارزیاب
+	AssignedAt      *time.Time  `json:"assigned_at"`                                                                                                          // زمان تخصیص به ارزیاب
+
+	// پذیرش
+	AdmissionType AdmissionType `json:"admission_type"`
+	AdmissionDate time.Time     `json:"admission_date"`
+	DischargeDate *time.Time    `json:"discharge_date"`
+	ServiceDate   time.Time     `gorm:"index:idx_claims_service_date,where:deleted_at IS NULL" json:"service_date"`
+
+	// مبالغ (ریال)
+	RequestAmount   int64  `json:"request_amount"`
+	BasicInsShare   int64  `json:"basic_ins_share"` // سهم بیمه پایه
+	ApprovedAmount  int64  `json:"approved_amount"`
+	Deduction       int64  `json:"deduction"` // کسورات
+	DeductionReason string `gorm:"type:text" json:"deduction_reason"`
+	Notes           string `gorm:"type:text" json:"notes"`
+
+	// تایید خودکار پرونده‌های کم‌مبلغ؛ نمونه‌ای تصادفی از آن‌ها بعدا بازبینی می‌شود
+	AutoApproved bool               `gorm:"not null;default:false" json:"auto_approved"`
+	SampleReview SampleReviewStatus `gorm:"size:20;not null;default:'';index:idx_claims_sample_review,where:deleted_at IS NULL" json:"sample_review,omitempty"`
+	ReviewedBy   *uint              `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewNotes  string             `gorm:"type:text" json:"review_notes,omitempty"`
+
+	// Relations
+	PolicyMember *Employee        `gorm:"foreignKey:PolicyMemberID" json:"policy_member,omitempty"`
+	Center       *Center          `gorm:"foreignKey:CenterID" json:"center,omitempty"`
+	Package      *Package         `gorm:"foreignKey:PackageID" json:"package,omitempty"`
+	Items        []ClaimItem      `gorm:"foreignKey:ClaimID" json:"items,omitempty"`
+	Diagnoses    []ClaimDiagnosis `gorm:"foreignKey:ClaimID" json:"diagnoses,omitempty"`
+
+	DeductionReasons []ClaimDeductionReason `gorm:"foreignKey:ClaimID" json:"deduction_reasons,omitempty"`
+}
+
+// SampleReviewStatus - وضعیت بازبینی نمونه پرونده تایید خودکار؛ خالی یعنی در نمونه نیست
+type SampleReviewStatus string
+
+const (
+	SampleReviewPending SampleReviewStatus = "pending"
+	SampleReviewDone    SampleReviewStatus = "reviewed"
+)
+
+// TableName specifies the table name
+func (Claim) TableName() string {
+	return "claims"
+}
+
+// IsEditable reports whether the center may still change the claim
+func (c *Claim) IsEditable() bool {
+	return c.Status == ClaimStatusWaitRegister || c.Status == ClaimStatusReturned
+}
+
+// PayableAmount is the requested amount not covered by basic insurance
+func (c *Claim) PayableAmount() int64 {
+	return c.RequestAmount - c.BasicInsShare
+}
+
+// ClaimItem - قلم خدمت یا دارو در پرونده خسارت
+type ClaimItem struct {
+	BaseModel
+	TenantID uint `gorm:"not null;index:idx_claim_items_tenant" json:"tenant_id"`
+	ClaimID  uint `gorm:"not null;index:idx_claim_items_claim,where:deleted_at IS NULL" json:"claim_id"`
+
+	ItemID             *uint `gorm:"index:idx_claim_item_item" json:"item_id"` // خدمت/دارو (کدینگ یکپارچه)
+	PrescriptionItemID *uint `gorm:"index:idx_claim_item_prescription_item" json:"prescription_item_id"`
+	InstructionID      *uint `gorm:"index:idx_claim_item_instruction" json:"instruction_id"`
+	BodySiteID         *uint `gorm:"index:idx_claim_item_body_site" json:"body_site_id"`
+
+	ServiceCode string     `gorm:"size:50" json:"service_code"`
+	Title       string     `gorm:"size:255" json:"title"`
+	ServiceDate *time.Time `json:"service_date"`
+	Dosage      string     `gorm:"size:100" json:"dosage"`
+	Frequency   string     `gorm:"size:100" json:"frequency"`
+	Duration    string     `gorm:"size:100" json:"duration"`
+
+	// مبالغ (ریال)
+	Quantity       int   `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice      int64 `json:"unit_price"`
+	RequestAmount  int64 `json:"request_amount"`
+	BasicInsShare  int64 `json:"basic_ins_share"`
+	ConfirmedPrice int64 `json:"confirmed_price"` // مبلغ تایید شده ارزیاب
+	Deduction      int64 `json:"deduction"`
+
+	Notes         string `gorm:"type:text" json:"notes"`
+	ExaminerNotes string `gorm:"type:text" json:"examiner_notes"` // توضیحات ارزیاب
+
+	DeductionReasons []ClaimDeductionReason `gorm:"foreignKey:ClaimItemID" json:"deduction_reasons,omitempty"`
+}
+
+// PayableAmount is the requested amount of the item not covered by basic insurance
+func (i *ClaimItem) PayableAmount() int64 {
+	return i.RequestAmount - i.BasicInsShare
+}
+
+// TableName specifies the table name
+func (ClaimItem) TableName() string {
+	return "claim_items"
+}
+
+// ClaimDiagnosis - تشخیص (ICD-10) ثبت شده برای پرونده
+type ClaimDiagnosis struct {
+	BaseModel
+	TenantID uint `gorm:"not null;index:idx_claim_diagnoses_tenant" json:"tenant_id"`
+	ClaimID  uint `gorm:"not null;index:idx_claim_diagnoses_claim,where:deleted_at IS NULL" json:"claim_id"`
+
+	ICD10Code string `gorm:"size:20;not null" json:"icd10_code"`
+	Title     string `gorm:"size:255" json:"title"`
+	IsPrimary bool   `gorm:"default:false" json:"is_primary"` // تشخیص اصلی
+}
+
+// TableName specifies the table name
+func (ClaimDiagnosis) TableName() string {
+	return "claim_diagnoses"
+}
+
+// ClaimDeductionReason - کد علت کسر ثبت شده توسط ارزیاب برای پرونده یا یک قلم
+type ClaimDeductionReason struct {
+	ID           uint  `gorm:"primarykey" json:"id"`
+	TenantID     uint  `gorm:"not null;index:idx_claim_deduction_reasons_tenant" json:"tenant_id"`
+	ClaimID      uint  `gorm:"not null;index:idx_claim_deduction_reasons_claim" json:"claim_id"`
+	ClaimItemID  *uint `gorm:"index:idx_claim_deduction_reasons_item" json:"claim_item_id"` // خالی یعنی کسر در سطح پرونده
+	ReasonCodeID uint  `gorm:"not null" json:"reason_code_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	ReasonCode *ReasonCode `gorm:"foreignKey:ReasonCodeID" json:"reason_code,omitempty"`
+}
+
+// TableName specifies the table name
+func (ClaimDeductionReason) TableName() string {
+	return "claim_deduction_reasons"
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// ClaimStatusHistory - سابقه تغییر وضعیت پرونده خسارت (فقط درج)
+type ClaimStatusHistory struct {
+	ID       uint `gorm:"primarykey" json:"id"`
+	TenantID uint `gorm:"not null;index:idx_claim_status_history_tenant" json:"tenant_id"`
+	ClaimID  uint `gorm:"not null;index:idx_claim_status_history_claim,priority:1" json:"claim_id"`
+
+	Action     string      `gorm:"size:30;not null" json:"action"` // submit, examine, approve, ...
+	FromStatus ClaimStatus `gorm:"not null" json:"from_status"`
+	ToStatus   ClaimStatus `gorm:"not null" json:"to_status"`
+	ActorID    uint        `gorm:"not null;default:0" json:"actor_id"` // کاربر انجام‌دهنده؛ صفر یعنی سیستم
+	Reason     string      `gorm:"type:text" json:"reason"`
+
+	CreatedAt time.Time `gorm:"not null;index:idx_claim_status_history_claim,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ClaimStatusHistory) TableName() string {
+	return "claim_status_history"
+}
+
+// GetTenantID returns the owning tenant
+func (h *ClaimStatusHistory) GetTenantID() uint {
+	return h.TenantID
+}
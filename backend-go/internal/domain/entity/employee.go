@@ -1,6 +1,11 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/bank-melli/tpa/internal/pkg/persian"
+	"gorm.io/gorm"
+)
 
 // Employee - کارمند یا فرد تحت تکفل (Simplified structure)
 type Employee struct {
@@ -50,6 +55,20 @@ func (Employee) TableName() string {
 	return "employees"
 }
 
+// BeforeSave stores names and codes in standard Persian spelling, so they
+// match whichever keyboard they are searched with
+func (e *Employee) BeforeSave(tx *gorm.DB) error {
+	e.FirstName = persian.Canonical(e.FirstName)
+	e.LastName = persian.Canonical(e.LastName)
+	if e.FatherName != nil {
+		name := persian.Canonical(*e.FatherName)
+		e.FatherName = &name
+	}
+	e.PersonnelCode = persian.Canonical(e.PersonnelCode)
+	e.NationalCode = persian.Canonical(e.NationalCode)
+	return nil
+}
+
 // IsMainEmployee checks if this is a main employee (not a family member)
 func (e *Employee) IsMainEmployee() bool {
 	return e.ParentID == nil
@@ -64,3 +83,8 @@ func (e *Employee) IsFamilyMember() bool {
 func (e *Employee) GetFullName() string {
 	return e.FirstName + " " + e.LastName
 }
+
+// GetTenantID returns the owning tenant
+func (e *Employee) GetTenantID() uint {
+	return e.TenantID
+}
@@ -58,21 +58,21 @@ func (EmployeeImportTemp) TableName() string {
 
 // EmployeeImportHistory - تاریخچه import کارمندان
 type EmployeeImportHistory struct {
-	ID       uint `gorm:"primaryKey"`
-	TenantID uint `gorm:"not null"`
-
-	BatchID        string    `gorm:"size:100;uniqueIndex;not null"`
-	ImportDate     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	Source         string    `gorm:"size:100"` // hr_server, csv_file, manual
-	TotalRecords   int       `gorm:"default:0"`
-	NewRecords     int       `gorm:"default:0"`
-	UpdatedRecords int       `gorm:"default:0"`
-	FailedRecords  int       `gorm:"default:0"`
-	Status         string    `gorm:"size:50;default:pending"` // pending, processing, completed, failed
-	Notes          *string   `gorm:"type:text"`
-	ImportedByUserID *uint
+	ID       uint `gorm:"primaryKey" json:"id"`
+	TenantID uint `gorm:"not null" json:"tenant_id"`
 
-	CreatedAt time.Time
+	BatchID          string    `gorm:"size:100;uniqueIndex;not null" json:"batch_id"`
+	ImportDate       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"import_date"`
+	Source           string    `gorm:"size:100" json:"source"` // hr_server, csv_file, manual
+	TotalRecords     int       `gorm:"default:0" json:"total_records"`
+	NewRecords       int       `gorm:"default:0" json:"new_records"`
+	UpdatedRecords   int       `gorm:"default:0" json:"updated_records"`
+	FailedRecords    int       `gorm:"default:0" json:"failed_records"`
+	Status           string    `gorm:"size:50;default:pending" json:"status"` // pending, processing, completed, failed
+	Notes            *string   `gorm:"type:text" json:"notes"`
+	ImportedByUserID *uint     `json:"imported_by_user_id"`
+
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // TableName specifies the table name
@@ -87,3 +87,8 @@ const (
 	ImportStatusCompleted  = "completed"
 	ImportStatusFailed     = "failed"
 )
+
+// GetTenantID returns the owning tenant
+func (h *EmployeeImportHistory) GetTenantID() uint {
+	return h.TenantID
+}
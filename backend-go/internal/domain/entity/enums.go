@@ -86,6 +86,17 @@ func (c ClaimType) String() string {
 	return "نامشخص"
 }
 
+// IsValid reports whether c is a known claim type
+func (c ClaimType) IsValid() bool {
+	switch c {
+	case ClaimTypeDrug, ClaimTypeHospitalization, ClaimTypeDental, ClaimTypeDoctorVisit,
+		ClaimTypeLabTest, ClaimTypeImaging, ClaimTypePhysiotherapy, ClaimTypeOutpatientSurgery,
+		ClaimTypeEmergency, ClaimTypeMedicalEquipment, ClaimTypeInjection, ClaimTypeClinic:
+		return true
+	}
+	return false
+}
+
 // ClaimStatus - وضعیت ادعا
 type ClaimStatus uint8
 
@@ -214,6 +225,7 @@ const (
 	PermClaimExamine PermissionName = "claim.examine"
 	PermClaimApprove PermissionName = "claim.approve"
 	PermClaimReject  PermissionName = "claim.reject"
+	PermClaimAssign  PermissionName = "claim.assign"
 
 	// Packages
 	PermPackageCreate  PermissionName = "package.create"
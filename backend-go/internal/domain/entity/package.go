@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Package - بسته اسناد ارسالی مرکز درمانی
+type Package struct {
+	TenantModel
+
+	CenterID   uint   `gorm:"not null;index" json:"center_id"`
+	WorkUnitID *uint  `json:"work_unit_id"`
+	Title      string `gorm:"size:255;not null" json:"title"`
+
+	// نامه ارسال
+	LetterNumber      string     `gorm:"size:50" json:"letter_number"`
+	LetterDate        *time.Time `json:"letter_date"`
+	ReceiveLetterDate *time.Time `json:"receive_letter_date"`
+	LetterImageURL    string     `gorm:"size:500" json:"letter_image_url"`
+
+	Status PackageStatus `gorm:"not null;default:1" json:"status"`
+
+	// Relations
+	Center *Center `gorm:"foreignKey:CenterID" json:"center,omitempty"`
+}
+
+// TableName specifies the table name
+func (Package) TableName() string {
+	return "packages"
+}
@@ -0,0 +1,21 @@
+package entity
+
+// ReasonCode - کد علت کسورات (به ازای هر بیمه‌گر)
+type ReasonCode struct {
+	BaseModel
+	TenantID uint `gorm:"not null;uniqueIndex:idx_reason_codes_tenant_code,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+
+	Code     string `gorm:"size:20;not null;uniqueIndex:idx_reason_codes_tenant_code,priority:2" json:"code"`
+	TitleFa  string `gorm:"size:255;not null" json:"title_fa"`
+	IsActive bool   `gorm:"default:true" json:"is_active"`
+}
+
+// TableName specifies the table name
+func (ReasonCode) TableName() string {
+	return "reason_codes"
+}
+
+// GetTenantID returns the owning tenant
+func (r *ReasonCode) GetTenantID() uint {
+	return r.TenantID
+}
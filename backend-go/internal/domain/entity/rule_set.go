@@ -0,0 +1,152 @@
+package entity
+
+import "time"
+
+// RuleSetStatus - وضعیت نسخه قوانین
+type RuleSetStatus string
+
+const (
+	RuleSetDraft   RuleSetStatus = "draft"   // پیش‌نویس
+	RuleSetActive  RuleSetStatus = "active"  // فعال (از تاریخ اجرا)
+	RuleSetRetired RuleSetStatus = "retired" // کنار گذاشته شده با بازگشت به نسخه قبلی
+)
+
+// RuleSet - نسخه‌ای از قوانین ارزیابی خودکار پرونده‌ها (GRL) برای یک بیمه‌گر
+type RuleSet struct {
+	AuditModel
+	TenantID uint `gorm:"not null;uniqueIndex:idx_rules_tenant_version,priority:1,where:deleted_at IS NULL;index:idx_rules_tenant_status,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+
+	Version     uint          `gorm:"not null;uniqueIndex:idx_rules_tenant_version,priority:2" json:"version"`
+	Name        string        `gorm:"size:255;not null" json:"name"`
+	Description string        `gorm:"type:text" json:"description"`
+	Status      RuleSetStatus `gorm:"size:20;not null;default:draft;index:idx_rules_tenant_status,priority:2" json:"status"`
+
+	Source   string `gorm:"type:text;not null" json:"source"` // متن قوانین به زبان GRL
+	Checksum string `gorm:"size:64;not null" json:"checksum"` // SHA-256 متن قوانین
+
+	EffectiveFrom *time.Time `json:"effective_from"` // از این زمان اجرا می‌شود
+	ActivatedAt   *time.Time `json:"activated_at"`
+	ActivatedBy   *uint      `json:"activated_by"`
+}
+
+// TableName specifies the table name
+func (RuleSet) TableName() string {
+	return "rules"
+}
+
+// GetTenantID returns the owning tenant
+func (r *RuleSet) GetTenantID() uint {
+	return r.TenantID
+}
+
+// InEffect reports whether the rule set applies at t
+func (r *RuleSet) InEffect(t time.Time) bool {
+	return r.Status == RuleSetActive && (r.EffectiveFrom == nil || !r.EffectiveFrom.After(t))
+}
+
+// RuleTest - آزمون نسخه قوانین: پرونده نمونه و نتیجه مورد انتظار
+type RuleTest struct {
+	BaseModel
+	TenantID  uint `gorm:"not null;index:idx_rule_tests_tenant" json:"tenant_id"`
+	RuleSetID uint `gorm:"not null;index:idx_rule_tests_rule_set,where:deleted_at IS NULL" json:"rule_set_id"`
+
+	Name     string              `gorm:"size:255;not null" json:"name"`
+	Claim    RuleTestClaim       `gorm:"type:jsonb;serializer:json;not null" json:"claim"`
+	Expected RuleTestExpectation `gorm:"type:jsonb;serializer:json;not null" json:"expected"`
+}
+
+// TableName specifies the table name
+func (RuleTest) TableName() string {
+	return "rule_tests"
+}
+
+// GetTenantID returns the owning tenant
+func (t *RuleTest) GetTenantID() uint {
+	return t.TenantID
+}
+
+// RuleTestClaim - پرونده نمونه آزمون؛ مبالغ به ریال
+type RuleTestClaim struct {
+	Stage         string        `json:"stage"` // submit (پیش‌فرض) یا examine
+	ClaimType     ClaimType     `json:"claim_type"`
+	AdmissionType AdmissionType `json:"admission_type"`
+	CenterID      uint          `json:"center_id"`
+
+	RequestAmount  int64 `json:"request_amount"`
+	BasicInsShare  int64 `json:"basic_ins_share"`
+	ApprovedAmount int64 `json:"approved_amount"` // مرحله ارزیابی، پرونده بدون قلم
+	StayDays       int64 `json:"stay_days"`
+
+	MemberAge      *int64 `json:"member_age"`
+	MemberGender   string `json:"member_gender"`
+	MemberRelation string `json:"member_relation"`
+	PriorApproved  int64  `json:"prior_approved"`
+
+	Diagnoses []string       `json:"diagnoses"`
+	Items     []RuleTestItem `json:"items"`
+}
+
+// RuleTestItem - قلم پرونده نمونه؛ مبلغ تایید شده در مرحله ارزیابی به کار می‌رود
+type RuleTestItem struct {
+	ServiceCode    string `json:"service_code"`
+	Quantity       int    `json:"quantity"`
+	RequestAmount  int64  `json:"request_amount"`
+	BasicInsShare  int64  `json:"basic_ins_share"`
+	ConfirmedPrice *int64 `json:"confirmed_price"`
+}
+
+// RuleTestExpectation - نتیجه مورد انتظار؛ فیلدهای خالی بررسی نمی‌شوند
+type RuleTestExpectation struct {
+	Deduction   *int64   `json:"deduction"`
+	FiredRules  []string `json:"fired_rules"` // دقیقا همین قوانین (بدون ترتیب)
+	ReasonCodes []string `json:"reason_codes"`
+	Flags       []string `json:"flags"`
+}
+
+// FiredRule - قانونی که در ارزیابی یک پرونده اجرا شده است
+type FiredRule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Salience    int      `json:"salience"`
+	Deduction   int64    `json:"deduction"`
+	Messages    []string `json:"messages,omitempty"`
+}
+
+// RuleDeduction - کسر پیشنهادی یک قانون؛ ClaimItemID خالی یعنی کسر در سطح پرونده
+type RuleDeduction struct {
+	Rule        string `json:"rule"`
+	ClaimItemID *uint  `json:"claim_item_id,omitempty"`
+	ServiceCode string `json:"service_code,omitempty"`
+	Amount      int64  `json:"amount"`
+	ReasonCode  string `json:"reason_code,omitempty"`
+	Message     string `json:"message"`
+}
+
+// RuleExecutionLog - سابقه اجرای قوانین روی پرونده (فقط درج)
+type RuleExecutionLog struct {
+	ID        uint `gorm:"primarykey" json:"id"`
+	TenantID  uint `gorm:"not null;index:idx_rule_execution_logs_tenant" json:"tenant_id"`
+	ClaimID   uint `gorm:"not null;index:idx_rule_execution_logs_claim,priority:1" json:"claim_id"`
+	RuleSetID uint `gorm:"not null;index:idx_rule_execution_logs_rule_set" json:"rule_set_id"`
+	Version   uint `gorm:"not null" json:"version"`
+
+	Stage      string          `gorm:"size:20;not null" json:"stage"` // submit, examine
+	FiredRules []FiredRule     `gorm:"type:jsonb;serializer:json" json:"fired_rules"`
+	Deduction  int64           `gorm:"not null;default:0" json:"deduction"` // جمع کسورات پیشنهادی قوانین
+	Deductions []RuleDeduction `gorm:"type:jsonb;serializer:json" json:"deductions"`
+	Flags      []string        `gorm:"type:jsonb;serializer:json" json:"flags"`
+	DurationMs int64           `gorm:"not null;default:0" json:"duration_ms"`
+	Error      string          `gorm:"type:text" json:"error,omitempty"`
+
+	CreatedAt time.Time `gorm:"not null;index:idx_rule_execution_logs_claim,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (RuleExecutionLog) TableName() string {
+	return "rule_execution_logs"
+}
+
+// GetTenantID returns the owning tenant
+func (l *RuleExecutionLog) GetTenantID() uint {
+	return l.TenantID
+}
@@ -0,0 +1,110 @@
+package entity
+
+import "time"
+
+// RuleSimulationStatus - وضعیت اجرای شبیه‌سازی
+type RuleSimulationStatus string
+
+const (
+	RuleSimulationPending   RuleSimulationStatus = "pending"
+	RuleSimulationRunning   RuleSimulationStatus = "running"
+	RuleSimulationCompleted RuleSimulationStatus = "completed"
+	RuleSimulationFailed    RuleSimulationStatus = "failed"
+)
+
+// RuleSimulation - شبیه‌سازی اثر مالی یک نسخه قوانین روی پرونده‌های گذشته،
+// در مقایسه با نسخه در حال اجرا؛ هیچ تغییری در پرونده‌ها ایجاد نمی‌کند
+type RuleSimulation struct {
+	BaseModel
+	TenantID   uint  `gorm:"not null;index:idx_rule_simulations_tenant" json:"tenant_id"`
+	RuleSetID  uint  `gorm:"not null;index:idx_rule_simulations_rule_set" json:"rule_set_id"` // نسخه پیشنهادی
+	BaselineID *uint `json:"baseline_id"`                                                     // نسخه در حال اجرا هنگام شروع؛ خالی یعنی بدون قوانین
+
+	// بازه تاریخ خدمت [ServiceFrom, ServiceTo)
+	ServiceFrom time.Time   `gorm:"not null" json:"service_from"`
+	ServiceTo   time.Time   `gorm:"not null" json:"service_to"`
+	ClaimTypes  []ClaimType `gorm:"type:jsonb;serializer:json" json:"claim_types"`
+
+	Status      RuleSimulationStatus  `gorm:"size:20;not null;default:pending" json:"status"`
+	TotalClaims int64                 `gorm:"not null;default:0" json:"total_claims"`
+	Processed   int64                 `gorm:"not null;default:0" json:"processed"`
+	Summary     RuleSimulationSummary `gorm:"type:jsonb;serializer:json" json:"summary"`
+	Error       string                `gorm:"type:text" json:"error,omitempty"`
+
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedBy  uint       `json:"created_by"`
+
+	RuleSet  *RuleSet `gorm:"foreignKey:RuleSetID" json:"rule_set,omitempty"`
+	Baseline *RuleSet `gorm:"foreignKey:BaselineID" json:"baseline,omitempty"`
+}
+
+// TableName specifies the table name
+func (RuleSimulation) TableName() string {
+	return "rule_simulations"
+}
+
+// GetTenantID returns the owning tenant
+func (s *RuleSimulation) GetTenantID() uint {
+	return s.TenantID
+}
+
+// RuleSimulationSummary - جمع اختلاف نسخه پیشنهادی با نسخه در حال اجرا
+type RuleSimulationSummary struct {
+	Claims  int64 `json:"claims"`
+	Changed int64 `json:"changed"` // پرونده‌هایی که کسورات یا قوانین اجرا شده آن‌ها تغییر کرده است
+	Failed  int64 `json:"failed"`
+
+	BaselineApproved   int64 `json:"baseline_approved"`
+	CandidateApproved  int64 `json:"candidate_approved"`
+	ApprovedDiff       int64 `json:"approved_diff"`
+	BaselineDeduction  int64 `json:"baseline_deduction"`
+	CandidateDeduction int64 `json:"candidate_deduction"`
+	DeductionDiff      int64 `json:"deduction_diff"`
+
+	Rules []RuleImpact `json:"rules"`
+}
+
+// RuleImpact - دفعات اجرا و کسورات یک قانون در هر دو نسخه
+type RuleImpact struct {
+	Rule               string `json:"rule"`
+	BaselineFired      int64  `json:"baseline_fired"`
+	CandidateFired     int64  `json:"candidate_fired"`
+	BaselineDeduction  int64  `json:"baseline_deduction"`
+	CandidateDeduction int64  `json:"candidate_deduction"`
+}
+
+// RuleSimulationClaim - نتیجه شبیه‌سازی یک پرونده
+type RuleSimulationClaim struct {
+	ID           uint `gorm:"primarykey" json:"id"`
+	TenantID     uint `gorm:"not null;index:idx_rule_simulation_claims_tenant" json:"tenant_id"`
+	SimulationID uint `gorm:"not null;index:idx_rule_simulation_claims_simulation" json:"simulation_id"`
+	ClaimID      uint `gorm:"not null" json:"claim_id"`
+
+	TrackingCode string    `gorm:"size:50" json:"tracking_code"`
+	ClaimType    ClaimType `json:"claim_type"`
+	Stage        string    `gorm:"size:20" json:"stage"`
+
+	Amount             int64 `json:"amount"`              // مبلغ مبنا پیش از قوانین
+	HistoricalApproved int64 `json:"historical_approved"` // مبلغ تایید شده ثبت شده در پرونده
+	BaselineDeduction  int64 `json:"baseline_deduction"`
+	CandidateDeduction int64 `json:"candidate_deduction"`
+	BaselineApproved   int64 `json:"baseline_approved"`
+	CandidateApproved  int64 `json:"candidate_approved"`
+	ApprovedDiff       int64 `json:"approved_diff"` // پیشنهادی منهای در حال اجرا
+
+	BaselineRules  []string `gorm:"type:jsonb;serializer:json" json:"baseline_rules"`
+	CandidateRules []string `gorm:"type:jsonb;serializer:json" json:"candidate_rules"`
+	Changed        bool     `gorm:"not null;default:false" json:"changed"`
+	Error          string   `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName specifies the table name
+func (RuleSimulationClaim) TableName() string {
+	return "rule_simulation_claims"
+}
+
+// GetTenantID returns the owning tenant
+func (c *RuleSimulationClaim) GetTenantID() uint {
+	return c.TenantID
+}
@@ -0,0 +1,76 @@
+package entity
+
+import "time"
+
+// SLAPolicy - حداکثر زمان ماندن پرونده در یک وضعیت، به ساعت کاری
+type SLAPolicy struct {
+	AuditModel
+	TenantID  uint        `gorm:"not null;uniqueIndex:idx_sla_policies_tenant_status_type,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+	Status    ClaimStatus `gorm:"not null;uniqueIndex:idx_sla_policies_tenant_status_type,priority:2" json:"status"`
+	ClaimType ClaimType   `gorm:"not null;default:0;uniqueIndex:idx_sla_policies_tenant_status_type,priority:3" json:"claim_type"` // صفر یعنی همه انواع
+
+	TargetHours int      `gorm:"not null" json:"target_hours"`
+	EscalateTo  RoleName `gorm:"size:50;not null;default:supervisor" json:"escalate_to"` // نقشی که تخطی به آن ارجاع می‌شود
+	IsActive    bool     `gorm:"not null;default:true" json:"is_active"`
+}
+
+// TableName specifies the table name
+func (SLAPolicy) TableName() string {
+	return "sla_policies"
+}
+
+// GetTenantID returns the owning tenant
+func (p *SLAPolicy) GetTenantID() uint {
+	return p.TenantID
+}
+
+// Holiday - روز تعطیل تقویم کاری بیمه‌گر
+type Holiday struct {
+	BaseModel
+	TenantID uint      `gorm:"not null;uniqueIndex:idx_holidays_tenant_date,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+	Date     time.Time `gorm:"type:date;not null;uniqueIndex:idx_holidays_tenant_date,priority:2" json:"date"`
+	Title    string    `gorm:"size:200" json:"title"`
+}
+
+// TableName specifies the table name
+func (Holiday) TableName() string {
+	return "holidays"
+}
+
+// GetTenantID returns the owning tenant
+func (h *Holiday) GetTenantID() uint {
+	return h.TenantID
+}
+
+// SLABreach - تخطی پرونده از مهلت یک وضعیت و ارجاع آن به سرپرست
+type SLABreach struct {
+	ID       uint `gorm:"primarykey" json:"id"`
+	TenantID uint `gorm:"not null;index:idx_sla_breaches_tenant,priority:1" json:"tenant_id"`
+	ClaimID  uint `gorm:"not null;uniqueIndex:idx_sla_breaches_claim_entry,priority:1" json:"claim_id"`
+	PolicyID uint `gorm:"not null" json:"policy_id"`
+
+	Status      ClaimStatus `gorm:"not null;uniqueIndex:idx_sla_breaches_claim_entry,priority:2" json:"status"`
+	ClaimType   ClaimType   `gorm:"not null" json:"claim_type"`
+	EnteredAt   time.Time   `gorm:"not null;uniqueIndex:idx_sla_breaches_claim_entry,priority:3" json:"entered_at"` // ورود پرونده به وضعیت
+	TargetHours int         `gorm:"not null" json:"target_hours"`
+	DueAt       time.Time   `gorm:"not null" json:"due_at"`
+	BreachedAt  time.Time   `gorm:"not null;index:idx_sla_breaches_tenant,priority:2" json:"breached_at"`
+
+	HandlerUserID  *uint      `json:"handler_user_id"` // ارزیاب پرونده هنگام تخطی
+	EscalatedTo    RoleName   `gorm:"size:50;not null" json:"escalated_to"`
+	AcknowledgedBy *uint      `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"` // خروج پرونده از وضعیت
+
+	Claim *Claim `gorm:"foreignKey:ClaimID" json:"claim,omitempty"`
+}
+
+// TableName specifies the table name
+func (SLABreach) TableName() string {
+	return "sla_breaches"
+}
+
+// GetTenantID returns the owning tenant
+func (b *SLABreach) GetTenantID() uint {
+	return b.TenantID
+}
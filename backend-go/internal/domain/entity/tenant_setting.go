@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Tenant setting types, stored in setting_type
+const (
+	SettingTypeString = "string"
+	SettingTypeInt    = "int"
+	SettingTypeFloat  = "float"
+	SettingTypeJSON   = "json"
+)
+
+// TenantSetting - تنظیمات کلید-مقدار هر بیمه‌گر (ایجاد شده توسط مهاجرت‌های بیمه‌گر)
+type TenantSetting struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	TenantID     uint      `gorm:"not null" json:"tenant_id"`
+	SettingKey   string    `gorm:"size:100;not null" json:"setting_key"`
+	SettingValue string    `gorm:"type:text" json:"setting_value"`
+	SettingType  string    `gorm:"size:50;default:string" json:"setting_type"`
+	Description  string    `gorm:"type:text" json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (TenantSetting) TableName() string {
+	return "tenant_settings"
+}
+
+// GetTenantID returns the owning tenant
+func (s *TenantSetting) GetTenantID() uint {
+	return s.TenantID
+}
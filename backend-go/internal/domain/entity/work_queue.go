@@ -0,0 +1,111 @@
+package entity
+
+import "time"
+
+// QueueMode - شیوه تخصیص پرونده‌های صف به ارزیاب‌ها
+type QueueMode string
+
+const (
+	QueueModePull QueueMode = "pull" // ارزیاب پرونده بعدی را خودش برمی‌دارد
+	QueueModePush QueueMode = "push" // پرونده هنگام ارسال به بهترین ارزیاب تخصیص می‌یابد
+)
+
+// IsValid reports whether m is a known mode
+func (m QueueMode) IsValid() bool {
+	return m == QueueModePull || m == QueueModePush
+}
+
+// WorkQueue - صف ارزیابی یک نوع پرونده؛ نوع‌های بدون ردیف از صف پیش‌فرض استفاده می‌کنند
+type WorkQueue struct {
+	AuditModel
+	TenantID  uint      `gorm:"not null;uniqueIndex:idx_work_queues_tenant_type,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+	ClaimType ClaimType `gorm:"not null;uniqueIndex:idx_work_queues_tenant_type,priority:2" json:"claim_type"`
+
+	Role     RoleName  `gorm:"size:50;not null" json:"role"` // نقش ارزیاب‌های صف
+	Mode     QueueMode `gorm:"size:10;not null;default:pull" json:"mode"`
+	IsActive bool      `gorm:"not null;default:true" json:"is_active"` // صف غیرفعال تخصیص خودکار ندارد
+}
+
+// TableName specifies the table name
+func (WorkQueue) TableName() string {
+	return "work_queues"
+}
+
+// GetTenantID returns the owning tenant
+func (q *WorkQueue) GetTenantID() uint {
+	return q.TenantID
+}
+
+// ExaminerProfile - مهارت، ظرفیت و حضور یک ارزیاب
+type ExaminerProfile struct {
+	BaseModel
+	TenantID uint `gorm:"not null;uniqueIndex:idx_examiner_profiles_tenant_user,priority:1,where:deleted_at IS NULL" json:"tenant_id"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_examiner_profiles_tenant_user,priority:2" json:"user_id"`
+
+	// Skills rates the examiner per claim type from 1 to 5; claim types left
+	// out are not assigned to the examiner. An empty map means every type of
+	// the examiner's queues at level 1.
+	Skills    map[ClaimType]int `gorm:"type:jsonb;serializer:json" json:"skills"`
+	Capacity  int               `gorm:"not null;default:20" json:"capacity"` // حداکثر پرونده باز
+	Available bool              `gorm:"not null;default:true" json:"available"`
+	AwayUntil *time.Time        `json:"away_until"` // مرخصی؛ تا این زمان پرونده نمی‌گیرد
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName specifies the table name
+func (ExaminerProfile) TableName() string {
+	return "examiner_profiles"
+}
+
+// GetTenantID returns the owning tenant
+func (p *ExaminerProfile) GetTenantID() uint {
+	return p.TenantID
+}
+
+// SkillFor returns the examiner's level for claimType, zero if not skilled
+func (p *ExaminerProfile) SkillFor(claimType ClaimType) int {
+	if len(p.Skills) == 0 {
+		return 1
+	}
+	return p.Skills[claimType]
+}
+
+// IsAvailable reports whether the examiner takes claims at t
+func (p *ExaminerProfile) IsAvailable(t time.Time) bool {
+	return p.Available && (p.AwayUntil == nil || !p.AwayUntil.After(t))
+}
+
+// AssignmentMode - نحوه تخصیص پرونده
+type AssignmentMode string
+
+const (
+	AssignmentPull     AssignmentMode = "pull"     // برداشتن توسط ارزیاب
+	AssignmentPush     AssignmentMode = "push"     // تخصیص خودکار
+	AssignmentReassign AssignmentMode = "reassign" // تخصیص مجدد توسط سرپرست
+)
+
+// ClaimAssignment - سابقه تخصیص پرونده به ارزیاب (فقط درج)
+type ClaimAssignment struct {
+	ID       uint `gorm:"primarykey" json:"id"`
+	TenantID uint `gorm:"not null;index:idx_claim_assignments_tenant" json:"tenant_id"`
+	ClaimID  uint `gorm:"not null;index:idx_claim_assignments_claim,priority:1" json:"claim_id"`
+
+	UserID         *uint          `json:"user_id"` // خالی یعنی بازگشت به صف
+	PreviousUserID *uint          `json:"previous_user_id"`
+	Mode           AssignmentMode `gorm:"size:20;not null" json:"mode"`
+	AssignedBy     uint           `gorm:"not null;default:0" json:"assigned_by"` // صفر یعنی سیستم
+	Reason         string         `gorm:"type:text" json:"reason"`
+
+	CreatedAt time.Time `gorm:"not null;index:idx_claim_assignments_claim,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ClaimAssignment) TableName() string {
+	return "claim_assignments"
+}
+
+// GetTenantID returns the owning tenant
+func (a *ClaimAssignment) GetTenantID() uint {
+	return a.TenantID
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// AttachmentFields whitelists attachment filters and sorts
+var AttachmentFields = FieldSet{
+	"owner":       {Type: FieldString, Operators: []string{OpEq}},
+	"owner_id":    {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"type_code":   {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"checksum":    {Type: FieldString, Operators: []string{OpEq}},
+	"scan_status": {Type: FieldString, Operators: []string{OpEq}},
+	"created_at":  {Type: FieldTime, Sortable: true},
+}
+
+// AttachmentRepository persists attachments and the attachment types of each tenant
+type AttachmentRepository interface {
+	Repository[entity.Attachment]
+
+	// FindByOwner returns the attachments of a claim or package, oldest first
+	FindByOwner(ctx context.Context, tenantID uint, owner entity.AttachmentOwner, ownerID uint) ([]entity.Attachment, error)
+
+	// FindTypes returns the tenant's types for owner that apply to claimType,
+	// those of all claim types (0) included, active or not
+	FindTypes(ctx context.Context, tenantID uint, owner entity.AttachmentOwner, claimType entity.ClaimType) ([]entity.AttachmentType, error)
+	// SaveType creates the type of its owner, claim type and code or replaces its settings
+	SaveType(ctx context.Context, t *entity.AttachmentType) error
+	DeleteType(ctx context.Context, tenantID uint, id uint) error
+}
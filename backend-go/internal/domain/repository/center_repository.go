@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// CenterFields whitelists center filters and sorts
+var CenterFields = FieldSet{
+	"id":          {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"title":       {Type: FieldString, Operators: []string{OpEq, OpLike}, Sortable: true},
+	"siam_id":     {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"code":        {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"type":        {Type: FieldInt, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"level":       {Type: FieldInt, Sortable: true},
+	"province_id": {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"city_id":     {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"is_active":   {Type: FieldBool},
+	"created_at":  {Type: FieldTime, Sortable: true},
+}
+
+// CenterRepository persists medical centers contracted by a tenant
+type CenterRepository interface {
+	Repository[entity.Center]
+
+	FindBySiamID(ctx context.Context, tenantID uint, siamID string) (*entity.Center, error)
+	GetStatsByType(ctx context.Context, tenantID uint) (map[entity.CenterType]int64, error)
+}
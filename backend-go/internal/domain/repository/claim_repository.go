@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ErrStatusChanged is returned when a claim left the expected status before a transition was saved
+var ErrStatusChanged = errors.New("repository: claim status changed concurrently")
+
+// ClaimFields whitelists claim filters and sorts
+var ClaimFields = FieldSet{
+	"id":               {Type: FieldInt, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"tracking_code":    {Type: FieldString, Operators: []string{OpEq, OpLike, OpIn}, Sortable: true},
+	"policy_member_id": {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"package_id":       {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"center_id":        {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"handler_user_id":  {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"claim_type":       {Type: FieldInt, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"status":           {Type: FieldInt, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"request_amount":   {Type: FieldInt, Sortable: true},
+	"approved_amount":  {Type: FieldInt, Sortable: true},
+	"deduction":        {Type: FieldInt, Sortable: true},
+	"auto_approved":    {Type: FieldBool},
+	"sample_review":    {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"reviewed_at":      {Type: FieldTime, Sortable: true},
+	"service_date":     {Type: FieldTime, Sortable: true},
+	"created_at":       {Type: FieldTime, Sortable: true},
+	"updated_at":       {Type: FieldTime, Sortable: true},
+}
+
+// ClaimDetails are the relations loaded for a single claim view
+var ClaimDetails = []string{"PolicyMember", "Center", "Items", "Diagnoses", "DeductionReasons.ReasonCode"}
+
+// ClaimRepository persists claims
+type ClaimRepository interface {
+	Repository[entity.Claim]
+
+	FindByTrackingCode(ctx context.Context, tenantID uint, trackingCode string) (*entity.Claim, error)
+	FindByPolicyMember(ctx context.Context, tenantID uint, policyMemberID uint, opts QueryOptions) (*PaginatedResult[entity.Claim], error)
+	FindByPackage(ctx context.Context, tenantID uint, packageID uint, opts QueryOptions) (*PaginatedResult[entity.Claim], error)
+	FindByStatus(ctx context.Context, tenantID uint, status entity.ClaimStatus, opts QueryOptions) (*PaginatedResult[entity.Claim], error)
+	FindByCenter(ctx context.Context, tenantID uint, centerID uint, opts QueryOptions) (*PaginatedResult[entity.Claim], error)
+	// FindByMemberBetween returns the member's claims, with items, whose
+	// service date or hospital stay falls in [from, to], except claim excludeID
+	FindByMemberBetween(ctx context.Context, tenantID uint, policyMemberID uint, from, to time.Time, excludeID uint) ([]entity.Claim, error)
+	FindPendingExamination(ctx context.Context, tenantID uint, examinerID *uint, opts QueryOptions) (*PaginatedResult[entity.Claim], error)
+
+	GetStatsByStatus(ctx context.Context, tenantID uint, filters []Filter) (map[entity.ClaimStatus]int64, error)
+	GetStatsByType(ctx context.Context, tenantID uint, filters []Filter) (map[entity.ClaimType]int64, error)
+	GetTotalAmounts(ctx context.Context, tenantID uint, filters []Filter) (requested, approved, deduction int64, err error)
+
+	// UpdateAmounts saves the request amount and basic insurance share of
+	// claim, provided the stored status is still from; otherwise it returns
+	// ErrStatusChanged. No other column is written, so a concurrent
+	// transition is never overwritten with stale values.
+	UpdateAmounts(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus) error
+
+	// Transition saves claim and appends history in one transaction, provided
+	// the stored status is still from; otherwise it returns ErrStatusChanged
+	Transition(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus, history *entity.ClaimStatusHistory) error
+	// Examine is Transition that also saves the examination columns of
+	// claim.Items and replaces the claim's deduction reasons. Auto-approval
+	// passes one history record per transition it made.
+	Examine(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus, history ...*entity.ClaimStatusHistory) error
+	FindStatusHistory(ctx context.Context, tenantID uint, claimID uint) ([]entity.ClaimStatusHistory, error)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// CursorColumn is the keyset sort column; id breaks ties
+const CursorColumn = "created_at"
+
+// TotalMode selects how cursor pages report the total row count
+type TotalMode string
+
+const (
+	TotalNone     TotalMode = "none"
+	TotalEstimate TotalMode = "estimate" // planner estimate, cheap on large tables
+	TotalExact    TotalMode = "exact"    // COUNT(*)
+)
+
+// CursorPage selects rows after an opaque cursor, ordered by (created_at, id)
+type CursorPage struct {
+	After string // cursor from the previous page; empty for the first page
+	Limit int
+	Desc  bool
+	Total TotalMode
+}
+
+// Normalize applies defaults and bounds to the page size
+func (p *CursorPage) Normalize() {
+	if p.Limit < 1 {
+		p.Limit = DefaultPageSize
+	}
+	if p.Limit > MaxPageSize {
+		p.Limit = MaxPageSize
+	}
+	if p.Total == "" {
+		p.Total = TotalNone
+	}
+}
+
+// CursorResult is one keyset page of T
+type CursorResult[T any] struct {
+	Items          []T    `json:"items"`
+	NextCursor     string `json:"next_cursor,omitempty"`
+	HasMore        bool   `json:"has_more"`
+	Limit          int    `json:"limit"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+}
+
+// Cursor is the position of the last row of a page
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uint      `json:"i"`
+	Desc      bool      `json:"d"`
+}
+
+// Encode returns the opaque form handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode for a page sorted in the same direction
+func DecodeCursor(s string, desc bool) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.ID == 0 {
+		return Cursor{}, &QueryError{Param: "cursor", Message: "invalid cursor"}
+	}
+	if c.Desc != desc {
+		return Cursor{}, &QueryError{Param: "cursor", Message: "cursor was issued for a different sort order"}
+	}
+	return c, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// EmployeeFields whitelists employee filters and sorts
+var EmployeeFields = FieldSet{
+	"id":               {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"parent_id":        {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"personnel_code":   {Type: FieldString, Operators: []string{OpEq, OpLike, OpIn}, Sortable: true},
+	"national_code":    {Type: FieldString, Operators: []string{OpEq, OpLike, OpIn}},
+	"first_name":       {Type: FieldString, Operators: []string{OpEq, OpLike}, Sortable: true},
+	"last_name":        {Type: FieldString, Operators: []string{OpEq, OpLike}, Sortable: true},
+	"relation_type":    {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"gender":           {Type: FieldString, Operators: []string{OpEq}},
+	"status":           {Type: FieldString, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"is_active":        {Type: FieldBool},
+	"recruitment_date": {Type: FieldTime, Sortable: true},
+	"created_at":       {Type: FieldTime, Sortable: true},
+	"updated_at":       {Type: FieldTime, Sortable: true},
+}
+
+// EmployeeRepository persists employees and their family members
+type EmployeeRepository interface {
+	Repository[entity.Employee]
+
+	FindByNationalCode(ctx context.Context, tenantID uint, nationalCode string) (*entity.Employee, error)
+	FindByPersonnelCode(ctx context.Context, tenantID uint, personnelCode string) (*entity.Employee, error)
+	FindFamilyMembers(ctx context.Context, tenantID uint, parentID uint) ([]entity.Employee, error)
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidQuery wraps every QueryError
+var ErrInvalidQuery = errors.New("invalid query")
+
+// QueryError reports a filter or sort that the entity does not allow
+type QueryError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+func (e *QueryError) Unwrap() error {
+	return ErrInvalidQuery
+}
+
+// FieldType decides how query string values are parsed
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldBool
+	FieldTime
+)
+
+// Default operators per field type
+var defaultOperators = map[FieldType][]string{
+	FieldString: {OpEq, OpNe, OpLike, OpIn},
+	FieldInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn},
+	FieldBool:   {OpEq, OpNe},
+	FieldTime:   {OpEq, OpGt, OpGte, OpLt, OpLte},
+}
+
+// Field is one filterable or sortable attribute of an entity
+type Field struct {
+	Column    string // defaults to the field name
+	Type      FieldType
+	Operators []string // defaults by Type
+	Sortable  bool
+}
+
+// FieldSet whitelists the fields of an entity by their public name.
+// Names and columns never come from user input unless found here.
+type FieldSet map[string]Field
+
+// Filterable resolves a filter to its column, rejecting unknown fields and operators
+func (s FieldSet) Filterable(name, op string) (Field, error) {
+	f, ok := s[name]
+	if !ok {
+		return Field{}, &QueryError{Param: "filter[" + name + "]", Message: "unknown field, allowed: " + strings.Join(s.names(false), ", ")}
+	}
+	ops := f.Operators
+	if ops == nil {
+		ops = defaultOperators[f.Type]
+	}
+	for _, allowed := range ops {
+		if allowed == op {
+			return f.withColumn(name), nil
+		}
+	}
+	return Field{}, &QueryError{
+		Param:   "filter[" + name + "][" + op + "]",
+		Message: "operator not allowed, allowed: " + strings.Join(ops, ", "),
+	}
+}
+
+// Sortable resolves a sort field to its column
+func (s FieldSet) Sortable(name string) (Field, error) {
+	f, ok := s[name]
+	if !ok || !f.Sortable {
+		return Field{}, &QueryError{Param: "sort", Message: fmt.Sprintf("cannot sort by %q, allowed: %s", name, strings.Join(s.names(true), ", "))}
+	}
+	return f.withColumn(name), nil
+}
+
+// Validate checks every filter and the sort of opts
+func (s FieldSet) Validate(opts QueryOptions) error {
+	for _, filter := range opts.Filters {
+		if _, err := s.Filterable(filter.Field, filter.Operator); err != nil {
+			return err
+		}
+	}
+	if opts.Pagination != nil && opts.Pagination.Sort != "" {
+		if _, err := s.Sortable(opts.Pagination.Sort); err != nil {
+			return err
+		}
+		if o := opts.Pagination.Order; o != "" && o != "asc" && o != "desc" {
+			return &QueryError{Param: "order", Message: "must be asc or desc"}
+		}
+	}
+	return nil
+}
+
+func (f Field) withColumn(name string) Field {
+	if f.Column == "" {
+		f.Column = name
+	}
+	return f
+}
+
+func (s FieldSet) names(sortableOnly bool) []string {
+	names := make([]string, 0, len(s))
+	for name, f := range s {
+		if !sortableOnly || f.Sortable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
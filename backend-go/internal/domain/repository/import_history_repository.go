@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ImportHistoryFields whitelists employee import history filters and sorts
+var ImportHistoryFields = FieldSet{
+	"id":          {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"batch_id":    {Type: FieldString, Operators: []string{OpEq, OpLike}},
+	"source":      {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"status":      {Type: FieldString, Operators: []string{OpEq, OpNe, OpIn}},
+	"import_date": {Type: FieldTime, Sortable: true},
+	"created_at":  {Type: FieldTime, Sortable: true},
+}
+
+// ImportHistoryRepository persists employee import batches
+type ImportHistoryRepository interface {
+	Repository[entity.EmployeeImportHistory]
+
+	FindByBatchID(ctx context.Context, tenantID uint, batchID string) (*entity.EmployeeImportHistory, error)
+}
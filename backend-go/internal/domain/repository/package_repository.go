@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// PackageFields whitelists package filters and sorts
+var PackageFields = FieldSet{
+	"id":                  {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"center_id":           {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"work_unit_id":        {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"title":               {Type: FieldString, Operators: []string{OpEq, OpLike}, Sortable: true},
+	"letter_number":       {Type: FieldString, Operators: []string{OpEq, OpLike}},
+	"status":              {Type: FieldInt, Operators: []string{OpEq, OpNe, OpIn}, Sortable: true},
+	"letter_date":         {Type: FieldTime, Sortable: true},
+	"receive_letter_date": {Type: FieldTime, Sortable: true},
+	"created_at":          {Type: FieldTime, Sortable: true},
+}
+
+// PackageRepository persists claim packages sent by centers
+type PackageRepository interface {
+	Repository[entity.Package]
+
+	FindByCenter(ctx context.Context, tenantID uint, centerID uint, opts QueryOptions) (*PaginatedResult[entity.Package], error)
+	FindByStatus(ctx context.Context, tenantID uint, status entity.PackageStatus, opts QueryOptions) (*PaginatedResult[entity.Package], error)
+	GetStatsByStatus(ctx context.Context, tenantID uint) (map[entity.PackageStatus]int64, error)
+}
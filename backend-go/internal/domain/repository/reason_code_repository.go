@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ReasonCodeFields whitelists reason code filters and sorts
+var ReasonCodeFields = FieldSet{
+	"id":        {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"code":      {Type: FieldString, Operators: []string{OpEq, OpLike, OpIn}, Sortable: true},
+	"title_fa":  {Type: FieldString, Operators: []string{OpLike}},
+	"is_active": {Type: FieldBool},
+}
+
+// ReasonCodeRepository persists deduction reason codes of a tenant
+type ReasonCodeRepository interface {
+	Repository[entity.ReasonCode]
+
+	// FindActiveByIDs returns the active codes among ids; unknown, inactive
+	// and other tenants' ids are left out
+	FindActiveByIDs(ctx context.Context, tenantID uint, ids []uint) ([]entity.ReasonCode, error)
+	FindByCode(ctx context.Context, tenantID uint, code string) (*entity.ReasonCode, error)
+}
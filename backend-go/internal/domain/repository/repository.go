@@ -0,0 +1,99 @@
+// Package repository declares the persistence ports of the domain.
+// Every query is scoped to a tenant (insurer); implementations reject
+// calls without one.
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTenantRequired is returned when a call carries no tenant ID
+var ErrTenantRequired = errors.New("repository: tenant id is required")
+
+// Filter operators
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpLike = "like"
+	OpIn   = "in"
+)
+
+// Pagination defaults
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Filter is a single column condition
+type Filter struct {
+	Field    string
+	Operator string
+	Value    interface{}
+}
+
+// Pagination selects one page of a sorted result
+type Pagination struct {
+	Page     int
+	PageSize int
+	Sort     string
+	Order    string // asc, desc
+}
+
+// Normalize applies defaults and bounds to page and page size
+func (p *Pagination) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+}
+
+// Offset returns the number of rows before the page
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// QueryOptions narrows a query. TenantID is mandatory.
+// Cursor is used instead of Pagination by FindWithCursor.
+type QueryOptions struct {
+	TenantID   uint
+	Filters    []Filter
+	Preloads   []string
+	Pagination *Pagination
+	Cursor     *CursorPage
+}
+
+// PaginatedResult is one page of T
+type PaginatedResult[T any] struct {
+	Items      []T   `json:"items"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// TenantScoped is implemented by entities owned by a tenant
+type TenantScoped interface {
+	GetTenantID() uint
+}
+
+// Repository is the CRUD contract shared by tenant-owned entities
+type Repository[T any] interface {
+	Create(ctx context.Context, entity *T) error
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, tenantID uint, id uint) error
+	FindByID(ctx context.Context, id uint, opts QueryOptions) (*T, error)
+	FindAll(ctx context.Context, opts QueryOptions) ([]T, error)
+	FindWithPagination(ctx context.Context, opts QueryOptions) (*PaginatedResult[T], error)
+	FindWithCursor(ctx context.Context, opts QueryOptions) (*CursorResult[T], error)
+	Count(ctx context.Context, opts QueryOptions) (int64, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// RoleRepository reads roles and their permissions; roles are shared by all tenants
+type RoleRepository interface {
+	// PermissionsOf returns the active permissions granted to the active role named role
+	PermissionsOf(ctx context.Context, role entity.RoleName) ([]entity.PermissionName, error)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// RuleSetFields whitelists rule set filters and sorts
+var RuleSetFields = FieldSet{
+	"id":             {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"version":        {Type: FieldInt, Sortable: true},
+	"name":           {Type: FieldString, Operators: []string{OpEq, OpLike}},
+	"status":         {Type: FieldString, Operators: []string{OpEq, OpNe, OpIn}},
+	"effective_from": {Type: FieldTime, Sortable: true},
+	"created_at":     {Type: FieldTime, Sortable: true},
+}
+
+// RuleSetRepository persists the adjudication rule sets of a tenant and the
+// log of their evaluations
+type RuleSetRepository interface {
+	Repository[entity.RuleSet]
+
+	// FindInEffect returns the active rule set with the latest effective date
+	// not after at, or gorm.ErrRecordNotFound when the tenant has none
+	FindInEffect(ctx context.Context, tenantID uint, at time.Time) (*entity.RuleSet, error)
+	// FindScheduled returns the active rule sets taking effect after at, by effective date
+	FindScheduled(ctx context.Context, tenantID uint, after time.Time) ([]entity.RuleSet, error)
+	// NextVersion returns the version number of the tenant's next rule set
+	NextVersion(ctx context.Context, tenantID uint) (uint, error)
+	// CreateWithTests saves a new rule set together with its tests
+	CreateWithTests(ctx context.Context, set *entity.RuleSet, tests []entity.RuleTest) error
+	// Rollback retires the other active rule sets taking effect on or after
+	// set, including scheduled ones, and activates set
+	Rollback(ctx context.Context, set *entity.RuleSet) error
+
+	FindTests(ctx context.Context, tenantID uint, ruleSetID uint) ([]entity.RuleTest, error)
+	CreateTest(ctx context.Context, test *entity.RuleTest) error
+	DeleteTest(ctx context.Context, tenantID uint, ruleSetID uint, testID uint) error
+
+	CreateExecutionLog(ctx context.Context, log *entity.RuleExecutionLog) error
+	FindExecutionLogs(ctx context.Context, tenantID uint, claimID uint) ([]entity.RuleExecutionLog, error)
+	// FindLatestExecutionLogs returns the last successful log at stage of each
+	// of claimIDs, keyed by claim
+	FindLatestExecutionLogs(ctx context.Context, tenantID uint, claimIDs []uint, stage string) (map[uint]entity.RuleExecutionLog, error)
+}
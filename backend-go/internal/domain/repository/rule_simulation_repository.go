@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// RuleSimulationFields whitelists rule simulation filters and sorts
+var RuleSimulationFields = FieldSet{
+	"id":          {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"rule_set_id": {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"status":      {Type: FieldString, Operators: []string{OpEq, OpNe, OpIn}},
+	"created_at":  {Type: FieldTime, Sortable: true},
+}
+
+// RuleSimulationClaimFields whitelists filters and sorts of simulated claims
+var RuleSimulationClaimFields = FieldSet{
+	"id":            {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"simulation_id": {Type: FieldInt, Operators: []string{OpEq}},
+	"claim_id":      {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"tracking_code": {Type: FieldString, Operators: []string{OpEq, OpLike}},
+	"claim_type":    {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"stage":         {Type: FieldString, Operators: []string{OpEq}},
+	"changed":       {Type: FieldBool},
+	"approved_diff": {Type: FieldInt, Sortable: true},
+	"amount":        {Type: FieldInt, Sortable: true},
+}
+
+// RuleSimulationRepository persists rule simulations and their per-claim results
+type RuleSimulationRepository interface {
+	Repository[entity.RuleSimulation]
+
+	// SaveProgress appends results and stores the simulation's counters,
+	// status and summary
+	SaveProgress(ctx context.Context, sim *entity.RuleSimulation, results []entity.RuleSimulationClaim) error
+	// FindClaims pages the results of a simulation; opts.Filters use RuleSimulationClaimFields
+	FindClaims(ctx context.Context, simulationID uint, opts QueryOptions) (*PaginatedResult[entity.RuleSimulationClaim], error)
+}
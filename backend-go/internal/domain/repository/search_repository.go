@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// SearchQuery is normalized search input
+type SearchQuery struct {
+	TenantID uint
+	Key      string   // the whole input folded by persian.SearchKey
+	Words    []string // its words, matched as prefixes
+	Code     string   // the input as a code (tracking, personnel, national), or empty
+	Limit    int
+}
+
+// ClaimHit is a claim found by tracking code or member name
+type ClaimHit struct {
+	ID             uint               `json:"id"`
+	TrackingCode   string             `json:"tracking_code"`
+	ClaimType      entity.ClaimType   `json:"claim_type"`
+	Status         entity.ClaimStatus `json:"status"`
+	ServiceDate    time.Time          `json:"service_date"`
+	PolicyMemberID uint               `json:"policy_member_id"`
+	MemberName     string             `json:"member_name"`
+	NationalCode   string             `json:"national_code"`
+	Rank           float64            `json:"rank"`
+}
+
+// EmployeeHit is a member found by name or code
+type EmployeeHit struct {
+	ID            uint    `json:"id"`
+	ParentID      *uint   `json:"parent_id"`
+	FirstName     string  `json:"first_name"`
+	LastName      string  `json:"last_name"`
+	PersonnelCode string  `json:"personnel_code"`
+	NationalCode  string  `json:"national_code"`
+	IsActive      bool    `json:"is_active"`
+	Rank          float64 `json:"rank"`
+}
+
+// CenterHit is a center found by title or code
+type CenterHit struct {
+	ID       uint              `json:"id"`
+	Title    string            `json:"title"`
+	SiamID   string            `json:"siam_id"`
+	Code     string            `json:"code"`
+	Type     entity.CenterType `json:"type"`
+	IsActive bool              `json:"is_active"`
+	Rank     float64           `json:"rank"`
+}
+
+// SearchRepository runs ranked text searches, best match first
+type SearchRepository interface {
+	SearchClaims(ctx context.Context, q SearchQuery) ([]ClaimHit, error)
+	SearchEmployees(ctx context.Context, q SearchQuery) ([]EmployeeHit, error)
+	SearchCenters(ctx context.Context, q SearchQuery) ([]CenterHit, error)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ErrAlreadyAcknowledged is returned when a breach was acknowledged since it was read
+var ErrAlreadyAcknowledged = errors.New("repository: breach already acknowledged")
+
+// SLAPolicyFields whitelists SLA policy filters and sorts
+var SLAPolicyFields = FieldSet{
+	"status":     {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"claim_type": {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"is_active":  {Type: FieldBool},
+}
+
+// SLABreachFields whitelists SLA breach filters and sorts
+var SLABreachFields = FieldSet{
+	"id":              {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"claim_id":        {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"status":          {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"claim_type":      {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"handler_user_id": {Type: FieldInt, Operators: []string{OpEq, OpIn}},
+	"escalated_to":    {Type: FieldString, Operators: []string{OpEq, OpIn}},
+	"breached_at":     {Type: FieldTime, Sortable: true},
+	"due_at":          {Type: FieldTime, Sortable: true},
+	"acknowledged_at": {Type: FieldTime, Sortable: true},
+	"resolved_at":     {Type: FieldTime, Sortable: true},
+}
+
+// ClaimClock is the part of a claim its SLA timer runs on
+type ClaimClock struct {
+	ID              uint
+	TenantID        uint
+	ClaimType       entity.ClaimType
+	Status          entity.ClaimStatus
+	StatusChangedAt time.Time
+	HandlerUserID   *uint
+}
+
+// ClockQuery selects claims by how long they have been in their status
+type ClockQuery struct {
+	Statuses      []entity.ClaimStatus
+	EnteredBefore time.Time // entered the status no later than this
+	Unbreached    bool      // leave out claims with a breach of their current stay
+	AfterID       uint
+	Limit         int
+}
+
+// SLAPolicyRepository persists SLA policies and the holiday calendar
+type SLAPolicyRepository interface {
+	Repository[entity.SLAPolicy]
+
+	// SavePolicy creates the policy of its status and claim type or replaces its settings
+	SavePolicy(ctx context.Context, policy *entity.SLAPolicy) error
+	FindActivePolicies(ctx context.Context, tenantID uint) ([]entity.SLAPolicy, error)
+	// FindTenantIDs returns the tenants with active policies. It is the one
+	// query across tenants, for the background checker.
+	FindTenantIDs(ctx context.Context) ([]uint, error)
+
+	// FindHolidays returns the tenant's holidays from the date of from on, by date
+	FindHolidays(ctx context.Context, tenantID uint, from time.Time) ([]entity.Holiday, error)
+	CreateHoliday(ctx context.Context, holiday *entity.Holiday) error
+	DeleteHoliday(ctx context.Context, tenantID uint, id uint) error
+
+	// FindClocks returns one batch of the claims q selects, in ID order
+	FindClocks(ctx context.Context, tenantID uint, q ClockQuery) ([]ClaimClock, error)
+}
+
+// SLABreachRepository persists SLA breaches
+type SLABreachRepository interface {
+	Repository[entity.SLABreach]
+
+	// CreateMissing stores the breaches not recorded yet, leaving those of
+	// the same claim stay alone, and returns the ones stored
+	CreateMissing(ctx context.Context, breaches []entity.SLABreach) ([]entity.SLABreach, error)
+	// Resolve closes the open breaches of claims that left the breached
+	// status, or were deleted, and returns how many it closed
+	Resolve(ctx context.Context, tenantID uint, at time.Time) (int64, error)
+	// Acknowledge records that userID took up the escalated breach; it
+	// returns ErrAlreadyAcknowledged if someone did so already
+	Acknowledge(ctx context.Context, breach *entity.SLABreach, userID uint, at time.Time) error
+	FindByClaim(ctx context.Context, tenantID uint, claimID uint) ([]entity.SLABreach, error)
+}
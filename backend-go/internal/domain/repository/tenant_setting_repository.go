@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// TenantSettingFields whitelists tenant setting filters and sorts
+var TenantSettingFields = FieldSet{
+	"setting_key": {Type: FieldString, Operators: []string{OpEq, OpLike, OpIn}, Sortable: true},
+}
+
+// TenantSettingRepository persists the key-value settings of a tenant
+type TenantSettingRepository interface {
+	Repository[entity.TenantSetting]
+
+	// FindValues returns the values of those keys the tenant has a setting for
+	FindValues(ctx context.Context, tenantID uint, keys ...string) (map[string]string, error)
+	// Upsert creates settings, or replaces the value and type of existing
+	// ones with the same key, in one transaction
+	Upsert(ctx context.Context, tenantID uint, settings []entity.TenantSetting) error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ErrAlreadyAssigned is returned when a claim's assignee changed since it was read
+var ErrAlreadyAssigned = errors.New("repository: claim assignment changed")
+
+// WorkQueueFields whitelists work queue filters and sorts
+var WorkQueueFields = FieldSet{
+	"claim_type": {Type: FieldInt, Operators: []string{OpEq, OpIn}, Sortable: true},
+	"mode":       {Type: FieldString, Operators: []string{OpEq}},
+	"is_active":  {Type: FieldBool},
+}
+
+// QueueBacklog counts the claims of one type waiting for examination
+type QueueBacklog struct {
+	ClaimType     entity.ClaimType `json:"claim_type"`
+	Unassigned    int64            `json:"unassigned"`
+	Assigned      int64            `json:"assigned"`
+	OldestWaiting *time.Time       `json:"oldest_waiting"` // ثبت قدیمی‌ترین پرونده بدون ارزیاب
+}
+
+// WorkQueueRepository persists work queues, examiner profiles and claim assignments
+type WorkQueueRepository interface {
+	Repository[entity.WorkQueue]
+
+	FindByClaimType(ctx context.Context, tenantID uint, claimType entity.ClaimType) (*entity.WorkQueue, error)
+	// SaveQueue creates the queue of its claim type or replaces its settings
+	SaveQueue(ctx context.Context, queue *entity.WorkQueue) error
+
+	// FindExaminers returns the tenant's active users holding one of roles,
+	// with their Role loaded
+	FindExaminers(ctx context.Context, tenantID uint, roles []entity.RoleName) ([]entity.User, error)
+	// FindProfiles returns the profiles of users, keyed by user ID; users
+	// without one are left out
+	FindProfiles(ctx context.Context, tenantID uint, userIDs []uint) (map[uint]entity.ExaminerProfile, error)
+	// SaveProfile creates the user's profile or replaces it
+	SaveProfile(ctx context.Context, profile *entity.ExaminerProfile) error
+
+	// Loads counts the claims in statuses assigned to each examiner
+	Loads(ctx context.Context, tenantID uint, statuses []entity.ClaimStatus) (map[uint]int64, error)
+	// Backlog counts claims in statuses per claim type
+	Backlog(ctx context.Context, tenantID uint, statuses []entity.ClaimStatus) ([]QueueBacklog, error)
+
+	// Assign sets the claim's examiner to record.UserID provided it is still
+	// record.PreviousUserID and the claim is in one of statuses, and appends
+	// record; otherwise it returns ErrAlreadyAssigned
+	Assign(ctx context.Context, claim *entity.Claim, statuses []entity.ClaimStatus, record *entity.ClaimAssignment) error
+	// AssignNext assigns the oldest unassigned claim of claimTypes in
+	// statuses to record.UserID, skipping claims other examiners are taking
+	// at the same moment, and appends record. It returns
+	// gorm.ErrRecordNotFound when there is none.
+	AssignNext(ctx context.Context, tenantID uint, claimTypes []entity.ClaimType, statuses []entity.ClaimStatus, record *entity.ClaimAssignment) (*entity.Claim, error)
+	FindAssignments(ctx context.Context, tenantID uint, claimID uint) ([]entity.ClaimAssignment, error)
+}
@@ -0,0 +1,250 @@
+// Package rules evaluates the adjudication rules of a tenant against claims.
+// Rule sets are written in GRL (grule-rule-engine) over two facts: Claim,
+// the read-only ClaimFact, and Result, which collects deductions and flags:
+//
+//	rule DentalCap "سقف تعهد دندانپزشکی" salience 10 {
+//	    when Claim.ClaimType == 3 && Result.Remaining() > 20000000
+//	    then Result.Deduct(Result.Remaining() - 20000000, "R010", "مازاد سقف تعهد");
+//	}
+//
+// Every rule fires at most once per evaluation, so rules need not Retract
+// themselves. Deductions are suggestions on submission and are applied to
+// the examiner's amounts on examination.
+package rules
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/engine"
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+)
+
+var (
+	// ErrInvalidSource is returned when a rule set does not compile
+	ErrInvalidSource = errors.New("rules: invalid rule source")
+	// ErrEvaluation is returned when a rule set fails on a claim
+	ErrEvaluation = errors.New("rules: evaluation failed")
+)
+
+// EvaluationError explains why a rule set could not be compiled or run
+type EvaluationError struct {
+	RuleSetID uint
+	Version   uint
+	Message   string
+	Err       error
+}
+
+func (e *EvaluationError) Error() string {
+	return fmt.Sprintf("rule set %d (v%d): %s", e.RuleSetID, e.Version, e.Message)
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// SourceError lists the GRL syntax errors of a rule source
+type SourceError struct {
+	Errors []string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%d error(s) in rule source: %s", len(e.Errors), strings.Join(e.Errors, "; "))
+}
+
+func (e *SourceError) Unwrap() error {
+	return ErrInvalidSource
+}
+
+// Evaluation is the outcome of one rule set on one claim
+type Evaluation struct {
+	RuleSetID  uint                   `json:"rule_set_id"`
+	Version    uint                   `json:"version"`
+	Stage      Stage                  `json:"stage"`
+	FiredRules []entity.FiredRule     `json:"fired_rules"`
+	Deductions []entity.RuleDeduction `json:"deductions"`
+	Deduction  int64                  `json:"deduction"`
+	Flags      []string               `json:"flags"`
+	Duration   time.Duration          `json:"-"`
+}
+
+// Checksum is the SHA-256 of a rule source, stored with the rule set
+func Checksum(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedLibraries caps the compiled sources an engine keeps. Saved versions
+// in effect and the drafts being simulated fit easily.
+const cachedLibraries = 64
+
+// Engine runs rule sets, keeping the most recently run sources compiled
+type Engine struct {
+	mu        sync.Mutex
+	libraries map[string]*list.Element // by checksum, into recent
+	recent    *list.List               // of *cachedLibrary, most recent first
+}
+
+type cachedLibrary struct {
+	key string
+	lib *ast.KnowledgeLibrary
+}
+
+// NewEngine creates an engine with an empty cache
+func NewEngine() *Engine {
+	return &Engine{libraries: make(map[string]*list.Element), recent: list.New()}
+}
+
+// Compile checks that source is valid GRL and returns the sorted names of
+// its rules. Sources only checked are not cached.
+func (e *Engine) Compile(source string) ([]string, error) {
+	lib, err := build(source)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := lib.NewKnowledgeBaseInstance(knowledgeName, knowledgeVersion)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(kb.RuleEntries))
+	for name := range kb.RuleEntries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Run evaluates set against fact
+func (e *Engine) Run(ctx context.Context, set *entity.RuleSet, fact *ClaimFact) (*Evaluation, error) {
+	start := time.Now()
+	fail := func(err error) error {
+		return &EvaluationError{RuleSetID: set.ID, Version: set.Version, Message: err.Error(), Err: ErrEvaluation}
+	}
+
+	lib, err := e.library(set.Source)
+	if err != nil {
+		return nil, fail(err)
+	}
+	// Knowledge base instances hold working memory, so each run gets its own
+	kb, err := lib.NewKnowledgeBaseInstance(knowledgeName, knowledgeVersion)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	result := newResult(fact)
+	dc := ast.NewDataContext()
+	if err := dc.Add("Claim", fact); err != nil {
+		return nil, fail(err)
+	}
+	if err := dc.Add("Result", result); err != nil {
+		return nil, fail(err)
+	}
+
+	ge := engine.NewGruleEngine()
+	ge.MaxCycle = uint64(len(kb.RuleEntries)) + 1
+	ge.ReturnErrOnFailedRuleEvaluation = true
+	ge.Listeners = []engine.GruleEngineListener{recorder{result}}
+	if err := ge.ExecuteWithContext(ctx, dc, kb); err != nil {
+		return nil, fail(err)
+	}
+
+	return &Evaluation{
+		RuleSetID:  set.ID,
+		Version:    set.Version,
+		Stage:      Stage(fact.Stage),
+		FiredRules: result.FiredRules,
+		Deductions: result.Deductions,
+		Deduction:  result.Total(),
+		Flags:      result.Flags,
+		Duration:   time.Since(start),
+	}, nil
+}
+
+const (
+	knowledgeName    = "claim"
+	knowledgeVersion = "1"
+)
+
+// library returns the compiled rules of source, from the cache when it was
+// run recently. Sources are compiled outside the lock, so a slow build holds
+// up no other run.
+func (e *Engine) library(source string) (*ast.KnowledgeLibrary, error) {
+	key := Checksum(source)
+	if lib := e.cached(key); lib != nil {
+		return lib, nil
+	}
+	lib, err := build(source)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Another run may have compiled the same source meanwhile
+	if el, ok := e.libraries[key]; ok {
+		e.recent.MoveToFront(el)
+		return el.Value.(*cachedLibrary).lib, nil
+	}
+	e.libraries[key] = e.recent.PushFront(&cachedLibrary{key: key, lib: lib})
+	if e.recent.Len() > cachedLibraries {
+		oldest := e.recent.Remove(e.recent.Back()).(*cachedLibrary)
+		delete(e.libraries, oldest.key)
+	}
+	return lib, nil
+}
+
+// cached returns the compiled library under key, or nil
+func (e *Engine) cached(key string) *ast.KnowledgeLibrary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	el, ok := e.libraries[key]
+	if !ok {
+		return nil
+	}
+	e.recent.MoveToFront(el)
+	return el.Value.(*cachedLibrary).lib
+}
+
+// build compiles source
+func build(source string) (*ast.KnowledgeLibrary, error) {
+	lib := ast.NewKnowledgeLibrary()
+	if err := builder.NewRuleBuilder(lib).BuildRuleFromResource(knowledgeName, knowledgeVersion, pkg.NewBytesResource([]byte(source))); err != nil {
+		srcErr := &SourceError{}
+		var reporter *pkg.GruleErrorReporter
+		if errors.As(err, &reporter) {
+			for _, e := range reporter.Errors {
+				srcErr.Errors = append(srcErr.Errors, e.Error())
+			}
+		} else {
+			srcErr.Errors = append(srcErr.Errors, err.Error())
+		}
+		return nil, srcErr
+	}
+	return lib, nil
+}
+
+// recorder attributes the actions of each rule to it and retracts it, so
+// that it fires once
+type recorder struct {
+	result *Result
+}
+
+func (r recorder) BeginCycle(uint64) {}
+
+func (r recorder) EvaluateRuleEntry(uint64, *ast.RuleEntry, bool) {}
+
+func (r recorder) ExecuteRuleEntry(_ uint64, entry *ast.RuleEntry) {
+	entry.Retracted = true
+	r.result.fire(entry)
+}
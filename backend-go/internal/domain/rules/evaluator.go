@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"gorm.io/gorm"
+)
+
+// examinedStatuses are the claims whose approved amount counts towards PriorApproved
+var examinedStatuses = []entity.ClaimStatus{
+	entity.ClaimStatusWaitCheckConfirm,
+	entity.ClaimStatusWaitSendFinancial,
+	entity.ClaimStatusArchived,
+}
+
+// Evaluator runs the rule set a tenant has in effect and logs every run
+type Evaluator struct {
+	sets   repository.RuleSetRepository
+	claims repository.ClaimRepository
+	engine *Engine
+	now    func() time.Time
+}
+
+// NewEvaluator creates an evaluator
+func NewEvaluator(sets repository.RuleSetRepository, claims repository.ClaimRepository, engine *Engine) *Evaluator {
+	return &Evaluator{sets: sets, claims: claims, engine: engine, now: time.Now}
+}
+
+// Evaluate runs the tenant's rule set in effect on claim, loaded with its
+// Items, Diagnoses and PolicyMember. exam is nil on submission. It returns
+// nil when the tenant has no rule set in effect. Failed runs are logged too.
+func (v *Evaluator) Evaluate(ctx context.Context, claim *entity.Claim, exam *workflow.Examination) (*Evaluation, error) {
+	set, err := v.sets.FindInEffect(ctx, claim.TenantID, v.now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fact := NewClaimFact(claim, exam)
+	if fact.PriorApproved, err = priorApproved(ctx, v.claims, claim, v.now()); err != nil {
+		return nil, err
+	}
+
+	eval, runErr := v.engine.Run(ctx, set, fact)
+	log := &entity.RuleExecutionLog{
+		TenantID:  claim.TenantID,
+		ClaimID:   claim.ID,
+		RuleSetID: set.ID,
+		Version:   set.Version,
+		Stage:     fact.Stage,
+		CreatedAt: v.now(),
+	}
+	if runErr != nil {
+		log.Error = runErr.Error()
+	} else {
+		log.FiredRules = eval.FiredRules
+		log.Deduction = eval.Deduction
+		log.Deductions = eval.Deductions
+		log.Flags = eval.Flags
+		log.DurationMs = eval.Duration.Milliseconds()
+	}
+	if err := v.sets.CreateExecutionLog(ctx, log); err != nil {
+		return nil, err
+	}
+	return eval, runErr
+}
+
+// priorApproved sums the member's other examined claims in the year before
+// the service date, or before now for claims without one
+func priorApproved(ctx context.Context, claims repository.ClaimRepository, claim *entity.Claim, now time.Time) (int64, error) {
+	to := claim.ServiceDate
+	if to.IsZero() {
+		to = now
+	}
+	_, approved, _, err := claims.GetTotalAmounts(ctx, claim.TenantID, []repository.Filter{
+		{Field: "policy_member_id", Operator: repository.OpEq, Value: claim.PolicyMemberID},
+		{Field: "id", Operator: repository.OpNe, Value: claim.ID},
+		{Field: "status", Operator: repository.OpIn, Value: examinedStatuses},
+		{Field: "service_date", Operator: repository.OpGte, Value: to.AddDate(-1, 0, 0)},
+		{Field: "service_date", Operator: repository.OpLte, Value: to},
+	})
+	return approved, err
+}
+
+// Apply adds the deductions of an examination stage evaluation to exam,
+// lowering confirmed prices (or the approved amount of claims without items)
+// and adding the rules' reason codes. codes maps the tenant's active reason
+// codes to their IDs; a deduction under any other code fails the evaluation.
+func (ev *Evaluation) Apply(exam *workflow.Examination, codes map[string]uint) error {
+	byItem := make(map[uint]*workflow.ItemExamination, len(exam.Items))
+	for i := range exam.Items {
+		byItem[exam.Items[i].ItemID] = &exam.Items[i]
+	}
+
+	for _, d := range ev.Deductions {
+		codeID, ok := codes[d.ReasonCode]
+		if !ok {
+			return &EvaluationError{RuleSetID: ev.RuleSetID, Version: ev.Version, Err: ErrEvaluation,
+				Message: fmt.Sprintf("کد علت %q قانون %s یافت نشد یا غیرفعال است", d.ReasonCode, d.Rule)}
+		}
+		if d.ClaimItemID == nil {
+			exam.ApprovedAmount -= d.Amount
+			exam.Deduction += d.Amount
+			exam.ReasonCodeIDs = appendID(exam.ReasonCodeIDs, codeID)
+			continue
+		}
+		// Items left unexamined are refused by the examination itself
+		if ie := byItem[*d.ClaimItemID]; ie != nil {
+			ie.ConfirmedPrice -= d.Amount
+			ie.Deduction = 0
+			ie.ReasonCodeIDs = appendID(ie.ReasonCodeIDs, codeID)
+		}
+	}
+	return nil
+}
+
+// ReasonCodes lists the distinct reason codes of the deductions
+func (ev *Evaluation) ReasonCodes() []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, d := range ev.Deductions {
+		if !seen[d.ReasonCode] {
+			seen[d.ReasonCode] = true
+			codes = append(codes, d.ReasonCode)
+		}
+	}
+	return codes
+}
+
+func appendID(ids []uint, id uint) []uint {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+)
+
+// Stage is the point of the claim lifecycle rules are evaluated at
+type Stage string
+
+const (
+	StageSubmit  Stage = "submit"  // ارسال پرونده؛ نتیجه فقط پیشنهادی است
+	StageExamine Stage = "examine" // ثبت ارزیابی؛ کسورات قوانین اعمال می‌شود
+)
+
+// ClaimFact is the claim as rules see it, under the name "Claim". Amounts
+// are in rials. Amount is what rules deduct from: the payable amount on
+// submission, the examiner's approved amount on examination.
+type ClaimFact struct {
+	Stage         string
+	ClaimType     int64
+	AdmissionType int64
+	CenterID      int64
+	TrackingCode  string
+
+	RequestAmount int64
+	BasicInsShare int64
+	PayableAmount int64
+	Amount        int64
+	StayDays      int64 // شب‌های بستری؛ صفر برای سرپایی
+	ItemCount     int64
+
+	MemberAge      int64 // سن در تاریخ خدمت؛ -1 اگر تاریخ تولد ثبت نشده باشد
+	MemberGender   string
+	MemberRelation string
+
+	// PriorApproved is the approved amount of the member's other examined
+	// claims in the year before the service date, for annual coverage limits
+	PriorApproved int64
+
+	items     []itemFact
+	diagnoses []string
+}
+
+type itemFact struct {
+	id       uint
+	code     string
+	quantity int64
+	amount   int64
+}
+
+// NewClaimFact builds the fact of claim, loaded with its Items, Diagnoses and
+// PolicyMember. With exam the fact is for the examination stage and its
+// amounts are the examiner's confirmed ones.
+func NewClaimFact(claim *entity.Claim, exam *workflow.Examination) *ClaimFact {
+	f := &ClaimFact{
+		Stage:         string(StageSubmit),
+		ClaimType:     int64(claim.ClaimType),
+		AdmissionType: int64(claim.AdmissionType),
+		CenterID:      int64(claim.CenterID),
+		TrackingCode:  claim.TrackingCode,
+		RequestAmount: claim.RequestAmount,
+		BasicInsShare: claim.BasicInsShare,
+		PayableAmount: claim.PayableAmount(),
+		Amount:        claim.PayableAmount(),
+		ItemCount:     int64(len(claim.Items)),
+		MemberAge:     -1,
+	}
+
+	confirmed := make(map[uint]int64)
+	if exam != nil {
+		f.Stage = string(StageExamine)
+		for _, ie := range exam.Items {
+			confirmed[ie.ItemID] = ie.ConfirmedPrice
+		}
+		if len(claim.Items) == 0 {
+			f.Amount = exam.ApprovedAmount
+		}
+	}
+	if len(claim.Items) > 0 {
+		f.Amount = 0
+	}
+	for i := range claim.Items {
+		item := &claim.Items[i]
+		amount, ok := confirmed[item.ID]
+		if !ok {
+			amount = item.PayableAmount()
+		}
+		f.items = append(f.items, itemFact{id: item.ID, code: item.ServiceCode, quantity: int64(item.Quantity), amount: amount})
+		f.Amount += amount
+	}
+	for _, d := range claim.Diagnoses {
+		f.diagnoses = append(f.diagnoses, strings.ToUpper(d.ICD10Code))
+	}
+
+	if claim.DischargeDate != nil && !claim.AdmissionDate.IsZero() && claim.DischargeDate.After(claim.AdmissionDate) {
+		f.StayDays = int64(claim.DischargeDate.Sub(claim.AdmissionDate).Hours()/24 + 0.5)
+	}
+	if m := claim.PolicyMember; m != nil {
+		f.MemberGender = m.Gender
+		if m.RelationType != nil {
+			f.MemberRelation = *m.RelationType
+		}
+		if m.BirthDate != nil {
+			f.MemberAge = age(*m.BirthDate, claim.ServiceDate)
+		}
+	}
+	return f
+}
+
+// HasDiagnosis reports whether an ICD-10 code of the claim starts with prefix
+func (f *ClaimFact) HasDiagnosis(prefix string) bool {
+	prefix = strings.ToUpper(prefix)
+	for _, code := range f.diagnoses {
+		if strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasService reports whether the claim has an item with the service code
+func (f *ClaimFact) HasService(code string) bool {
+	for _, item := range f.items {
+		if item.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAmount is the amount of the items with the service code
+func (f *ClaimFact) ServiceAmount(code string) int64 {
+	var sum int64
+	for _, item := range f.items {
+		if item.code == code {
+			sum += item.amount
+		}
+	}
+	return sum
+}
+
+// ServiceQuantity is the quantity of the items with the service code
+func (f *ClaimFact) ServiceQuantity(code string) int64 {
+	var sum int64
+	for _, item := range f.items {
+		if item.code == code {
+			sum += item.quantity
+		}
+	}
+	return sum
+}
+
+// age in whole years at t; t defaults to now
+func age(birth, t time.Time) int64 {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	years := t.Year() - birth.Year()
+	if t.Month() < birth.Month() || (t.Month() == birth.Month() && t.Day() < birth.Day()) {
+		years--
+	}
+	if years < 0 {
+		return 0
+	}
+	return int64(years)
+}
@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+)
+
+// Result collects what the rules decided, under the name "Result". A
+// deduction never exceeds what is left of the claim or item after earlier
+// rules; claim level deductions of claims with items are taken from the
+// items in order.
+type Result struct {
+	fact      *ClaimFact
+	remaining []int64 // per item, or one entry for claims without items
+
+	FiredRules []entity.FiredRule
+	Deductions []entity.RuleDeduction
+	Flags      []string
+}
+
+func newResult(fact *ClaimFact) *Result {
+	r := &Result{fact: fact, FiredRules: []entity.FiredRule{}, Deductions: []entity.RuleDeduction{}, Flags: []string{}}
+	if len(fact.items) == 0 {
+		r.remaining = []int64{fact.Amount}
+	}
+	for _, item := range fact.items {
+		r.remaining = append(r.remaining, item.amount)
+	}
+	return r
+}
+
+// fire records entry as the rule whose actions follow
+func (r *Result) fire(entry *ast.RuleEntry) {
+	r.FiredRules = append(r.FiredRules, entity.FiredRule{
+		Name:        entry.RuleName,
+		Description: entry.RuleDescription,
+		Salience:    entry.Salience,
+	})
+}
+
+func (r *Result) current() *entity.FiredRule {
+	if len(r.FiredRules) == 0 {
+		return nil
+	}
+	return &r.FiredRules[len(r.FiredRules)-1]
+}
+
+// Remaining is the amount left after the deductions so far
+func (r *Result) Remaining() int64 {
+	var sum int64
+	for _, amount := range r.remaining {
+		sum += amount
+	}
+	return sum
+}
+
+// Total is the sum of the deductions so far
+func (r *Result) Total() int64 {
+	var sum int64
+	for _, d := range r.Deductions {
+		sum += d.Amount
+	}
+	return sum
+}
+
+// Deduct takes amount off the claim
+func (r *Result) Deduct(amount int64, reasonCode, message string) {
+	r.deduct(func(int) bool { return true }, amount, reasonCode, message)
+}
+
+// DeductService takes amount off the items with the service code
+func (r *Result) DeductService(code string, amount int64, reasonCode, message string) {
+	r.deduct(r.service(code), amount, reasonCode, message)
+}
+
+// Exclude deducts everything left of the claim, for services not covered
+func (r *Result) Exclude(reasonCode, message string) {
+	r.Deduct(r.Remaining(), reasonCode, message)
+}
+
+// ExcludeService deducts everything left of the items with the service code
+func (r *Result) ExcludeService(code, reasonCode, message string) {
+	match := r.service(code)
+	var left int64
+	for i, amount := range r.remaining {
+		if match(i) {
+			left += amount
+		}
+	}
+	r.deduct(match, left, reasonCode, message)
+}
+
+// Flag notes something for the examiner without deducting
+func (r *Result) Flag(message string) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return
+	}
+	r.Flags = append(r.Flags, message)
+	if rule := r.current(); rule != nil {
+		rule.Messages = append(rule.Messages, message)
+	}
+}
+
+// service matches the remaining entries of the items with code
+func (r *Result) service(code string) func(int) bool {
+	return func(i int) bool {
+		return i < len(r.fact.items) && r.fact.items[i].code == code
+	}
+}
+
+func (r *Result) deduct(match func(int) bool, amount int64, reasonCode, message string) {
+	rule := r.current()
+	name := ""
+	if rule != nil {
+		name = rule.Name
+	}
+	message = strings.TrimSpace(message)
+
+	for i := range r.remaining {
+		if amount <= 0 {
+			break
+		}
+		if !match(i) || r.remaining[i] <= 0 {
+			continue
+		}
+		take := amount
+		if take > r.remaining[i] {
+			take = r.remaining[i]
+		}
+		r.remaining[i] -= take
+		amount -= take
+
+		d := entity.RuleDeduction{Rule: name, Amount: take, ReasonCode: reasonCode, Message: message}
+		if i < len(r.fact.items) {
+			id := r.fact.items[i].id
+			d.ClaimItemID = &id
+			d.ServiceCode = r.fact.items[i].code
+		}
+		r.Deductions = append(r.Deductions, d)
+		if rule != nil {
+			rule.Deduction += take
+		}
+	}
+	if rule != nil && message != "" {
+		rule.Messages = append(rule.Messages, message)
+	}
+}
@@ -0,0 +1,379 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/domain/workflow"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTooManyClaims is returned when a simulation range holds more claims than allowed
+	ErrTooManyClaims = errors.New("rules: too many claims to simulate")
+	// ErrSimulationRunning is returned when the tenant already has a simulation in progress
+	ErrSimulationRunning = errors.New("rules: a simulation is already running")
+)
+
+// simulationPreloads are the relations a claim fact is built from
+var simulationPreloads = []string{"Items", "Diagnoses", "PolicyMember"}
+
+// SimulationConfig bounds simulation runs
+type SimulationConfig struct {
+	MaxClaims int64         // claims one simulation may cover
+	BatchSize int           // claims loaded and saved at a time
+	Timeout   time.Duration // a run still going after this fails
+}
+
+// DefaultSimulationConfig returns default simulation configuration
+func DefaultSimulationConfig() SimulationConfig {
+	return SimulationConfig{
+		MaxClaims: 50000,
+		BatchSize: repository.MaxPageSize,
+		Timeout:   30 * time.Minute,
+	}
+}
+
+// Simulator re-runs a candidate rule set over past claims next to the rule
+// set in effect and records the difference. It writes no execution logs and
+// leaves the claims untouched.
+type Simulator struct {
+	sims   repository.RuleSimulationRepository
+	sets   repository.RuleSetRepository
+	claims repository.ClaimRepository
+	engine *Engine
+	config SimulationConfig
+	now    func() time.Time
+}
+
+// NewSimulator creates a simulator
+func NewSimulator(sims repository.RuleSimulationRepository, sets repository.RuleSetRepository, claims repository.ClaimRepository, engine *Engine, config ...SimulationConfig) *Simulator {
+	cfg := DefaultSimulationConfig()
+	if len(config) > 0 {
+		c := config[0]
+		if c.MaxClaims > 0 {
+			cfg.MaxClaims = c.MaxClaims
+		}
+		if c.BatchSize > 0 && c.BatchSize <= repository.MaxPageSize {
+			cfg.BatchSize = c.BatchSize
+		}
+		if c.Timeout > 0 {
+			cfg.Timeout = c.Timeout
+		}
+	}
+	return &Simulator{sims: sims, sets: sets, claims: claims, engine: engine, config: cfg, now: time.Now}
+}
+
+// Start saves sim, whose range and claim types are set, as a simulation of
+// candidate against the tenant's rule set in effect and runs it in the
+// background. Poll the simulation for its progress and summary.
+func (s *Simulator) Start(ctx context.Context, candidate *entity.RuleSet, sim *entity.RuleSimulation) error {
+	if _, err := s.engine.Compile(candidate.Source); err != nil {
+		return &EvaluationError{RuleSetID: candidate.ID, Version: candidate.Version, Message: err.Error(), Err: err}
+	}
+
+	// Runs cut short by a restart stay running; past the timeout they no longer count
+	busy, err := s.sims.Count(ctx, repository.QueryOptions{
+		TenantID: sim.TenantID,
+		Filters: []repository.Filter{
+			{Field: "status", Operator: repository.OpIn, Value: []entity.RuleSimulationStatus{
+				entity.RuleSimulationPending, entity.RuleSimulationRunning,
+			}},
+			{Field: "created_at", Operator: repository.OpGte, Value: s.now().Add(-s.config.Timeout)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if busy > 0 {
+		return ErrSimulationRunning
+	}
+
+	total, err := s.claims.Count(ctx, repository.QueryOptions{TenantID: sim.TenantID, Filters: simulationFilters(sim)})
+	if err != nil {
+		return err
+	}
+	if total > s.config.MaxClaims {
+		return fmt.Errorf("%w: %d claims in range, at most %d", ErrTooManyClaims, total, s.config.MaxClaims)
+	}
+
+	baseline, err := s.sets.FindInEffect(ctx, sim.TenantID, s.now())
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		baseline = nil
+	case err != nil:
+		return err
+	default:
+		sim.BaselineID = &baseline.ID
+	}
+
+	sim.RuleSetID = candidate.ID
+	sim.Status = entity.RuleSimulationPending
+	sim.TotalClaims = total
+	sim.Summary = entity.RuleSimulationSummary{Rules: []entity.RuleImpact{}}
+	if err := s.sims.Create(ctx, sim); err != nil {
+		return err
+	}
+
+	// The run outlives the request that started it
+	run := *sim
+	go s.run(candidate, baseline, &run)
+	return nil
+}
+
+// run simulates every claim of sim in batches, saving progress after each
+func (s *Simulator) run(candidate, baseline *entity.RuleSet, sim *entity.RuleSimulation) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	started := s.now()
+	sim.Status = entity.RuleSimulationRunning
+	sim.StartedAt = &started
+	err := s.sims.SaveProgress(ctx, sim, nil)
+
+	tally := newTally()
+	page := &repository.CursorPage{Limit: s.config.BatchSize, Total: repository.TotalNone}
+	for err == nil {
+		var batch *repository.CursorResult[entity.Claim]
+		batch, err = s.claims.FindWithCursor(ctx, repository.QueryOptions{
+			TenantID: sim.TenantID,
+			Filters:  simulationFilters(sim),
+			Preloads: simulationPreloads,
+			Cursor:   page,
+		})
+		if err != nil {
+			break
+		}
+
+		var results []entity.RuleSimulationClaim
+		if results, err = s.simulate(ctx, sim, candidate, baseline, batch.Items, tally); err != nil {
+			break
+		}
+		sim.Processed += int64(len(results))
+		sim.Summary = tally.summary()
+		if !batch.HasMore {
+			finished := s.now()
+			sim.Status = entity.RuleSimulationCompleted
+			sim.FinishedAt = &finished
+		}
+		if err = s.sims.SaveProgress(ctx, sim, results); err != nil || !batch.HasMore {
+			break
+		}
+		page.After = batch.NextCursor
+	}
+	if err == nil {
+		return
+	}
+
+	finished := s.now()
+	sim.Status = entity.RuleSimulationFailed
+	sim.Error = err.Error()
+	sim.FinishedAt = &finished
+	// ctx may be what failed, so the failure is saved without it
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	if err := s.sims.SaveProgress(saveCtx, sim, nil); err != nil {
+		log.Printf("rule simulation %d: failed to save failure: %v", sim.ID, err)
+	}
+}
+
+// simulate runs both rule sets on each claim of a batch and adds them to t
+func (s *Simulator) simulate(ctx context.Context, sim *entity.RuleSimulation, candidate, baseline *entity.RuleSet, claims []entity.Claim, t *tally) ([]entity.RuleSimulationClaim, error) {
+	var examined []uint
+	for i := range claims {
+		if isExamined(claims[i].Status) {
+			examined = append(examined, claims[i].ID)
+		}
+	}
+	var logs map[uint]entity.RuleExecutionLog
+	if len(examined) > 0 {
+		var err error
+		if logs, err = s.sets.FindLatestExecutionLogs(ctx, sim.TenantID, examined, string(StageExamine)); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]entity.RuleSimulationClaim, 0, len(claims))
+	for i := range claims {
+		claim := &claims[i]
+		var exam *workflow.Examination
+		if isExamined(claim.Status) {
+			exam = examinerBasis(claim, logs[claim.ID])
+		}
+		fact := NewClaimFact(claim, exam)
+		prior, err := priorApproved(ctx, s.claims, claim, s.now())
+		if err != nil {
+			return nil, err
+		}
+		fact.PriorApproved = prior
+
+		r := entity.RuleSimulationClaim{
+			TenantID:           sim.TenantID,
+			SimulationID:       sim.ID,
+			ClaimID:            claim.ID,
+			TrackingCode:       claim.TrackingCode,
+			ClaimType:          claim.ClaimType,
+			Stage:              fact.Stage,
+			Amount:             fact.Amount,
+			HistoricalApproved: claim.ApprovedAmount,
+			BaselineRules:      []string{},
+			CandidateRules:     []string{},
+		}
+		base, err := s.runSet(ctx, baseline, fact)
+		if err == nil {
+			var cand *Evaluation
+			if cand, err = s.runSet(ctx, candidate, fact); err == nil {
+				compare(&r, base, cand)
+				t.addRules(base, cand)
+			}
+		}
+		if err != nil {
+			// A run that ran out of time is the simulation failing, not the claim
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.Error = err.Error()
+		}
+		t.add(&r)
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// runSet evaluates set against fact; without a set nothing is deducted
+func (s *Simulator) runSet(ctx context.Context, set *entity.RuleSet, fact *ClaimFact) (*Evaluation, error) {
+	if set == nil {
+		return &Evaluation{Stage: Stage(fact.Stage), FiredRules: []entity.FiredRule{}, Deductions: []entity.RuleDeduction{}, Flags: []string{}}, nil
+	}
+	return s.engine.Run(ctx, set, fact)
+}
+
+// compare fills r with the amounts and fired rules of both evaluations
+func compare(r *entity.RuleSimulationClaim, base, cand *Evaluation) {
+	r.BaselineDeduction = base.Deduction
+	r.CandidateDeduction = cand.Deduction
+	r.BaselineApproved = r.Amount - base.Deduction
+	r.CandidateApproved = r.Amount - cand.Deduction
+	r.ApprovedDiff = r.CandidateApproved - r.BaselineApproved
+	r.BaselineRules = ruleNames(base)
+	r.CandidateRules = ruleNames(cand)
+	r.Changed = r.ApprovedDiff != 0 || compareSets("", r.BaselineRules, r.CandidateRules) != ""
+}
+
+// examinerBasis rebuilds the examiner's amounts of an examined claim before
+// the rules in effect at the time deducted from them, using the claim's
+// last examination stage log
+func examinerBasis(claim *entity.Claim, execLog entity.RuleExecutionLog) *workflow.Examination {
+	exam := &workflow.Examination{ApprovedAmount: claim.ApprovedAmount}
+	byItem := make(map[uint]int64)
+	for _, d := range execLog.Deductions {
+		if d.ClaimItemID == nil {
+			exam.ApprovedAmount += d.Amount
+			continue
+		}
+		byItem[*d.ClaimItemID] += d.Amount
+	}
+	for _, item := range claim.Items {
+		exam.Items = append(exam.Items, workflow.ItemExamination{
+			ItemID:         item.ID,
+			ConfirmedPrice: item.ConfirmedPrice + byItem[item.ID],
+		})
+	}
+	return exam
+}
+
+// simulationFilters selects the claims of sim's range
+func simulationFilters(sim *entity.RuleSimulation) []repository.Filter {
+	filters := []repository.Filter{
+		{Field: "service_date", Operator: repository.OpGte, Value: sim.ServiceFrom},
+		{Field: "service_date", Operator: repository.OpLt, Value: sim.ServiceTo},
+	}
+	if len(sim.ClaimTypes) > 0 {
+		filters = append(filters, repository.Filter{Field: "claim_type", Operator: repository.OpIn, Value: sim.ClaimTypes})
+	}
+	return filters
+}
+
+func isExamined(status entity.ClaimStatus) bool {
+	for _, s := range examinedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleNames(eval *Evaluation) []string {
+	names := make([]string, 0, len(eval.FiredRules))
+	for _, rule := range eval.FiredRules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+// tally accumulates the summary of a simulation across batches
+type tally struct {
+	sum   entity.RuleSimulationSummary
+	rules map[string]*entity.RuleImpact
+}
+
+func newTally() *tally {
+	return &tally{rules: make(map[string]*entity.RuleImpact)}
+}
+
+func (t *tally) add(r *entity.RuleSimulationClaim) {
+	t.sum.Claims++
+	if r.Error != "" {
+		t.sum.Failed++
+		return
+	}
+	if r.Changed {
+		t.sum.Changed++
+	}
+	t.sum.BaselineApproved += r.BaselineApproved
+	t.sum.CandidateApproved += r.CandidateApproved
+	t.sum.BaselineDeduction += r.BaselineDeduction
+	t.sum.CandidateDeduction += r.CandidateDeduction
+	t.sum.ApprovedDiff = t.sum.CandidateApproved - t.sum.BaselineApproved
+	t.sum.DeductionDiff = t.sum.CandidateDeduction - t.sum.BaselineDeduction
+}
+
+// addRules counts the fired rules and deductions of both evaluations of a claim
+func (t *tally) addRules(base, cand *Evaluation) {
+	for _, rule := range base.FiredRules {
+		impact := t.rule(rule.Name)
+		impact.BaselineFired++
+		impact.BaselineDeduction += rule.Deduction
+	}
+	for _, rule := range cand.FiredRules {
+		impact := t.rule(rule.Name)
+		impact.CandidateFired++
+		impact.CandidateDeduction += rule.Deduction
+	}
+}
+
+func (t *tally) rule(name string) *entity.RuleImpact {
+	impact, ok := t.rules[name]
+	if !ok {
+		impact = &entity.RuleImpact{Rule: name}
+		t.rules[name] = impact
+	}
+	return impact
+}
+
+// summary returns the totals so far, with rules sorted by name
+func (t *tally) summary() entity.RuleSimulationSummary {
+	sum := t.sum
+	sum.Rules = make([]entity.RuleImpact, 0, len(t.rules))
+	for _, impact := range t.rules {
+		sum.Rules = append(sum.Rules, *impact)
+	}
+	sort.Slice(sum.Rules, func(i, j int) bool { return sum.Rules[i].Rule < sum.Rules[j].Rule })
+	return sum
+}
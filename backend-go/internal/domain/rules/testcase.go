@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// TestResult is the outcome of one rule test
+type TestResult struct {
+	TestID     uint        `json:"test_id"`
+	Name       string      `json:"name"`
+	Passed     bool        `json:"passed"`
+	Failures   []string    `json:"failures,omitempty"`
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
+}
+
+// Validation is the outcome of checking a rule source
+type Validation struct {
+	Valid  bool     `json:"valid"`
+	Rules  []string `json:"rules"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// SampleFact builds the fact of a test's sample claim. Item i gets ID i+1;
+// the quantity defaults to 1 and, on examination, the confirmed price to the
+// payable amount.
+func SampleFact(s entity.RuleTestClaim) *ClaimFact {
+	f := &ClaimFact{
+		Stage:          string(StageSubmit),
+		ClaimType:      int64(s.ClaimType),
+		AdmissionType:  int64(s.AdmissionType),
+		CenterID:       int64(s.CenterID),
+		RequestAmount:  s.RequestAmount,
+		BasicInsShare:  s.BasicInsShare,
+		PayableAmount:  s.RequestAmount - s.BasicInsShare,
+		StayDays:       s.StayDays,
+		ItemCount:      int64(len(s.Items)),
+		MemberAge:      -1,
+		MemberGender:   s.MemberGender,
+		MemberRelation: s.MemberRelation,
+		PriorApproved:  s.PriorApproved,
+	}
+	examine := Stage(s.Stage) == StageExamine
+	if examine {
+		f.Stage = string(StageExamine)
+	}
+	if s.MemberAge != nil {
+		f.MemberAge = *s.MemberAge
+	}
+	for _, d := range s.Diagnoses {
+		f.diagnoses = append(f.diagnoses, strings.ToUpper(d))
+	}
+
+	f.Amount = f.PayableAmount
+	if examine {
+		f.Amount = s.ApprovedAmount
+	}
+	if len(s.Items) > 0 {
+		f.Amount = 0
+	}
+	for i, item := range s.Items {
+		amount := item.RequestAmount - item.BasicInsShare
+		if examine && item.ConfirmedPrice != nil {
+			amount = *item.ConfirmedPrice
+		}
+		quantity := int64(item.Quantity)
+		if quantity == 0 {
+			quantity = 1
+		}
+		f.items = append(f.items, itemFact{id: uint(i + 1), code: item.ServiceCode, quantity: quantity, amount: amount})
+		f.Amount += amount
+	}
+	return f
+}
+
+// Validate compiles source and runs it once on an empty claim, which catches
+// conditions that refer to unknown facts or fields
+func (e *Engine) Validate(ctx context.Context, source string) *Validation {
+	v := &Validation{Rules: []string{}}
+	names, err := e.Compile(source)
+	var srcErr *SourceError
+	switch {
+	case errors.As(err, &srcErr):
+		v.Errors = srcErr.Errors
+		return v
+	case err != nil:
+		v.Errors = []string{err.Error()}
+		return v
+	case len(names) == 0:
+		v.Errors = []string{"متن قوانین هیچ قانونی ندارد"}
+		return v
+	}
+	v.Rules = names
+
+	if _, err := e.Run(ctx, &entity.RuleSet{Source: source}, SampleFact(entity.RuleTestClaim{})); err != nil {
+		var evalErr *EvaluationError
+		if errors.As(err, &evalErr) {
+			err = errors.New(evalErr.Message)
+		}
+		v.Errors = []string{err.Error()}
+		return v
+	}
+	v.Valid = true
+	return v
+}
+
+// RunTests runs tests against set and reports whether every test passed
+func (e *Engine) RunTests(ctx context.Context, set *entity.RuleSet, tests []entity.RuleTest) ([]TestResult, bool) {
+	results := make([]TestResult, 0, len(tests))
+	passed := true
+	for _, t := range tests {
+		r := TestResult{TestID: t.ID, Name: t.Name}
+		eval, err := e.Run(ctx, set, SampleFact(t.Claim))
+		if err != nil {
+			var evalErr *EvaluationError
+			if errors.As(err, &evalErr) {
+				err = errors.New(evalErr.Message)
+			}
+			r.Failures = []string{"خطا در اجرای قوانین: " + err.Error()}
+		} else {
+			r.Evaluation = eval
+			r.Failures = Check(t.Expected, eval)
+		}
+		r.Passed = len(r.Failures) == 0
+		passed = passed && r.Passed
+		results = append(results, r)
+	}
+	return results, passed
+}
+
+// Check compares an evaluation with the expectation of a test and returns
+// a message per mismatch
+func Check(expected entity.RuleTestExpectation, eval *Evaluation) []string {
+	var failures []string
+	if expected.Deduction != nil && *expected.Deduction != eval.Deduction {
+		failures = append(failures, fmt.Sprintf("کسورات %d مورد انتظار بود، %d محاسبه شد", *expected.Deduction, eval.Deduction))
+	}
+	if expected.FiredRules != nil {
+		fired := make([]string, 0, len(eval.FiredRules))
+		for _, rule := range eval.FiredRules {
+			fired = append(fired, rule.Name)
+		}
+		if msg := compareSets("قوانین اجرا شده", expected.FiredRules, fired); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	if expected.ReasonCodes != nil {
+		if msg := compareSets("کدهای علت", expected.ReasonCodes, eval.ReasonCodes()); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	if expected.Flags != nil {
+		if msg := compareSets("هشدارها", expected.Flags, eval.Flags); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	return failures
+}
+
+// compareSets reports the difference of two string sets, order ignored
+func compareSets(what string, want, got []string) string {
+	w, g := distinct(want), distinct(got)
+	if strings.Join(w, "\x00") == strings.Join(g, "\x00") {
+		return ""
+	}
+	return fmt.Sprintf("%s [%s] مورد انتظار بود، [%s] به دست آمد", what, strings.Join(w, "، "), strings.Join(g, "، "))
+}
+
+func distinct(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,156 @@
+// Package search finds claims, members and centers by what users type: a
+// name in any Persian spelling, part of a name, or a code. The input is
+// folded like the indexed text (see persian.SearchKey), so ي and ی, ك and
+// ک, Persian and ASCII digits and names with or without a zero-width
+// non-joiner all match. Input that looks like a code also matches tracking,
+// personnel, national and SIAM codes exactly or, for tracking codes, by
+// prefix.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/persian"
+)
+
+// ErrQueryTooShort is returned for input shorter than MinQueryLength
+var ErrQueryTooShort = errors.New("search: query too short")
+
+const (
+	// MinQueryLength is the fewest letters or digits a search takes
+	MinQueryLength = 2
+	// DefaultLimit is the number of hits per scope when none is asked for
+	DefaultLimit = 10
+	// MaxLimit caps the hits per scope
+	MaxLimit = 50
+)
+
+// Scope is a kind of record to search
+type Scope string
+
+const (
+	ScopeClaims    Scope = "claims"
+	ScopeEmployees Scope = "employees"
+	ScopeCenters   Scope = "centers"
+)
+
+// Scopes are all scopes, in the order results are listed
+var Scopes = []Scope{ScopeClaims, ScopeEmployees, ScopeCenters}
+
+// ParseScope returns the scope named s
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("search: unknown scope %q", s)
+}
+
+// Results are the hits of each searched scope, best first. Scopes not
+// searched are nil.
+type Results struct {
+	Query     string                   `json:"query"`
+	Claims    []repository.ClaimHit    `json:"claims,omitempty"`
+	Employees []repository.EmployeeHit `json:"employees,omitempty"`
+	Centers   []repository.CenterHit   `json:"centers,omitempty"`
+}
+
+// Searcher runs searches over the scopes
+type Searcher struct {
+	repo repository.SearchRepository
+}
+
+// NewSearcher creates a searcher
+func NewSearcher(repo repository.SearchRepository) *Searcher {
+	return &Searcher{repo: repo}
+}
+
+// Search searches scopes, or all scopes when none are given, for text and
+// returns up to limit hits of each
+func (s *Searcher) Search(ctx context.Context, tenantID uint, text string, scopes []Scope, limit int) (*Results, error) {
+	q, err := Query(tenantID, text, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		scopes = Scopes
+	}
+
+	results := &Results{Query: q.Key}
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeClaims:
+			if results.Claims, err = s.repo.SearchClaims(ctx, q); err != nil {
+				return nil, err
+			}
+			if results.Claims == nil {
+				results.Claims = []repository.ClaimHit{}
+			}
+		case ScopeEmployees:
+			if results.Employees, err = s.Employees(ctx, q); err != nil {
+				return nil, err
+			}
+		case ScopeCenters:
+			if results.Centers, err = s.repo.SearchCenters(ctx, q); err != nil {
+				return nil, err
+			}
+			if results.Centers == nil {
+				results.Centers = []repository.CenterHit{}
+			}
+		}
+	}
+	return results, nil
+}
+
+// Employees searches members only, for lookups that need no other scope
+func (s *Searcher) Employees(ctx context.Context, q repository.SearchQuery) ([]repository.EmployeeHit, error) {
+	hits, err := s.repo.SearchEmployees(ctx, q)
+	if hits == nil && err == nil {
+		hits = []repository.EmployeeHit{}
+	}
+	return hits, err
+}
+
+// Query normalizes text into a search query. A limit outside 1..MaxLimit
+// is replaced by DefaultLimit or MaxLimit.
+func Query(tenantID uint, text string, limit int) (repository.SearchQuery, error) {
+	key := persian.SearchKey(text)
+	words := persian.Words(key)
+	if utf8.RuneCountInString(strings.Join(words, "")) < MinQueryLength {
+		return repository.SearchQuery{}, ErrQueryTooShort
+	}
+	switch {
+	case limit <= 0:
+		limit = DefaultLimit
+	case limit > MaxLimit:
+		limit = MaxLimit
+	}
+	return repository.SearchQuery{
+		TenantID: tenantID,
+		Key:      key,
+		Words:    words,
+		Code:     code(text),
+		Limit:    limit,
+	}, nil
+}
+
+// code returns text as a code when it is one word of ASCII letters, digits
+// and dashes, with Persian digits made ASCII
+func code(text string) string {
+	c := persian.Canonical(text)
+	if c == "" {
+		return ""
+	}
+	for _, r := range c {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return ""
+		}
+	}
+	return c
+}
@@ -0,0 +1,223 @@
+package sla
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+)
+
+// Tenant settings holding the work hours
+const (
+	SettingWorkStart = "sla_work_start" // HH:MM
+	SettingWorkEnd   = "sla_work_end"   // HH:MM
+	SettingWorkDays  = "sla_work_days"  // روزهای هفته با شماره Go؛ ۰ یکشنبه تا ۶ شنبه
+)
+
+// maxCalendarDays bounds the day-by-day walks of a calendar
+const maxCalendarDays = 3660
+
+// WorkHours is a tenant's working week
+type WorkHours struct {
+	Start string         `json:"start"` // HH:MM
+	End   string         `json:"end"`   // HH:MM
+	Days  []time.Weekday `json:"days"`
+}
+
+// DefaultWorkHours is Saturday to Wednesday, 8:00 to 16:00
+func DefaultWorkHours() WorkHours {
+	return WorkHours{
+		Start: "08:00",
+		End:   "16:00",
+		Days:  []time.Weekday{time.Saturday, time.Sunday, time.Monday, time.Tuesday, time.Wednesday},
+	}
+}
+
+// Validate returns a user facing message for the first invalid value
+func (w *WorkHours) Validate() string {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return "ساعت شروع کار باید به شکل HH:MM باشد"
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return "ساعت پایان کار باید به شکل HH:MM باشد"
+	}
+	if end <= start {
+		return "ساعت پایان کار باید بعد از ساعت شروع باشد"
+	}
+	if len(w.Days) == 0 {
+		return "حداقل یک روز کاری لازم است"
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return "روز کاری باید بین ۰ و ۶ باشد"
+		}
+	}
+	return ""
+}
+
+// LoadWorkHours reads the work hours of a tenant; missing settings keep the defaults
+func LoadWorkHours(ctx context.Context, settings repository.TenantSettingRepository, tenantID uint) (*WorkHours, error) {
+	values, err := settings.FindValues(ctx, tenantID, SettingWorkStart, SettingWorkEnd, SettingWorkDays)
+	if err != nil {
+		return nil, err
+	}
+
+	w := DefaultWorkHours()
+	if v := values[SettingWorkStart]; v != "" {
+		w.Start = v
+	}
+	if v := values[SettingWorkEnd]; v != "" {
+		w.End = v
+	}
+	if v := values[SettingWorkDays]; v != "" {
+		w.Days = nil
+		for _, part := range strings.Split(v, ",") {
+			d, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("sla: setting %s: %w", SettingWorkDays, err)
+			}
+			w.Days = append(w.Days, time.Weekday(d))
+		}
+	}
+	if msg := w.Validate(); msg != "" {
+		return nil, fmt.Errorf("sla: invalid work hours of tenant %d: %s", tenantID, msg)
+	}
+	return &w, nil
+}
+
+// SaveWorkHours stores w as the tenant's settings
+func SaveWorkHours(ctx context.Context, settings repository.TenantSettingRepository, tenantID uint, w *WorkHours) error {
+	w.Days = sortedWeekdays(w.Days)
+	days := make([]string, len(w.Days))
+	for i, d := range w.Days {
+		days[i] = strconv.Itoa(int(d))
+	}
+	return settings.Upsert(ctx, tenantID, []entity.TenantSetting{
+		{SettingKey: SettingWorkStart, SettingValue: w.Start, SettingType: entity.SettingTypeString},
+		{SettingKey: SettingWorkEnd, SettingValue: w.End, SettingType: entity.SettingTypeString},
+		{SettingKey: SettingWorkDays, SettingValue: strings.Join(days, ","), SettingType: entity.SettingTypeString},
+	})
+}
+
+// Calendar counts business time: the work hours of work days that are not holidays
+type Calendar struct {
+	location *time.Location
+	start    time.Duration // since midnight
+	end      time.Duration
+	workdays [7]bool
+	holidays map[string]bool // 2006-01-02
+}
+
+// NewCalendar creates the calendar of w, which must be valid, in loc
+func NewCalendar(w *WorkHours, holidays []entity.Holiday, loc *time.Location) *Calendar {
+	c := &Calendar{location: loc, holidays: make(map[string]bool, len(holidays))}
+	c.start, _ = parseClock(w.Start)
+	c.end, _ = parseClock(w.End)
+	for _, d := range w.Days {
+		c.workdays[d] = true
+	}
+	for _, h := range holidays {
+		// Dates come back from the database as midnight UTC
+		c.holidays[h.Date.UTC().Format("2006-01-02")] = true
+	}
+	return c
+}
+
+// DayLength is the business time of one work day
+func (c *Calendar) DayLength() time.Duration {
+	return c.end - c.start
+}
+
+// Elapsed returns the business time between from and to
+func (c *Calendar) Elapsed(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	var total time.Duration
+	day := c.midnight(from)
+	for i := 0; i < maxCalendarDays && day.Before(to); i++ {
+		if opens, closes, ok := c.hours(day); ok {
+			if s, e := latest(opens, from), earliest(closes, to); e.After(s) {
+				total += e.Sub(s)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// Add returns the time d of business time after from
+func (c *Calendar) Add(from time.Time, d time.Duration) time.Time {
+	day := c.midnight(from)
+	at := from
+	for i := 0; i < maxCalendarDays; i++ {
+		if opens, closes, ok := c.hours(day); ok && closes.After(from) {
+			at = latest(opens, from)
+			left := closes.Sub(at)
+			if d <= left {
+				return at.Add(d)
+			}
+			d -= left
+			at = closes
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// hours returns the opening and closing time of day, false on days off
+func (c *Calendar) hours(day time.Time) (time.Time, time.Time, bool) {
+	if !c.workdays[day.Weekday()] || c.holidays[day.Format("2006-01-02")] {
+		return time.Time{}, time.Time{}, false
+	}
+	return day.Add(c.start), day.Add(c.end), true
+}
+
+func (c *Calendar) midnight(t time.Time) time.Time {
+	t = t.In(c.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+}
+
+// parseClock parses HH:MM into the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// sortedWeekdays returns days in week order without repeats
+func sortedWeekdays(days []time.Weekday) []time.Weekday {
+	seen := make(map[time.Weekday]bool, len(days))
+	var out []time.Weekday
+	for _, d := range days {
+		if !seen[d] {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
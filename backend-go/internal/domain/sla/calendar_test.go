@@ -0,0 +1,128 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+var tehran = time.FixedZone("IRST", 3*3600+1800)
+
+// at parses "2006-01-02 15:04" in Tehran time. 2024-03-16 is a Saturday.
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation("2006-01-02 15:04", s, tehran)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// testCalendar is Saturday to Wednesday, 8:00 to 16:00, with holidays
+func testCalendar(t *testing.T, holidays ...string) *Calendar {
+	t.Helper()
+	var hs []entity.Holiday
+	for _, h := range holidays {
+		// As loaded from a date column: midnight UTC
+		d, err := time.Parse("2006-01-02", h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs = append(hs, entity.Holiday{Date: d})
+	}
+	w := DefaultWorkHours()
+	return NewCalendar(&w, hs, tehran)
+}
+
+func TestCalendarElapsed(t *testing.T) {
+	tests := []struct {
+		name     string
+		holidays []string
+		from, to string
+		want     time.Duration
+	}{
+		{name: "within one day", from: "2024-03-16 09:00", to: "2024-03-16 12:00", want: 3 * time.Hour},
+		{name: "outside work hours", from: "2024-03-16 06:00", to: "2024-03-16 20:00", want: 8 * time.Hour},
+		{name: "overnight", from: "2024-03-16 15:00", to: "2024-03-17 09:00", want: 2 * time.Hour},
+		{name: "over the weekend", from: "2024-03-20 15:00", to: "2024-03-23 09:00", want: 2 * time.Hour},
+		{name: "over a holiday", holidays: []string{"2024-03-18"}, from: "2024-03-17 15:00", to: "2024-03-19 09:00", want: 2 * time.Hour},
+		{
+			name:     "over a weekend followed by a holiday",
+			holidays: []string{"2024-03-23"},
+			from:     "2024-03-20 14:00", to: "2024-03-24 10:00",
+			want: 4 * time.Hour,
+		},
+		{name: "on a holiday", holidays: []string{"2024-03-18"}, from: "2024-03-18 09:00", to: "2024-03-18 15:00", want: 0},
+		{name: "on a weekend", from: "2024-03-21 09:00", to: "2024-03-22 15:00", want: 0},
+		{name: "backwards", from: "2024-03-17 12:00", to: "2024-03-16 12:00", want: 0},
+		{name: "whole weeks", from: "2024-03-16 08:00", to: "2024-03-30 08:00", want: 10 * 8 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCalendar(t, tt.holidays...)
+			if got := c.Elapsed(at(t, tt.from), at(t, tt.to)); got != tt.want {
+				t.Errorf("Elapsed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		holidays []string
+		from     string
+		d        time.Duration
+		want     string
+	}{
+		{name: "within one day", from: "2024-03-16 09:00", d: 3 * time.Hour, want: "2024-03-16 12:00"},
+		{name: "up to closing", from: "2024-03-16 15:00", d: time.Hour, want: "2024-03-16 16:00"},
+		{name: "into the next day", from: "2024-03-16 15:00", d: 2 * time.Hour, want: "2024-03-17 09:00"},
+		{name: "before opening", from: "2024-03-16 06:00", d: time.Hour, want: "2024-03-16 09:00"},
+		{name: "after closing", from: "2024-03-16 18:00", d: time.Hour, want: "2024-03-17 09:00"},
+		{name: "over the weekend", from: "2024-03-20 15:00", d: 2 * time.Hour, want: "2024-03-23 09:00"},
+		{name: "from a holiday", holidays: []string{"2024-03-18"}, from: "2024-03-18 10:00", d: time.Hour, want: "2024-03-19 09:00"},
+		{
+			name:     "over a weekend followed by a holiday",
+			holidays: []string{"2024-03-23"},
+			from:     "2024-03-20 15:00", d: 2 * time.Hour,
+			want: "2024-03-24 09:00",
+		},
+		{name: "several days", from: "2024-03-16 12:00", d: 3 * 8 * time.Hour, want: "2024-03-19 12:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCalendar(t, tt.holidays...)
+			from := at(t, tt.from)
+			got := c.Add(from, tt.d)
+			if want := at(t, tt.want); !got.Equal(want) {
+				t.Errorf("Add = %v, want %v", got, want)
+			}
+			if back := c.Elapsed(from, got); back != tt.d {
+				t.Errorf("Elapsed after Add = %v, want %v", back, tt.d)
+			}
+		})
+	}
+}
+
+func TestWorkHoursValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours WorkHours
+		valid bool
+	}{
+		{name: "default", hours: DefaultWorkHours(), valid: true},
+		{name: "bad start", hours: WorkHours{Start: "8", End: "16:00", Days: []time.Weekday{time.Saturday}}},
+		{name: "end before start", hours: WorkHours{Start: "16:00", End: "08:00", Days: []time.Weekday{time.Saturday}}},
+		{name: "no days", hours: WorkHours{Start: "08:00", End: "16:00"}},
+		{name: "bad day", hours: WorkHours{Start: "08:00", End: "16:00", Days: []time.Weekday{7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tt.hours.Validate(); (msg == "") != tt.valid {
+				t.Errorf("Validate = %q, want valid %v", msg, tt.valid)
+			}
+		})
+	}
+}
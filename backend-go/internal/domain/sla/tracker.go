@@ -0,0 +1,412 @@
+// Package sla times how long claims wait in each status. A tenant's policies
+// cap the stay in a status, per claim type, in business hours: the tenant's
+// work hours on work days that are not holidays. A background checker
+// records a breach once per stay that outlives its cap and escalates it to
+// the policy's role, supervisors by default; the breach is resolved when
+// the claim moves on.
+package sla
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+)
+
+// OpenStatuses are the statuses a claim may wait in; archived claims are done
+var OpenStatuses = []entity.ClaimStatus{
+	entity.ClaimStatusWaitRegister,
+	entity.ClaimStatusWaitCheck,
+	entity.ClaimStatusWaitCheckConfirm,
+	entity.ClaimStatusWaitCheckAgain,
+	entity.ClaimStatusReturned,
+	entity.ClaimStatusWaitSendFinancial,
+}
+
+// Config tunes the checker
+type Config struct {
+	// Interval between checks; zero disables the background checker
+	Interval time.Duration
+	// BatchSize is the number of claims read per query
+	BatchSize int
+	// Location is where work hours and holidays are reckoned
+	Location *time.Location
+}
+
+// DefaultConfig returns the default checker settings
+func DefaultConfig() Config {
+	loc, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		loc = time.FixedZone("IRST", 3*3600+1800)
+	}
+	return Config{
+		Interval:  5 * time.Minute,
+		BatchSize: 500,
+		Location:  loc,
+	}
+}
+
+// Policies are a tenant's active policies
+type Policies []entity.SLAPolicy
+
+// For returns the policy of claimType in status, falling back to the one for
+// all claim types; nil if there is none
+func (ps Policies) For(status entity.ClaimStatus, claimType entity.ClaimType) *entity.SLAPolicy {
+	var fallback *entity.SLAPolicy
+	for i := range ps {
+		p := &ps[i]
+		if p.Status != status {
+			continue
+		}
+		if p.ClaimType == claimType {
+			return p
+		}
+		if p.ClaimType == 0 {
+			fallback = p
+		}
+	}
+	return fallback
+}
+
+// statuses returns the statuses with a policy, and the shortest target
+func (ps Policies) statuses() ([]entity.ClaimStatus, time.Duration) {
+	seen := make(map[entity.ClaimStatus]bool)
+	var statuses []entity.ClaimStatus
+	var shortest time.Duration
+	for _, p := range ps {
+		if !seen[p.Status] {
+			seen[p.Status] = true
+			statuses = append(statuses, p.Status)
+		}
+		if target := hours(p.TargetHours); shortest == 0 || target < shortest {
+			shortest = target
+		}
+	}
+	return statuses, shortest
+}
+
+// Clock is the SLA timer of a claim's current stay in its status
+type Clock struct {
+	Status         entity.ClaimStatus `json:"status"`
+	EnteredAt      time.Time          `json:"entered_at"`
+	ElapsedHours   float64            `json:"elapsed_hours"` // ساعت کاری
+	Policy         *entity.SLAPolicy  `json:"policy"`        // خالی یعنی بدون مهلت
+	DueAt          *time.Time         `json:"due_at,omitempty"`
+	RemainingHours float64            `json:"remaining_hours"` // منفی پس از تخطی
+	Breached       bool               `json:"breached"`
+}
+
+// Tracker computes SLA timers and records breaches
+type Tracker struct {
+	policies repository.SLAPolicyRepository
+	breaches repository.SLABreachRepository
+	settings repository.TenantSettingRepository
+	config   Config
+	now      func() time.Time
+}
+
+// NewTracker creates a tracker
+func NewTracker(policies repository.SLAPolicyRepository, breaches repository.SLABreachRepository, settings repository.TenantSettingRepository, config ...Config) *Tracker {
+	cfg := DefaultConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return &Tracker{policies: policies, breaches: breaches, settings: settings, config: cfg, now: time.Now}
+}
+
+// Location is where the tracker reckons work hours and holidays
+func (t *Tracker) Location() *time.Location {
+	return t.config.Location
+}
+
+// Calendar returns the business calendar of a tenant
+func (t *Tracker) Calendar(ctx context.Context, tenantID uint) (*Calendar, error) {
+	hours, err := LoadWorkHours(ctx, t.settings, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := t.policies.FindHolidays(ctx, tenantID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return NewCalendar(hours, holidays, t.config.Location), nil
+}
+
+// Clock returns the timer of claim's current status
+func (t *Tracker) Clock(ctx context.Context, claim *entity.Claim) (*Clock, error) {
+	policies, err := t.policies.FindActivePolicies(ctx, claim.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := t.Calendar(ctx, claim.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.now()
+	elapsed := cal.Elapsed(claim.StatusChangedAt, now)
+	clock := &Clock{
+		Status:       claim.Status,
+		EnteredAt:    claim.StatusChangedAt,
+		ElapsedHours: elapsed.Hours(),
+		Policy:       Policies(policies).For(claim.Status, claim.ClaimType),
+	}
+	if clock.Policy != nil {
+		target := hours(clock.Policy.TargetHours)
+		due := cal.Add(claim.StatusChangedAt, target)
+		clock.DueAt = &due
+		clock.RemainingHours = (target - elapsed).Hours()
+		clock.Breached = elapsed >= target
+	}
+	return clock, nil
+}
+
+// Run checks every tenant with policies each Interval until ctx is done.
+// Running it on several instances is safe: each stay is recorded once.
+func (t *Tracker) Run(ctx context.Context) {
+	if t.config.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := t.Check(ctx); err != nil {
+				log.Printf("sla: check: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check checks every tenant with policies and returns the number of new
+// breaches. A failing tenant does not hold up the others.
+func (t *Tracker) Check(ctx context.Context) (int, error) {
+	tenants, err := t.policies.FindTenantIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, tenantID := range tenants {
+		breaches, err := t.CheckTenant(ctx, tenantID)
+		if err != nil {
+			log.Printf("sla: check tenant %d: %v", tenantID, err)
+			continue
+		}
+		total += len(breaches)
+	}
+	return total, nil
+}
+
+// CheckTenant resolves the breaches of claims that moved on, then records
+// and escalates those of claims overstaying their status. It returns the
+// new breaches.
+func (t *Tracker) CheckTenant(ctx context.Context, tenantID uint) ([]entity.SLABreach, error) {
+	now := t.now()
+	if _, err := t.breaches.Resolve(ctx, tenantID, now); err != nil {
+		return nil, err
+	}
+	found, err := t.policies.FindActivePolicies(ctx, tenantID)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	policies := Policies(found)
+	cal, err := t.Calendar(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Business time never runs faster than the wall clock, so claims newer
+	// than the shortest target cannot have breached yet
+	statuses, shortest := policies.statuses()
+	q := repository.ClockQuery{
+		Statuses:      statuses,
+		EnteredBefore: now.Add(-shortest),
+		Unbreached:    true,
+		Limit:         t.config.BatchSize,
+	}
+	var created []entity.SLABreach
+	for {
+		clocks, err := t.policies.FindClocks(ctx, tenantID, q)
+		if err != nil {
+			return created, err
+		}
+		var breaches []entity.SLABreach
+		for _, clock := range clocks {
+			if b := t.breach(cal, policies, clock, now); b != nil {
+				breaches = append(breaches, *b)
+			}
+		}
+		if len(breaches) > 0 {
+			stored, err := t.breaches.CreateMissing(ctx, breaches)
+			created = append(created, stored...)
+			if err != nil {
+				return created, err
+			}
+		}
+		if len(clocks) < q.Limit {
+			break
+		}
+		q.AfterID = clocks[len(clocks)-1].ID
+	}
+
+	for _, b := range created {
+		log.Printf("sla: claim %d overstayed status %d by its %dh target; escalated to %s", b.ClaimID, b.Status, b.TargetHours, b.EscalatedTo)
+	}
+	return created, nil
+}
+
+// breach returns the breach of clock's stay, nil while it is within its policy
+func (t *Tracker) breach(cal *Calendar, policies Policies, clock repository.ClaimClock, now time.Time) *entity.SLABreach {
+	p := policies.For(clock.Status, clock.ClaimType)
+	if p == nil {
+		return nil
+	}
+	target := hours(p.TargetHours)
+	if now.Sub(clock.StatusChangedAt) < target || cal.Elapsed(clock.StatusChangedAt, now) < target {
+		return nil
+	}
+	escalateTo := p.EscalateTo
+	if escalateTo == "" {
+		escalateTo = entity.RoleSupervisor
+	}
+	return &entity.SLABreach{
+		TenantID:      clock.TenantID,
+		ClaimID:       clock.ID,
+		PolicyID:      p.ID,
+		Status:        clock.Status,
+		ClaimType:     clock.ClaimType,
+		EnteredAt:     clock.StatusChangedAt,
+		TargetHours:   p.TargetHours,
+		DueAt:         cal.Add(clock.StatusChangedAt, target),
+		BreachedAt:    now,
+		HandlerUserID: clock.HandlerUserID,
+		EscalatedTo:   escalateTo,
+	}
+}
+
+// AgingBucket is a band of business time spent in a status
+type AgingBucket struct {
+	Label    string  `json:"label"`
+	MinHours float64 `json:"min_hours"`
+	MaxHours float64 `json:"max_hours,omitempty"` // خالی یعنی بی‌انتها
+}
+
+// AgingRow counts the claims of one type waiting in one status
+type AgingRow struct {
+	Status      entity.ClaimStatus `json:"status"`
+	ClaimType   entity.ClaimType   `json:"claim_type"`
+	TargetHours int                `json:"target_hours"` // صفر یعنی بدون مهلت
+	Total       int64              `json:"total"`
+	Breached    int64              `json:"breached"`
+	Buckets     []int64            `json:"buckets"` // به ترتیب Buckets گزارش
+}
+
+// AgingReport spreads the waiting claims over buckets of business time
+type AgingReport struct {
+	WorkdayHours float64       `json:"workday_hours"`
+	Buckets      []AgingBucket `json:"buckets"`
+	Rows         []AgingRow    `json:"rows"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+}
+
+// agingDays are the bucket bounds in work days
+var agingDays = []struct {
+	label    string
+	min, max float64
+}{
+	{"کمتر از ۱ روز", 0, 1},
+	{"۱ تا ۳ روز", 1, 3},
+	{"۳ تا ۵ روز", 3, 5},
+	{"۵ تا ۱۰ روز", 5, 10},
+	{"بیش از ۱۰ روز", 10, 0},
+}
+
+// Aging reports how long the tenant's claims in statuses, all open statuses
+// when empty, have been waiting
+func (t *Tracker) Aging(ctx context.Context, tenantID uint, statuses []entity.ClaimStatus) (*AgingReport, error) {
+	if len(statuses) == 0 {
+		statuses = OpenStatuses
+	}
+	found, err := t.policies.FindActivePolicies(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	policies := Policies(found)
+	cal, err := t.Calendar(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.now()
+	day := cal.DayLength().Hours()
+	report := &AgingReport{WorkdayHours: day, GeneratedAt: now}
+	for _, b := range agingDays {
+		report.Buckets = append(report.Buckets, AgingBucket{Label: b.label, MinHours: b.min * day, MaxHours: b.max * day})
+	}
+
+	type key struct {
+		status    entity.ClaimStatus
+		claimType entity.ClaimType
+	}
+	rows := make(map[key]*AgingRow)
+	q := repository.ClockQuery{Statuses: statuses, EnteredBefore: now, Limit: t.config.BatchSize}
+	for {
+		clocks, err := t.policies.FindClocks(ctx, tenantID, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, clock := range clocks {
+			k := key{clock.Status, clock.ClaimType}
+			row := rows[k]
+			if row == nil {
+				row = &AgingRow{Status: clock.Status, ClaimType: clock.ClaimType, Buckets: make([]int64, len(agingDays))}
+				if p := policies.For(clock.Status, clock.ClaimType); p != nil {
+					row.TargetHours = p.TargetHours
+				}
+				rows[k] = row
+			}
+
+			elapsed := cal.Elapsed(clock.StatusChangedAt, now)
+			row.Total++
+			row.Buckets[bucketOf(report.Buckets, elapsed.Hours())]++
+			if row.TargetHours > 0 && elapsed >= hours(row.TargetHours) {
+				row.Breached++
+			}
+		}
+		if len(clocks) < q.Limit {
+			break
+		}
+		q.AfterID = clocks[len(clocks)-1].ID
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Status != report.Rows[j].Status {
+			return report.Rows[i].Status < report.Rows[j].Status
+		}
+		return report.Rows[i].ClaimType < report.Rows[j].ClaimType
+	})
+	return report, nil
+}
+
+func bucketOf(buckets []AgingBucket, elapsed float64) int {
+	for i, b := range buckets {
+		if b.MaxHours == 0 || elapsed < b.MaxHours {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
+
+func hours(n int) time.Duration {
+	return time.Duration(n) * time.Hour
+}
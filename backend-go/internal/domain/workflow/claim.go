@@ -0,0 +1,302 @@
+// Package workflow holds the state machines that govern status changes of
+// domain entities. A machine only validates and applies a transition in
+// memory; callers persist the entity together with the history record it
+// returns.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// Action names a claim transition
+type Action string
+
+const (
+	ActionSubmit    Action = "submit"    // ارسال برای ارزیابی
+	ActionExamine   Action = "examine"   // ثبت نتیجه ارزیابی
+	ActionApprove   Action = "approve"   // تایید ارزیابی
+	ActionReexamine Action = "reexamine" // ارجاع برای ارزیابی مجدد
+	ActionReturn    Action = "return"    // عودت به مرکز
+	ActionArchive   Action = "archive"   // پرداخت و بایگانی
+)
+
+var (
+	ErrUnknownAction = errors.New("workflow: unknown action")
+	// ErrInvalidTransition is returned when the action is not allowed from the claim's status
+	ErrInvalidTransition = errors.New("workflow: transition not allowed")
+	// ErrForbidden is returned when the actor lacks the transition's permission
+	ErrForbidden = errors.New("workflow: permission denied")
+	// ErrGuardFailed is returned when a guard rejects the claim
+	ErrGuardFailed = errors.New("workflow: guard failed")
+)
+
+// TransitionError explains why an action was refused
+type TransitionError struct {
+	Action  Action
+	From    entity.ClaimStatus
+	Message string
+	Err     error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s from %d: %s", e.Action, e.From, e.Message)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// Actor is whoever fires a transition. System actors (background jobs such
+// as auto-approval) skip permission checks but not guards.
+type Actor struct {
+	UserID      uint
+	System      bool
+	Permissions map[entity.PermissionName]bool
+}
+
+// NewActor creates an actor holding perms
+func NewActor(userID uint, perms ...entity.PermissionName) Actor {
+	a := Actor{UserID: userID, Permissions: make(map[entity.PermissionName]bool, len(perms))}
+	for _, p := range perms {
+		a.Permissions[p] = true
+	}
+	return a
+}
+
+// SystemActor is the actor of transitions made without a user
+func SystemActor() Actor {
+	return Actor{System: true}
+}
+
+// Can reports whether the actor holds perm
+func (a Actor) Can(perm entity.PermissionName) bool {
+	return a.System || perm == "" || a.Permissions[perm]
+}
+
+// Input carries the actor and free-text reason of a transition
+type Input struct {
+	Actor  Actor
+	Reason string
+}
+
+// Guard rejects a transition by returning a user facing message
+type Guard func(c *entity.Claim, in Input) string
+
+// Transition declares one allowed move
+type Transition struct {
+	Action         Action
+	From           []entity.ClaimStatus
+	To             entity.ClaimStatus
+	Permission     entity.PermissionName
+	ReasonRequired bool
+	Guards         []Guard
+	// Apply updates claim fields owned by the transition, after the guards pass
+	Apply func(c *entity.Claim, in Input)
+}
+
+func (t Transition) allowedFrom(s entity.ClaimStatus) bool {
+	for _, from := range t.From {
+		if from == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimTransitions is the claim lifecycle:
+//
+//	WaitRegister/Returned -submit-> WaitCheck -examine-> WaitCheckConfirm -approve-> WaitSendFinancial -archive-> Archived
+//	WaitCheckConfirm -reexamine-> WaitCheckAgain -examine-> WaitCheckConfirm
+//	WaitCheck/WaitCheckAgain/WaitCheckConfirm -return-> Returned
+var ClaimTransitions = []Transition{
+	{
+		Action:     ActionSubmit,
+		From:       []entity.ClaimStatus{entity.ClaimStatusWaitRegister, entity.ClaimStatusReturned},
+		To:         entity.ClaimStatusWaitCheck,
+		Permission: entity.PermClaimCreate,
+		Guards:     []Guard{requireAmounts},
+	},
+	{
+		Action:     ActionExamine,
+		From:       []entity.ClaimStatus{entity.ClaimStatusWaitCheck, entity.ClaimStatusWaitCheckAgain},
+		To:         entity.ClaimStatusWaitCheckConfirm,
+		Permission: entity.PermClaimExamine,
+		Guards:     []Guard{requireExamination, requireAssignee},
+		Apply: func(c *entity.Claim, in Input) {
+			if !in.Actor.System {
+				examiner := in.Actor.UserID
+				c.HandlerUserID = &examiner
+			}
+		},
+	},
+	{
+		Action:     ActionApprove,
+		From:       []entity.ClaimStatus{entity.ClaimStatusWaitCheckConfirm},
+		To:         entity.ClaimStatusWaitSendFinancial,
+		Permission: entity.PermClaimApprove,
+		Guards:     []Guard{requireExamination, requireSecondPerson},
+	},
+	{
+		Action:         ActionReexamine,
+		From:           []entity.ClaimStatus{entity.ClaimStatusWaitCheckConfirm},
+		To:             entity.ClaimStatusWaitCheckAgain,
+		Permission:     entity.PermClaimApprove,
+		ReasonRequired: true,
+	},
+	{
+		Action:         ActionReturn,
+		From:           []entity.ClaimStatus{entity.ClaimStatusWaitCheck, entity.ClaimStatusWaitCheckAgain, entity.ClaimStatusWaitCheckConfirm},
+		To:             entity.ClaimStatusReturned,
+		Permission:     entity.PermClaimReject,
+		ReasonRequired: true,
+	},
+	{
+		Action:     ActionArchive,
+		From:       []entity.ClaimStatus{entity.ClaimStatusWaitSendFinancial},
+		To:         entity.ClaimStatusArchived,
+		Permission: entity.PermSettlementApprove,
+	},
+}
+
+// requireAmounts keeps claims without a payable amount out of examination
+func requireAmounts(c *entity.Claim, _ Input) string {
+	switch {
+	case c.RequestAmount <= 0:
+		return "مبلغ درخواستی پرونده ثبت نشده است"
+	case c.BasicInsShare > c.RequestAmount:
+		return "سهم بیمه پایه نمی‌تواند بیشتر از مبلغ درخواستی باشد"
+	}
+	return ""
+}
+
+// requireExamination checks that approved amount and deduction add up to the payable amount
+func requireExamination(c *entity.Claim, _ Input) string {
+	switch {
+	case c.ApprovedAmount < 0 || c.Deduction < 0:
+		return "مبالغ ارزیابی نمی‌توانند منفی باشند"
+	case c.ApprovedAmount+c.Deduction != c.PayableAmount():
+		return "جمع مبلغ تایید شده و کسورات باید با مبلغ قابل پرداخت برابر باشد"
+	case c.Deduction > 0 && strings.TrimSpace(c.DeductionReason) == "":
+		return "علت کسورات الزامی است"
+	}
+	return ""
+}
+
+// requireSecondPerson stops examiners from approving their own examination
+func requireSecondPerson(c *entity.Claim, in Input) string {
+	if !in.Actor.System && c.HandlerUserID != nil && *c.HandlerUserID == in.Actor.UserID {
+		return "ارزیاب نمی‌تواند ارزیابی خود را تایید کند"
+	}
+	return ""
+}
+
+// requireAssignee keeps examiners off claims their queue gave to someone else;
+// whoever may reassign claims may also examine them
+func requireAssignee(c *entity.Claim, in Input) string {
+	if in.Actor.Can(entity.PermClaimAssign) || c.HandlerUserID == nil || *c.HandlerUserID == in.Actor.UserID {
+		return ""
+	}
+	return "پرونده به ارزیاب دیگری تخصیص یافته است"
+}
+
+// ClaimMachine applies ClaimTransitions
+type ClaimMachine struct {
+	transitions map[Action]Transition
+	order       []Action
+	now         func() time.Time
+}
+
+// NewClaimMachine creates a machine for transitions, ClaimTransitions when none are given
+func NewClaimMachine(transitions ...Transition) *ClaimMachine {
+	if len(transitions) == 0 {
+		transitions = ClaimTransitions
+	}
+	m := &ClaimMachine{transitions: make(map[Action]Transition, len(transitions)), now: time.Now}
+	for _, t := range transitions {
+		if _, dup := m.transitions[t.Action]; !dup {
+			m.order = append(m.order, t.Action)
+		}
+		m.transitions[t.Action] = t
+	}
+	return m
+}
+
+// Transition returns the declaration of action
+func (m *ClaimMachine) Transition(action Action) (Transition, bool) {
+	t, ok := m.transitions[action]
+	return t, ok
+}
+
+// Check reports whether actor may fire action on the claim's current status,
+// without running the guards
+func (m *ClaimMachine) Check(c *entity.Claim, action Action, actor Actor) error {
+	t, ok := m.transitions[action]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+	if !t.allowedFrom(c.Status) {
+		return &TransitionError{Action: action, From: c.Status, Err: ErrInvalidTransition,
+			Message: fmt.Sprintf("پرونده در وضعیت %s امکان %s ندارد", c.Status, action)}
+	}
+	if !actor.Can(t.Permission) {
+		return &TransitionError{Action: action, From: c.Status, Err: ErrForbidden,
+			Message: "مجوز " + string(t.Permission) + " لازم است"}
+	}
+	return nil
+}
+
+// Fire checks status, permission, reason and guards, then moves the claim to
+// the target status and returns the history record to persist with it
+func (m *ClaimMachine) Fire(c *entity.Claim, action Action, in Input) (*entity.ClaimStatusHistory, error) {
+	if err := m.Check(c, action, in.Actor); err != nil {
+		return nil, err
+	}
+	t := m.transitions[action]
+	from := c.Status
+
+	in.Reason = strings.TrimSpace(in.Reason)
+	if t.ReasonRequired && in.Reason == "" {
+		return nil, &TransitionError{Action: action, From: from, Err: ErrGuardFailed, Message: "ذکر علت الزامی است"}
+	}
+	for _, guard := range t.Guards {
+		if msg := guard(c, in); msg != "" {
+			return nil, &TransitionError{Action: action, From: from, Err: ErrGuardFailed, Message: msg}
+		}
+	}
+
+	if t.Apply != nil {
+		t.Apply(c, in)
+	}
+	now := m.now()
+	c.Status = t.To
+	c.StatusChangedAt = now
+	c.UpdatedBy = in.Actor.UserID
+
+	return &entity.ClaimStatusHistory{
+		TenantID:   c.TenantID,
+		ClaimID:    c.ID,
+		Action:     string(action),
+		FromStatus: from,
+		ToStatus:   t.To,
+		ActorID:    in.Actor.UserID,
+		Reason:     in.Reason,
+		CreatedAt:  now,
+	}, nil
+}
+
+// Available lists the actions actor may attempt on the claim's current status
+func (m *ClaimMachine) Available(c *entity.Claim, actor Actor) []Action {
+	var actions []Action
+	for _, action := range m.order {
+		t := m.transitions[action]
+		if t.allowedFrom(c.Status) && actor.Can(t.Permission) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,215 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+// ErrInvalidExamination is returned when examination results do not fit the claim
+var ErrInvalidExamination = errors.New("workflow: invalid examination")
+
+// ExaminationError points at the item (0 for the claim) an examination result is wrong for
+type ExaminationError struct {
+	ItemID  uint
+	Message string
+}
+
+func (e *ExaminationError) Error() string {
+	if e.ItemID == 0 {
+		return "examination: " + e.Message
+	}
+	return fmt.Sprintf("examination of item %d: %s", e.ItemID, e.Message)
+}
+
+func (e *ExaminationError) Unwrap() error {
+	return ErrInvalidExamination
+}
+
+// Examination is the examiner's decision on a claim. Claims with items are
+// examined per item; ApprovedAmount, Deduction and ReasonCodeIDs are only
+// used for claims without items.
+type Examination struct {
+	ApprovedAmount  int64
+	Deduction       int64
+	DeductionReason string
+	ReasonCodeIDs   []uint
+	Items           []ItemExamination
+}
+
+// ItemExamination is the decision on one claim item. Deduction may be left
+// zero; it is derived as payable amount minus confirmed price.
+type ItemExamination struct {
+	ItemID         uint
+	ConfirmedPrice int64
+	Deduction      int64
+	ReasonCodeIDs  []uint
+	Notes          string
+}
+
+// AllReasonCodeIDs returns every reason code referenced by e, without duplicates
+func (e *Examination) AllReasonCodeIDs() []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	add := func(list []uint) {
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	add(e.ReasonCodeIDs)
+	for _, item := range e.Items {
+		add(item.ReasonCodeIDs)
+	}
+	return ids
+}
+
+// ExaminationSummary reports the outcome per item and for the whole claim
+type ExaminationSummary struct {
+	PayableAmount  int64                    `json:"payable_amount"`
+	ApprovedAmount int64                    `json:"approved_amount"`
+	Deduction      int64                    `json:"deduction"`
+	ReasonCodes    []string                 `json:"reason_codes,omitempty"`
+	Items          []ItemExaminationSummary `json:"items,omitempty"`
+}
+
+// ItemExaminationSummary is the outcome of one item
+type ItemExaminationSummary struct {
+	ItemID         uint     `json:"item_id"`
+	Title          string   `json:"title"`
+	RequestAmount  int64    `json:"request_amount"`
+	BasicInsShare  int64    `json:"basic_ins_share"`
+	PayableAmount  int64    `json:"payable_amount"`
+	ConfirmedPrice int64    `json:"confirmed_price"`
+	Deduction      int64    `json:"deduction"`
+	ReasonCodes    []string `json:"reason_codes,omitempty"`
+}
+
+// ApplyExamination validates e against the claim's items and the tenant's
+// active reason codes (keyed by ID), then writes confirmed prices and
+// deductions to the items and recomputes the claim totals. The claim's
+// DeductionReasons are replaced by the codes used. The claim must be loaded
+// with its Items.
+func ApplyExamination(c *entity.Claim, e Examination, codes map[uint]entity.ReasonCode) (*ExaminationSummary, error) {
+	for _, id := range e.AllReasonCodeIDs() {
+		if _, ok := codes[id]; !ok {
+			return nil, &ExaminationError{Message: fmt.Sprintf("کد علت %d یافت نشد یا غیرفعال است", id)}
+		}
+	}
+
+	summary := &ExaminationSummary{PayableAmount: c.PayableAmount()}
+	var reasons []entity.ClaimDeductionReason
+	var used []uint
+
+	if len(c.Items) == 0 {
+		if len(e.Items) > 0 {
+			return nil, &ExaminationError{Message: "پرونده قلمی برای ارزیابی ندارد"}
+		}
+		if e.ApprovedAmount < 0 || e.Deduction < 0 {
+			return nil, &ExaminationError{Message: "مبالغ ارزیابی نمی‌توانند منفی باشند"}
+		}
+		if e.Deduction > 0 && len(e.ReasonCodeIDs) == 0 {
+			return nil, &ExaminationError{Message: "برای کسورات حداقل یک کد علت لازم است"}
+		}
+		summary.ApprovedAmount, summary.Deduction = e.ApprovedAmount, e.Deduction
+		for _, id := range e.ReasonCodeIDs {
+			reasons = append(reasons, entity.ClaimDeductionReason{TenantID: c.TenantID, ClaimID: c.ID, ReasonCodeID: id})
+		}
+		used = e.ReasonCodeIDs
+	} else {
+		byID := make(map[uint]*ItemExamination, len(e.Items))
+		for i := range e.Items {
+			ie := &e.Items[i]
+			if byID[ie.ItemID] != nil {
+				return nil, &ExaminationError{ItemID: ie.ItemID, Message: "قلم بیش از یک بار ارزیابی شده است"}
+			}
+			byID[ie.ItemID] = ie
+		}
+
+		var itemsPayable int64
+		for i := range c.Items {
+			item := &c.Items[i]
+			ie := byID[item.ID]
+			if ie == nil {
+				return nil, &ExaminationError{ItemID: item.ID, Message: "نتیجه ارزیابی قلم ثبت نشده است"}
+			}
+			delete(byID, item.ID)
+
+			payable := item.PayableAmount()
+			deduction := payable - ie.ConfirmedPrice
+			switch {
+			case ie.ConfirmedPrice < 0:
+				return nil, &ExaminationError{ItemID: item.ID, Message: "مبلغ تایید شده نمی‌تواند منفی باشد"}
+			case deduction < 0:
+				return nil, &ExaminationError{ItemID: item.ID, Message: "مبلغ تایید شده بیشتر از مبلغ قابل پرداخت قلم است"}
+			case ie.Deduction != 0 && ie.Deduction != deduction:
+				return nil, &ExaminationError{ItemID: item.ID, Message: "جمع مبلغ تایید شده و کسورات با مبلغ قابل پرداخت قلم برابر نیست"}
+			case deduction > 0 && len(ie.ReasonCodeIDs) == 0:
+				return nil, &ExaminationError{ItemID: item.ID, Message: "برای کسورات حداقل یک کد علت لازم است"}
+			}
+
+			item.ConfirmedPrice = ie.ConfirmedPrice
+			item.Deduction = deduction
+			item.ExaminerNotes = ie.Notes
+			itemsPayable += payable
+			summary.ApprovedAmount += ie.ConfirmedPrice
+			summary.Deduction += deduction
+
+			itemID := item.ID
+			for _, id := range ie.ReasonCodeIDs {
+				reasons = append(reasons, entity.ClaimDeductionReason{TenantID: c.TenantID, ClaimID: c.ID, ClaimItemID: &itemID, ReasonCodeID: id})
+			}
+			used = append(used, ie.ReasonCodeIDs...)
+			summary.Items = append(summary.Items, ItemExaminationSummary{
+				ItemID:         item.ID,
+				Title:          item.Title,
+				RequestAmount:  item.RequestAmount,
+				BasicInsShare:  item.BasicInsShare,
+				PayableAmount:  payable,
+				ConfirmedPrice: item.ConfirmedPrice,
+				Deduction:      item.Deduction,
+				ReasonCodes:    codeNames(ie.ReasonCodeIDs, codes),
+			})
+		}
+		for id := range byID {
+			return nil, &ExaminationError{ItemID: id, Message: "قلم متعلق به این پرونده نیست"}
+		}
+		if itemsPayable != summary.PayableAmount {
+			return nil, &ExaminationError{Message: "جمع مبالغ قابل پرداخت اقلام با مبلغ قابل پرداخت پرونده برابر نیست"}
+		}
+	}
+
+	summary.ReasonCodes = codeNames(used, codes)
+	c.ApprovedAmount = summary.ApprovedAmount
+	c.Deduction = summary.Deduction
+	c.DeductionReasons = reasons
+	c.DeductionReason = strings.TrimSpace(e.DeductionReason)
+	if c.DeductionReason == "" && c.Deduction > 0 {
+		c.DeductionReason = strings.Join(summary.ReasonCodes, "، ")
+	}
+	if c.Deduction == 0 {
+		c.DeductionReason = ""
+	}
+	return summary, nil
+}
+
+// codeNames renders ids as sorted, distinct "code - title" labels
+func codeNames(ids []uint, codes map[uint]entity.ReasonCode) []string {
+	seen := make(map[uint]bool, len(ids))
+	var names []string
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		rc := codes[id]
+		names = append(names, rc.Code+" - "+rc.TitleFa)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,244 @@
+package workflow
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+)
+
+var testReasonCodes = map[uint]entity.ReasonCode{
+	1: {BaseModel: entity.BaseModel{ID: 1}, Code: "R01", TitleFa: "مازاد تعرفه"},
+	2: {BaseModel: entity.BaseModel{ID: 2}, Code: "R02", TitleFa: "خارج از تعهد"},
+}
+
+// itemClaim is a claim of two items payable 800 and 300
+func itemClaim() *entity.Claim {
+	c := &entity.Claim{RequestAmount: 1500, BasicInsShare: 400}
+	c.ID, c.TenantID = 10, 1
+	c.Items = []entity.ClaimItem{
+		{BaseModel: entity.BaseModel{ID: 101}, Title: "ویزیت", RequestAmount: 1000, BasicInsShare: 200},
+		{BaseModel: entity.BaseModel{ID: 102}, Title: "آزمایش", RequestAmount: 500, BasicInsShare: 200},
+	}
+	return c
+}
+
+func TestApplyExaminationItems(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []ItemExamination
+		wantApproved  int64
+		wantDeduction int64
+		wantItems     [][2]int64 // confirmed price, deduction
+		wantCodes     []string
+	}{
+		{
+			name:         "confirmed in full",
+			items:        []ItemExamination{{ItemID: 101, ConfirmedPrice: 800}, {ItemID: 102, ConfirmedPrice: 300}},
+			wantApproved: 1100,
+			wantItems:    [][2]int64{{800, 0}, {300, 0}},
+		},
+		{
+			name: "deduction derived from confirmed price",
+			items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 650, ReasonCodeIDs: []uint{2, 1}},
+				{ItemID: 102, ConfirmedPrice: 300},
+			},
+			wantApproved:  950,
+			wantDeduction: 150,
+			wantItems:     [][2]int64{{650, 150}, {300, 0}},
+			wantCodes:     []string{"R01 - مازاد تعرفه", "R02 - خارج از تعهد"},
+		},
+		{
+			name: "stated deduction that matches",
+			items: []ItemExamination{
+				{ItemID: 102, ConfirmedPrice: 0, Deduction: 300, ReasonCodeIDs: []uint{2}},
+				{ItemID: 101, ConfirmedPrice: 700, Deduction: 100, ReasonCodeIDs: []uint{2}},
+			},
+			wantApproved:  700,
+			wantDeduction: 400,
+			wantItems:     [][2]int64{{700, 100}, {0, 300}},
+			wantCodes:     []string{"R02 - خارج از تعهد"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := itemClaim()
+			summary, err := ApplyExamination(c, Examination{Items: tt.items}, testReasonCodes)
+			if err != nil {
+				t.Fatalf("ApplyExamination: %v", err)
+			}
+			if summary.PayableAmount != 1100 {
+				t.Errorf("payable = %d, want 1100", summary.PayableAmount)
+			}
+			if summary.ApprovedAmount != tt.wantApproved || c.ApprovedAmount != tt.wantApproved {
+				t.Errorf("approved = %d (claim %d), want %d", summary.ApprovedAmount, c.ApprovedAmount, tt.wantApproved)
+			}
+			if summary.Deduction != tt.wantDeduction || c.Deduction != tt.wantDeduction {
+				t.Errorf("deduction = %d (claim %d), want %d", summary.Deduction, c.Deduction, tt.wantDeduction)
+			}
+			for i, want := range tt.wantItems {
+				if got := [2]int64{c.Items[i].ConfirmedPrice, c.Items[i].Deduction}; got != want {
+					t.Errorf("item %d = %v, want %v", c.Items[i].ID, got, want)
+				}
+			}
+			if !reflect.DeepEqual(summary.ReasonCodes, tt.wantCodes) {
+				t.Errorf("reason codes = %q, want %q", summary.ReasonCodes, tt.wantCodes)
+			}
+			if tt.wantDeduction == 0 && c.DeductionReason != "" {
+				t.Errorf("deduction reason = %q without a deduction", c.DeductionReason)
+			}
+		})
+	}
+}
+
+func TestApplyExaminationWithoutItems(t *testing.T) {
+	c := &entity.Claim{RequestAmount: 1000, BasicInsShare: 100}
+	c.ID, c.TenantID = 10, 1
+	summary, err := ApplyExamination(c, Examination{ApprovedAmount: 700, Deduction: 200, ReasonCodeIDs: []uint{1}}, testReasonCodes)
+	if err != nil {
+		t.Fatalf("ApplyExamination: %v", err)
+	}
+	if summary.PayableAmount != 900 || c.ApprovedAmount != 700 || c.Deduction != 200 {
+		t.Errorf("payable %d, approved %d, deduction %d; want 900, 700, 200", summary.PayableAmount, c.ApprovedAmount, c.Deduction)
+	}
+	if c.DeductionReason != "R01 - مازاد تعرفه" {
+		t.Errorf("deduction reason = %q", c.DeductionReason)
+	}
+	if len(c.DeductionReasons) != 1 || c.DeductionReasons[0].ClaimItemID != nil {
+		t.Errorf("deduction reasons = %+v, want one for the claim", c.DeductionReasons)
+	}
+}
+
+func TestApplyExaminationErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		claim      func() *entity.Claim
+		exam       Examination
+		wantItemID uint
+	}{
+		{
+			name:  "unknown reason code",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 700, ReasonCodeIDs: []uint{9}},
+				{ItemID: 102, ConfirmedPrice: 300},
+			}},
+		},
+		{
+			name:  "confirmed price above payable",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 900},
+				{ItemID: 102, ConfirmedPrice: 300},
+			}},
+			wantItemID: 101,
+		},
+		{
+			name:  "negative confirmed price",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 102, ConfirmedPrice: -1, ReasonCodeIDs: []uint{1}},
+			}},
+			wantItemID: 102,
+		},
+		{
+			name:  "stated deduction that does not match",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 700, Deduction: 50, ReasonCodeIDs: []uint{1}},
+				{ItemID: 102, ConfirmedPrice: 300},
+			}},
+			wantItemID: 101,
+		},
+		{
+			name:  "deduction without reason code",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 102, ConfirmedPrice: 100},
+			}},
+			wantItemID: 102,
+		},
+		{
+			name:  "duplicate item",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 102, ConfirmedPrice: 300},
+			}},
+			wantItemID: 101,
+		},
+		{
+			name:  "foreign item",
+			claim: itemClaim,
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 102, ConfirmedPrice: 300},
+				{ItemID: 999, ConfirmedPrice: 10},
+			}},
+			wantItemID: 999,
+		},
+		{
+			name:       "missing item",
+			claim:      itemClaim,
+			exam:       Examination{Items: []ItemExamination{{ItemID: 101, ConfirmedPrice: 800}}},
+			wantItemID: 102,
+		},
+		{
+			name: "items that do not add up to the claim",
+			claim: func() *entity.Claim {
+				c := itemClaim()
+				c.RequestAmount = 2000
+				return c
+			},
+			exam: Examination{Items: []ItemExamination{
+				{ItemID: 101, ConfirmedPrice: 800},
+				{ItemID: 102, ConfirmedPrice: 300},
+			}},
+		},
+		{
+			name: "items for a claim without items",
+			claim: func() *entity.Claim {
+				return &entity.Claim{RequestAmount: 1000}
+			},
+			exam: Examination{Items: []ItemExamination{{ItemID: 101, ConfirmedPrice: 800}}},
+		},
+		{
+			name: "negative amount on a claim without items",
+			claim: func() *entity.Claim {
+				return &entity.Claim{RequestAmount: 1000}
+			},
+			exam: Examination{ApprovedAmount: -1},
+		},
+		{
+			name: "claim deduction without reason code",
+			claim: func() *entity.Claim {
+				return &entity.Claim{RequestAmount: 1000}
+			},
+			exam: Examination{ApprovedAmount: 800, Deduction: 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.claim()
+			_, err := ApplyExamination(c, tt.exam, testReasonCodes)
+			var examErr *ExaminationError
+			if !errors.As(err, &examErr) {
+				t.Fatalf("error = %v, want an ExaminationError", err)
+			}
+			if !errors.Is(err, ErrInvalidExamination) {
+				t.Errorf("error %v does not wrap ErrInvalidExamination", err)
+			}
+			if examErr.ItemID != tt.wantItemID {
+				t.Errorf("item = %d, want %d", examErr.ItemID, tt.wantItemID)
+			}
+			if c.ApprovedAmount != 0 || c.Deduction != 0 {
+				t.Errorf("claim totals changed to %d and %d on error", c.ApprovedAmount, c.Deduction)
+			}
+		})
+	}
+}
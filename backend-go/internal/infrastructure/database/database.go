@@ -1,13 +1,13 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"github.com/bank-melli/tpa/internal/config"
-	"github.com/bank-melli/tpa/internal/domain/entity"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -16,6 +16,8 @@ import (
 // Database wraps the GORM database connection
 type Database struct {
 	*gorm.DB
+	replicas        *replicaSet
+	instrumentation *Instrumentation
 }
 
 // NewDatabase creates a new database connection
@@ -41,6 +43,8 @@ func NewDatabase(cfg *config.DatabaseConfig) (*Database, error) {
 		Logger:                 gormLogger,
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
+		// Unique violations surface as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -57,28 +61,46 @@ func NewDatabase(cfg *config.DatabaseConfig) (*Database, error) {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
 
-	return &Database{db}, nil
-}
+	// Query metrics, slow query log and N+1 detection
+	instrumentation := NewInstrumentation(InstrumentationConfig{
+		SlowThreshold:     cfg.SlowQueryThreshold,
+		NPlusOneThreshold: cfg.NPlusOneThreshold,
+	})
+	if err := db.Use(instrumentation); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to register instrumentation: %w", err)
+	}
 
-// AutoMigrate runs auto migration for all entities
-func (db *Database) AutoMigrate() error {
-	// SIMPLIFIED: Only migrate essential tables for testing
-	return db.DB.AutoMigrate(
-		// Base entities
-		&entity.Insurer{},
+	database := &Database{DB: db, instrumentation: instrumentation}
+
+	// Route eligible reads to replicas when configured
+	if len(cfg.ReplicaDSNs) > 0 {
+		replicas, err := newReplicaSet(cfg)
+		if err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+		if err := db.Use(replicas); err != nil {
+			replicas.close()
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to register replicas: %w", err)
+		}
+		database.replicas = replicas
+	}
 
-		// Employees (single table for main employees and family members)
-		&entity.Employee{},
+	return database, nil
+}
 
-		// Users & Auth
-		&entity.Role{},
-		&entity.Permission{},
-		&entity.User{},
-	)
+// Instrumentation returns the query instrumentation plugin
+func (db *Database) Instrumentation() *Instrumentation {
+	return db.instrumentation
 }
 
 // Close closes the database connection
 func (db *Database) Close() error {
+	if db.replicas != nil {
+		db.replicas.close()
+	}
 	sqlDB, err := db.DB.DB()
 	if err != nil {
 		return err
@@ -88,11 +110,16 @@ func (db *Database) Close() error {
 
 // HealthCheck checks if database connection is healthy
 func (db *Database) HealthCheck() error {
+	return db.Ping(context.Background())
+}
+
+// Ping checks the primary connection within ctx
+func (db *Database) Ping(ctx context.Context) error {
 	sqlDB, err := db.DB.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
 }
 
 // WithTenant returns a new DB instance scoped to a tenant
@@ -104,91 +131,3 @@ func (db *Database) WithTenant(tenantID uint) *gorm.DB {
 func (db *Database) Transaction(fn func(tx *gorm.DB) error) error {
 	return db.DB.Transaction(fn)
 }
-
-// Seed seeds initial data
-func (db *Database) Seed() error {
-	// Seed roles
-	roles := []entity.Role{
-		{Name: entity.RoleSystemAdmin, TitleFa: "مدیر سیستم", Level: 100, IsSystem: true, IsActive: true},
-		{Name: entity.RoleInsurerAdmin, TitleFa: "مدیر بیمه‌گر", Level: 90, IsSystem: true, IsActive: true},
-		{Name: entity.RoleSupervisor, TitleFa: "سرپرست", Level: 80, IsSystem: true, IsActive: true},
-		{Name: entity.RoleClaimExaminer, TitleFa: "ارزیاب", Level: 50, IsSystem: false, IsActive: true},
-		{Name: entity.RoleDrugExaminer, TitleFa: "ارزیاب دارو", Level: 50, IsSystem: false, IsActive: true},
-		{Name: entity.RoleFinancialOfficer, TitleFa: "کارشناس مالی", Level: 60, IsSystem: false, IsActive: true},
-		{Name: entity.RoleCenterUser, TitleFa: "کاربر مرکز", Level: 30, IsSystem: false, IsActive: true},
-		{Name: entity.RoleReportViewer, TitleFa: "مشاهده‌کننده گزارش", Level: 20, IsSystem: false, IsActive: true},
-	}
-
-	for _, role := range roles {
-		var existing entity.Role
-		if err := db.DB.Where("name = ?", role.Name).First(&existing).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				if err := db.DB.Create(&role).Error; err != nil {
-					return fmt.Errorf("failed to seed role %s: %w", role.Name, err)
-				}
-			} else {
-				return err
-			}
-		}
-	}
-
-	// Seed permissions
-	permissions := []entity.Permission{
-		// Claims
-		{Name: entity.PermClaimCreate, TitleFa: "ایجاد ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimRead, TitleFa: "مشاهده ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimUpdate, TitleFa: "ویرایش ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimDelete, TitleFa: "حذف ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimExamine, TitleFa: "ارزیابی ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimApprove, TitleFa: "تایید ادعا", Module: "claims", IsActive: true},
-		{Name: entity.PermClaimReject, TitleFa: "رد ادعا", Module: "claims", IsActive: true},
-
-		// Packages
-		{Name: entity.PermPackageCreate, TitleFa: "ایجاد بسته", Module: "packages", IsActive: true},
-		{Name: entity.PermPackageRead, TitleFa: "مشاهده بسته", Module: "packages", IsActive: true},
-		{Name: entity.PermPackageUpdate, TitleFa: "ویرایش بسته", Module: "packages", IsActive: true},
-		{Name: entity.PermPackageDelete, TitleFa: "حذف بسته", Module: "packages", IsActive: true},
-		{Name: entity.PermPackageExamine, TitleFa: "ارزیابی بسته", Module: "packages", IsActive: true},
-		{Name: entity.PermPackageApprove, TitleFa: "تایید بسته", Module: "packages", IsActive: true},
-
-		// Centers
-		{Name: entity.PermCenterCreate, TitleFa: "ایجاد مرکز", Module: "centers", IsActive: true},
-		{Name: entity.PermCenterRead, TitleFa: "مشاهده مرکز", Module: "centers", IsActive: true},
-		{Name: entity.PermCenterUpdate, TitleFa: "ویرایش مرکز", Module: "centers", IsActive: true},
-		{Name: entity.PermCenterDelete, TitleFa: "حذف مرکز", Module: "centers", IsActive: true},
-
-		// Settlements
-		{Name: entity.PermSettlementCreate, TitleFa: "ایجاد تسویه", Module: "settlements", IsActive: true},
-		{Name: entity.PermSettlementRead, TitleFa: "مشاهده تسویه", Module: "settlements", IsActive: true},
-		{Name: entity.PermSettlementApprove, TitleFa: "تایید تسویه", Module: "settlements", IsActive: true},
-
-		// Users
-		{Name: entity.PermUserCreate, TitleFa: "ایجاد کاربر", Module: "users", IsActive: true},
-		{Name: entity.PermUserRead, TitleFa: "مشاهده کاربر", Module: "users", IsActive: true},
-		{Name: entity.PermUserUpdate, TitleFa: "ویرایش کاربر", Module: "users", IsActive: true},
-		{Name: entity.PermUserDelete, TitleFa: "حذف کاربر", Module: "users", IsActive: true},
-
-		// Reports
-		{Name: entity.PermReportView, TitleFa: "مشاهده گزارش", Module: "reports", IsActive: true},
-		{Name: entity.PermReportExport, TitleFa: "خروجی گزارش", Module: "reports", IsActive: true},
-
-		// Settings
-		{Name: entity.PermSettingsRead, TitleFa: "مشاهده تنظیمات", Module: "settings", IsActive: true},
-		{Name: entity.PermSettingsUpdate, TitleFa: "ویرایش تنظیمات", Module: "settings", IsActive: true},
-	}
-
-	for _, perm := range permissions {
-		var existing entity.Permission
-		if err := db.DB.Where("name = ?", perm.Name).First(&existing).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				if err := db.DB.Create(&perm).Error; err != nil {
-					return fmt.Errorf("failed to seed permission %s: %w", perm.Name, err)
-				}
-			} else {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
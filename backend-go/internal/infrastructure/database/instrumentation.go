@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/pkg/metrics"
+	"gorm.io/gorm"
+)
+
+// InstrumentationConfig configures query instrumentation
+type InstrumentationConfig struct {
+	// SlowThreshold logs queries slower than this (0 disables the slow log)
+	SlowThreshold time.Duration
+
+	// NPlusOneThreshold flags a request that repeats the same SELECT this many times
+	NPlusOneThreshold int
+
+	Metrics *metrics.Registry
+	Logger  *log.Logger
+}
+
+// DefaultInstrumentationConfig returns default instrumentation configuration
+func DefaultInstrumentationConfig() InstrumentationConfig {
+	return InstrumentationConfig{
+		SlowThreshold:     200 * time.Millisecond,
+		NPlusOneThreshold: 10,
+		Metrics:           metrics.Default,
+		Logger:            log.New(os.Stdout, "[db] ", log.LstdFlags),
+	}
+}
+
+// Instrumentation is a GORM plugin recording per-route query metrics,
+// logging slow queries and detecting N+1 query patterns
+type Instrumentation struct {
+	config InstrumentationConfig
+
+	queries    *metrics.CounterVec
+	errors     *metrics.CounterVec
+	rows       *metrics.CounterVec
+	slow       *metrics.CounterVec
+	nPlusOne   *metrics.CounterVec
+	duration   *metrics.HistogramVec
+	perRequest *metrics.HistogramVec
+}
+
+// NewInstrumentation creates the instrumentation plugin
+func NewInstrumentation(config ...InstrumentationConfig) *Instrumentation {
+	cfg := DefaultInstrumentationConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = metrics.Default
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = DefaultInstrumentationConfig().Logger
+	}
+
+	m := cfg.Metrics
+	return &Instrumentation{
+		config:     cfg,
+		queries:    m.Counter("tpa_db_queries_total", "Database statements executed.", "route", "operation", "table"),
+		errors:     m.Counter("tpa_db_query_errors_total", "Database statements that returned an error.", "route", "operation", "table"),
+		rows:       m.Counter("tpa_db_rows_affected_total", "Rows returned or affected by database statements.", "route", "operation", "table"),
+		slow:       m.Counter("tpa_db_slow_queries_total", "Database statements slower than the slow query threshold.", "route", "operation", "table"),
+		nPlusOne:   m.Counter("tpa_db_n_plus_one_total", "Requests that repeated one SELECT past the N+1 threshold.", "route"),
+		duration:   m.Histogram("tpa_db_query_duration_seconds", "Database statement latency.", nil, "route", "operation", "table"),
+		perRequest: m.Histogram("tpa_db_queries_per_request", "Database statements issued per HTTP request.", []float64{1, 2, 5, 10, 20, 50, 100, 250}, "route"),
+	}
+}
+
+// Name implements gorm.Plugin
+func (in *Instrumentation) Name() string {
+	return "tpa:instrumentation"
+}
+
+// Initialize implements gorm.Plugin by timing every statement
+func (in *Instrumentation) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op     string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("tpa:instrumentation:start", startTimer); err != nil {
+			return err
+		}
+		if err := h.after("tpa:instrumentation:record", in.recorder(h.op)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const startKey = "tpa:instrumentation:start"
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (in *Instrumentation) recorder(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, _ := v.(time.Time)
+		elapsed := time.Since(start)
+
+		stmt := db.Statement
+		table := stmt.Table
+		if table == "" {
+			table = "-"
+		}
+		sql := stmt.SQL.String()
+		failed := db.Error != nil && db.Error != gorm.ErrRecordNotFound
+		slow := in.config.SlowThreshold > 0 && elapsed > in.config.SlowThreshold
+
+		q := queryRecord{
+			operation: op,
+			table:     table,
+			duration:  elapsed,
+			rows:      stmt.RowsAffected,
+			failed:    failed,
+			slow:      slow,
+		}
+		if (op == "query" || op == "row") && isSelect(sql) {
+			q.fingerprint = fingerprint(sql)
+		}
+
+		rq, _ := stmt.Context.Value(queryStatsKey).(*RequestQueries)
+		if slow {
+			where := "-"
+			if rq != nil {
+				where = rq.method + " " + rq.path
+			}
+			in.config.Logger.Printf("slow query %s (%s, %d rows) %s: %s [%d params redacted]",
+				elapsed.Round(time.Millisecond), op, stmt.RowsAffected, where, redact(sql), len(stmt.Vars))
+		}
+
+		if rq != nil {
+			rq.add(q)
+			return
+		}
+		// Outside an HTTP request (CLI, background jobs)
+		in.observe("-", q)
+	}
+}
+
+func (in *Instrumentation) observe(route string, q queryRecord) {
+	in.queries.Inc(route, q.operation, q.table)
+	in.duration.Observe(q.duration.Seconds(), route, q.operation, q.table)
+	if q.rows > 0 {
+		in.rows.Add(float64(q.rows), route, q.operation, q.table)
+	}
+	if q.failed {
+		in.errors.Inc(route, q.operation, q.table)
+	}
+	if q.slow {
+		in.slow.Inc(route, q.operation, q.table)
+	}
+}
+
+// Finish flushes a request's queries into metrics under route and reports N+1 patterns
+func (in *Instrumentation) Finish(route string, rq *RequestQueries) {
+	rq.mu.Lock()
+	records := rq.records
+	rq.records = nil
+	rq.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, q := range records {
+		in.observe(route, q)
+		if q.fingerprint != "" {
+			counts[q.fingerprint]++
+		}
+	}
+	in.perRequest.Observe(float64(len(records)), route)
+
+	if in.config.NPlusOneThreshold <= 0 {
+		return
+	}
+	for fp, n := range counts {
+		if n >= in.config.NPlusOneThreshold {
+			in.nPlusOne.Inc(route)
+			in.config.Logger.Printf("possible N+1 on %s %s: %d× %s", rq.method, route, n, fp)
+		}
+	}
+}
+
+type queryRecord struct {
+	operation   string
+	table       string
+	duration    time.Duration
+	rows        int64
+	failed      bool
+	slow        bool
+	fingerprint string
+}
+
+// RequestQueries collects the statements issued while serving one request
+type RequestQueries struct {
+	method  string
+	path    string
+	mu      sync.Mutex
+	records []queryRecord
+}
+
+func (rq *RequestQueries) add(q queryRecord) {
+	rq.mu.Lock()
+	rq.records = append(rq.records, q)
+	rq.mu.Unlock()
+}
+
+// WithQueryStats returns a context whose statements are attributed to one request
+func WithQueryStats(ctx context.Context, method, path string) (context.Context, *RequestQueries) {
+	rq := &RequestQueries{method: method, path: path}
+	return context.WithValue(ctx, queryStatsKey, rq), rq
+}
+
+var (
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	placeholder   = regexp.MustCompile(`\$\d+|\?`)
+	inList        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// redact removes inline string literals; bound parameters are never logged
+func redact(sql string) string {
+	return whitespace.ReplaceAllString(stringLiteral.ReplaceAllString(sql, "'?'"), " ")
+}
+
+// fingerprint normalises a statement so repeats with different arguments compare equal
+func fingerprint(sql string) string {
+	fp := placeholder.ReplaceAllString(redact(sql), "?")
+	return inList.ReplaceAllString(fp, "(?)")
+}
+
+func isSelect(sql string) bool {
+	sql = strings.ToUpper(strings.TrimSpace(sql))
+	return strings.HasPrefix(sql, "SELECT") || strings.HasPrefix(sql, "WITH")
+}
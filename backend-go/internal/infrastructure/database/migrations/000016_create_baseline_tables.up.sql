@@ -0,0 +1,323 @@
+-- Baseline: tables that existed before migrations were kept in this repository
+-- Databases migrated by golang-migrate are at version 16 or later and skip
+-- this file. Fresh databases, and databases created by the old startup
+-- AutoMigrate (no schema_migrations row), need these tables before 000017.
+-- Everything is IF NOT EXISTS so existing tables are adopted as they are.
+
+-- 1. Legacy tables referenced by 000017 onward
+CREATE TABLE IF NOT EXISTS tenants (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL DEFAULT '',
+    code VARCHAR(20),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS provinces (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    code VARCHAR(10),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS cities (
+    id BIGSERIAL PRIMARY KEY,
+    province_id BIGINT REFERENCES provinces(id),
+    title VARCHAR(100) NOT NULL,
+    code VARCHAR(10),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS persons (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    national_code VARCHAR(10),
+    first_name VARCHAR(255),
+    last_name VARCHAR(255),
+    birth_date DATE,
+    gender VARCHAR(10),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS family_members (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    person_id BIGINT REFERENCES persons(id),
+    national_code VARCHAR(10),
+    first_name VARCHAR(255),
+    last_name VARCHAR(255),
+    relation VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS insurances (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    code VARCHAR(50),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS policies (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    insurance_id BIGINT REFERENCES insurances(id),
+    policy_number VARCHAR(50),
+    start_date DATE,
+    end_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS item_categories (
+    id BIGSERIAL PRIMARY KEY,
+    parent_id BIGINT REFERENCES item_categories(id),
+    title VARCHAR(200) NOT NULL,
+    code VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS item_groups (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    code VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id BIGSERIAL PRIMARY KEY,
+    category_id BIGINT REFERENCES item_categories(id),
+    group_id BIGINT REFERENCES item_groups(id),
+    code VARCHAR(50),
+    title VARCHAR(255) NOT NULL,
+    type SMALLINT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 000029 adds the rest of the body site columns
+CREATE TABLE IF NOT EXISTS body_sites (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50),
+    title_fa VARCHAR(255),
+    title_en VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Centers and packages as the Go entities map them
+CREATE TABLE IF NOT EXISTS centers (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT,
+    title VARCHAR(255) NOT NULL,
+    siam_id VARCHAR(50) NOT NULL,
+    code VARCHAR(50),
+    type SMALLINT NOT NULL,
+    level BIGINT,
+    province_id BIGINT,
+    city_id BIGINT,
+    address TEXT,
+    postal_code VARCHAR(10),
+    phone VARCHAR(20),
+    fax VARCHAR(20),
+    email VARCHAR(100),
+    website VARCHAR(200),
+    owner_name VARCHAR(255),
+    manager_name VARCHAR(255),
+    manager_phone VARCHAR(20),
+    dependency_type SMALLINT,
+    payment_id VARCHAR(50),
+    account_number VARCHAR(50),
+    sheba_number VARCHAR(26),
+    economic_code VARCHAR(14),
+    national_id VARCHAR(11),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_centers_tenant_id ON centers(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_centers_deleted_at ON centers(deleted_at);
+
+CREATE TABLE IF NOT EXISTS packages (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT,
+    center_id BIGINT NOT NULL,
+    work_unit_id BIGINT,
+    title VARCHAR(255) NOT NULL,
+    letter_number VARCHAR(50),
+    letter_date TIMESTAMP WITH TIME ZONE,
+    receive_letter_date TIMESTAMP WITH TIME ZONE,
+    letter_image_url VARCHAR(500),
+    status SMALLINT NOT NULL DEFAULT 1,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_packages_tenant_id ON packages(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_packages_center_id ON packages(center_id);
+CREATE INDEX IF NOT EXISTS idx_packages_deleted_at ON packages(deleted_at);
+
+-- 2. Tables referenced by 000022-000029 but created later: by 000031
+-- (employees), 000032 (insurers, roles, users) and 000034 (claims,
+-- claim_items). These are the definitions those migrations adopt; users,
+-- roles and insurers are the same as in 000032.
+CREATE TABLE IF NOT EXISTS insurers (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    title_en VARCHAR(200),
+    code VARCHAR(20),
+    national_id VARCHAR(11),
+    economic_code VARCHAR(14),
+    phone VARCHAR(15),
+    mobile VARCHAR(15),
+    email VARCHAR(100),
+    website VARCHAR(200),
+    address TEXT,
+    postal_code VARCHAR(10),
+    is_active BOOLEAN DEFAULT TRUE,
+    logo VARCHAR(255),
+    primary_color VARCHAR(7),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50),
+    title_fa VARCHAR(100),
+    level BIGINT DEFAULT 0,
+    is_system BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    username VARCHAR(50),
+    email VARCHAR(100),
+    password_hash VARCHAR(255),
+    mobile VARCHAR(15),
+    first_name VARCHAR(100),
+    last_name VARCHAR(100),
+    national_code VARCHAR(10),
+    avatar VARCHAR(255),
+    role_id BIGINT,
+    is_active BOOLEAN DEFAULT TRUE,
+    is_email_verified BOOLEAN DEFAULT FALSE,
+    is_mobile_verified BOOLEAN DEFAULT FALSE,
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_users_role FOREIGN KEY (role_id) REFERENCES roles(id),
+    CONSTRAINT fk_users_tenant FOREIGN KEY (tenant_id) REFERENCES insurers(id)
+);
+
+-- Employees as the old startup AutoMigrate created them
+CREATE TABLE IF NOT EXISTS employees (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    parent_id BIGINT REFERENCES employees(id) ON DELETE SET NULL,
+    relation_type VARCHAR(20),
+    personnel_code VARCHAR(50),
+    national_code VARCHAR(10),
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    father_name VARCHAR(255),
+    birth_date TIMESTAMP WITH TIME ZONE,
+    gender VARCHAR(10),
+    marital_status VARCHAR(20),
+    phone VARCHAR(20),
+    mobile VARCHAR(20),
+    email VARCHAR(255),
+    address TEXT,
+    recruitment_date TIMESTAMP WITH TIME ZONE,
+    retirement_date TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN DEFAULT TRUE,
+    status VARCHAR(20) DEFAULT 'active',
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 000031 creates employees with IF NOT EXISTS, so an existing table keeps
+-- its columns; add the ones 000031 expects. The lookup tables they reference
+-- come with 000031, so 000045 adds the foreign keys.
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS relation_type_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS custom_employee_code_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS special_employee_type_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS guardianship_type_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS id_number VARCHAR(50);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS branch_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS location_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS work_location_id INT;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS account_number VARCHAR(50);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS termination_date DATE;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS priority INT DEFAULT 1;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS picture VARCHAR(255);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- AutoMigrate also created indexes under the names 000031 uses, which
+-- 000031 creates without IF NOT EXISTS; drop them and let 000031 recreate them
+DROP INDEX IF EXISTS idx_employees_tenant;
+DROP INDEX IF EXISTS idx_employees_parent;
+DROP INDEX IF EXISTS idx_employees_personnel_code;
+DROP INDEX IF EXISTS idx_employees_national_code;
+DROP INDEX IF EXISTS idx_employees_relation_type;
+DROP INDEX IF EXISTS idx_employees_temp_batch;
+DROP INDEX IF EXISTS idx_employees_temp_tenant;
+
+-- 000034 adds the rest of the claim and claim item columns
+CREATE TABLE IF NOT EXISTS claims (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    tracking_code VARCHAR(50) NOT NULL,
+    policy_member_id BIGINT NOT NULL,
+    center_id BIGINT NOT NULL,
+    claim_type SMALLINT NOT NULL,
+    status SMALLINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS claim_items (
+    id BIGSERIAL PRIMARY KEY,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
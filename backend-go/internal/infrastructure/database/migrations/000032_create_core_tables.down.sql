@@ -0,0 +1,4 @@
+-- 000032 adopts the core tables with IF NOT EXISTS, so on databases created
+-- by the old startup AutoMigrate they hold users, roles and tenant data that
+-- this migration did not create. Rolling back leaves them in place.
+SELECT 1;
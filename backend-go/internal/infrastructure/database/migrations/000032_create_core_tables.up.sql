@@ -0,0 +1,179 @@
+-- Core tables previously created by GORM AutoMigrate at startup and by ad-hoc SQL
+-- in the tenant migrator. IF NOT EXISTS lets databases created that way adopt
+-- this migration unchanged; index names and WHERE clauses match the entity tags.
+
+-- 1. Insurers (tenants)
+CREATE TABLE IF NOT EXISTS insurers (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    title_en VARCHAR(200),
+    code VARCHAR(20),
+    national_id VARCHAR(11),
+    economic_code VARCHAR(14),
+    phone VARCHAR(15),
+    mobile VARCHAR(15),
+    email VARCHAR(100),
+    website VARCHAR(200),
+    address TEXT,
+    postal_code VARCHAR(10),
+    is_active BOOLEAN DEFAULT TRUE,
+    logo VARCHAR(255),
+    primary_color VARCHAR(7),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_insurers_code ON insurers(code);
+CREATE INDEX IF NOT EXISTS idx_insurers_deleted_at ON insurers(deleted_at);
+
+-- 2. Roles & permissions
+CREATE TABLE IF NOT EXISTS roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50),
+    title_fa VARCHAR(100),
+    level BIGINT DEFAULT 0,
+    is_system BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles(name);
+CREATE INDEX IF NOT EXISTS idx_roles_deleted_at ON roles(deleted_at);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100),
+    title_fa VARCHAR(100),
+    module VARCHAR(50),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name ON permissions(name);
+CREATE INDEX IF NOT EXISTS idx_permissions_deleted_at ON permissions(deleted_at);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id BIGINT NOT NULL,
+    permission_id BIGINT NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles(id),
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions(id)
+);
+
+-- 3. Users
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    username VARCHAR(50),
+    email VARCHAR(100),
+    password_hash VARCHAR(255),
+    mobile VARCHAR(15),
+    first_name VARCHAR(100),
+    last_name VARCHAR(100),
+    national_code VARCHAR(10),
+    avatar VARCHAR(255),
+    role_id BIGINT,
+    is_active BOOLEAN DEFAULT TRUE,
+    is_email_verified BOOLEAN DEFAULT FALSE,
+    is_mobile_verified BOOLEAN DEFAULT FALSE,
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_users_role FOREIGN KEY (role_id) REFERENCES roles(id),
+    CONSTRAINT fk_users_tenant FOREIGN KEY (tenant_id) REFERENCES insurers(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant ON users(tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token VARCHAR(500),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    is_revoked BOOLEAN DEFAULT FALSE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_user_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_refresh_tokens_user_id ON user_refresh_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_refresh_tokens_token ON user_refresh_tokens(token);
+CREATE INDEX IF NOT EXISTS idx_user_refresh_tokens_deleted_at ON user_refresh_tokens(deleted_at);
+
+-- 4. Employees: soft delete index declared by BaseModel, missing from 000031
+CREATE INDEX IF NOT EXISTS idx_employees_deleted_at ON employees(deleted_at);
+
+-- 5. Per-tenant settings, sequences and reason codes (seeded by tenant migrations)
+CREATE TABLE IF NOT EXISTS tenant_settings (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    setting_key VARCHAR(100) NOT NULL,
+    setting_value TEXT,
+    setting_type VARCHAR(50) DEFAULT 'string',
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(tenant_id, setting_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_settings_tenant ON tenant_settings(tenant_id);
+
+CREATE TABLE IF NOT EXISTS tenant_sequences (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    sequence_name VARCHAR(100) NOT NULL,
+    current_value BIGINT DEFAULT 0,
+    prefix VARCHAR(20),
+    suffix VARCHAR(20),
+    pad_length INTEGER DEFAULT 6,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(tenant_id, sequence_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_sequences_tenant ON tenant_sequences(tenant_id);
+
+CREATE TABLE IF NOT EXISTS reason_codes (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    code VARCHAR(20) NOT NULL,
+    title_fa VARCHAR(255) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reason_codes_tenant_code ON reason_codes(tenant_id, code) WHERE deleted_at IS NULL;
+
+-- 6. Tenant migration history (migration.MigrationRecord)
+CREATE TABLE IF NOT EXISTS tenant_migrations (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT,
+    version VARCHAR(100),
+    name VARCHAR(255),
+    description VARCHAR(1000),
+    status VARCHAR(50),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    error VARCHAR(2000),
+    checksum VARCHAR(64),
+    executed_by VARCHAR(100),
+    batch_no BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_migrations_tenant_id ON tenant_migrations(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_tenant_migrations_version ON tenant_migrations(version);
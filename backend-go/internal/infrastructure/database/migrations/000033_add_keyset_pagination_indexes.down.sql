@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_employee_import_history_tenant_created_id;
+DROP INDEX IF EXISTS idx_employees_tenant_created_id;
+DROP INDEX IF EXISTS idx_claims_tenant_created_id;
@@ -0,0 +1,12 @@
+-- Keyset (cursor) pagination indexes
+-- List pages are ordered by (created_at, id) within a tenant; these indexes
+-- serve both directions without a sort step.
+
+CREATE INDEX IF NOT EXISTS idx_claims_tenant_created_id
+    ON claims(tenant_id, created_at, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_employees_tenant_created_id
+    ON employees(tenant_id, created_at, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_employee_import_history_tenant_created_id
+    ON employee_import_history(tenant_id, created_at, id);
@@ -0,0 +1,16 @@
+-- claims and claim_items predate this migration on existing databases, so only
+-- the objects it introduced are dropped.
+DROP TABLE IF EXISTS claim_diagnoses CASCADE;
+
+DROP INDEX IF EXISTS idx_claim_items_deleted_at;
+DROP INDEX IF EXISTS idx_claim_items_claim;
+DROP INDEX IF EXISTS idx_claim_items_tenant;
+
+DROP INDEX IF EXISTS idx_claims_service_date;
+DROP INDEX IF EXISTS idx_claims_handler;
+DROP INDEX IF EXISTS idx_claims_status;
+DROP INDEX IF EXISTS idx_claims_center;
+DROP INDEX IF EXISTS idx_claims_package;
+DROP INDEX IF EXISTS idx_claims_policy_member;
+DROP INDEX IF EXISTS idx_claims_hid;
+DROP INDEX IF EXISTS idx_claims_tenant_tracking_code;
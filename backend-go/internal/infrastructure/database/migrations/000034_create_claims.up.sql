@@ -0,0 +1,107 @@
+-- Claims, claim items and diagnoses
+-- Databases created before this migration already have claims and claim_items;
+-- IF NOT EXISTS adopts them and the ALTERs add columns the Go entities need.
+-- Index names and WHERE clauses match the entity tags.
+
+-- 1. Claims (پرونده خسارت)
+CREATE TABLE IF NOT EXISTS claims (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    tracking_code VARCHAR(50) NOT NULL,
+    policy_member_id BIGINT NOT NULL,
+    center_id BIGINT NOT NULL,
+    claim_type SMALLINT NOT NULL,
+    status SMALLINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS created_by BIGINT DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS updated_by BIGINT DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS hid VARCHAR(50);
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS mrn VARCHAR(50);
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS package_id BIGINT;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS handler_user_id BIGINT;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS admission_type SMALLINT;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS admission_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS discharge_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS service_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS request_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS basic_ins_share BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS approved_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS deduction BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS deduction_reason TEXT;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS notes TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_claims_tenant_id ON claims(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claims_deleted_at ON claims(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_claims_tenant_tracking_code ON claims(tenant_id, tracking_code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_hid ON claims(hid) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_policy_member ON claims(policy_member_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_package ON claims(package_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_center ON claims(center_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_status ON claims(status) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_handler ON claims(handler_user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claims_service_date ON claims(service_date) WHERE deleted_at IS NULL;
+
+-- 2. Claim items (اقلام خدمت/دارو)
+CREATE TABLE IF NOT EXISTS claim_items (
+    id BIGSERIAL PRIMARY KEY,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS tenant_id BIGINT;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS item_id BIGINT;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS prescription_item_id BIGINT;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS instruction_id BIGINT;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS body_site_id BIGINT;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS service_code VARCHAR(50);
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS title VARCHAR(255);
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS service_date TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS dosage VARCHAR(100);
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS frequency VARCHAR(100);
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS duration VARCHAR(100);
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS quantity INT NOT NULL DEFAULT 1;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS unit_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS request_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS basic_ins_share BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS confirmed_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS deduction BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS notes TEXT;
+
+-- Backfill tenant from the parent claim for pre-existing rows
+UPDATE claim_items ci SET tenant_id = c.tenant_id FROM claims c WHERE ci.claim_id = c.id AND ci.tenant_id IS NULL;
+ALTER TABLE claim_items ALTER COLUMN tenant_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_claim_items_tenant ON claim_items(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claim_items_claim ON claim_items(claim_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claim_items_deleted_at ON claim_items(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_claim_item_item ON claim_items(item_id);
+CREATE INDEX IF NOT EXISTS idx_claim_item_prescription_item ON claim_items(prescription_item_id);
+CREATE INDEX IF NOT EXISTS idx_claim_item_instruction ON claim_items(instruction_id);
+CREATE INDEX IF NOT EXISTS idx_claim_item_body_site ON claim_items(body_site_id);
+
+-- 3. Claim diagnoses (تشخیص‌ها)
+CREATE TABLE IF NOT EXISTS claim_diagnoses (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    icd10_code VARCHAR(20) NOT NULL,
+    title VARCHAR(255),
+    is_primary BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_diagnoses_tenant ON claim_diagnoses(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claim_diagnoses_claim ON claim_diagnoses(claim_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_claim_diagnoses_deleted_at ON claim_diagnoses(deleted_at);
+
+COMMENT ON COLUMN claims.hid IS 'Health ID (سپاس)';
+COMMENT ON COLUMN claims.mrn IS 'Medical record number at the center';
+COMMENT ON COLUMN claims.basic_ins_share IS 'Share paid by basic insurance (Tamin/Salamat), in Rials';
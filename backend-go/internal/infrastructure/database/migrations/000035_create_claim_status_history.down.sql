@@ -0,0 +1 @@
+DROP TABLE IF EXISTS claim_status_history CASCADE;
@@ -0,0 +1,20 @@
+-- Claim status history
+-- One row per state machine transition of a claim (submit, examine, approve,
+-- return, ...). Rows are never updated or deleted by the application.
+
+CREATE TABLE IF NOT EXISTS claim_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    action VARCHAR(30) NOT NULL,
+    from_status SMALLINT NOT NULL,
+    to_status SMALLINT NOT NULL,
+    actor_id BIGINT NOT NULL DEFAULT 0,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_status_history_tenant ON claim_status_history(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claim_status_history_claim ON claim_status_history(claim_id, created_at);
+
+COMMENT ON COLUMN claim_status_history.actor_id IS 'User who made the transition; 0 for system actions';
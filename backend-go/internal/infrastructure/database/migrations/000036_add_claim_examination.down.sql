@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS claim_deduction_reasons CASCADE;
+
+DROP INDEX IF EXISTS idx_reason_codes_deleted_at;
+
+ALTER TABLE claim_items DROP COLUMN IF EXISTS examiner_notes;
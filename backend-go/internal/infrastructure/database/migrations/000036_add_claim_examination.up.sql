@@ -0,0 +1,23 @@
+-- Line-item claim examination
+-- Examiners confirm a price per claim item and justify every deduction with
+-- one or more tenant reason codes (reason_codes, seeded by tenant migrations).
+
+ALTER TABLE claim_items ADD COLUMN IF NOT EXISTS examiner_notes TEXT;
+
+-- reason_codes has soft deletes (entity.ReasonCode embeds BaseModel)
+CREATE INDEX IF NOT EXISTS idx_reason_codes_deleted_at ON reason_codes(deleted_at);
+
+-- Reason codes behind claim and item deductions; claim_item_id is NULL for
+-- claim-level deductions. Rows are replaced on every examination.
+CREATE TABLE IF NOT EXISTS claim_deduction_reasons (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    claim_item_id BIGINT REFERENCES claim_items(id) ON DELETE CASCADE,
+    reason_code_id BIGINT NOT NULL REFERENCES reason_codes(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_deduction_reasons_tenant ON claim_deduction_reasons(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claim_deduction_reasons_claim ON claim_deduction_reasons(claim_id);
+CREATE INDEX IF NOT EXISTS idx_claim_deduction_reasons_item ON claim_deduction_reasons(claim_item_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rule_execution_logs CASCADE;
+DROP TABLE IF EXISTS rules CASCADE;
@@ -0,0 +1,50 @@
+-- Adjudication rules
+-- Each tenant keeps versioned rule sets written in GRL (grule-rule-engine).
+-- The active version with the latest effective_from not in the future is
+-- evaluated against claims on submission and examination; every evaluation
+-- is logged with the rules that fired.
+
+CREATE TABLE IF NOT EXISTS rules (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    source TEXT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    activated_by BIGINT,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rules_tenant_version ON rules(tenant_id, version) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_rules_tenant_status ON rules(tenant_id, status) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_rules_deleted_at ON rules(deleted_at);
+
+-- One row per evaluation of a claim; rows are never updated or deleted by
+-- the application
+CREATE TABLE IF NOT EXISTS rule_execution_logs (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    rule_set_id BIGINT NOT NULL REFERENCES rules(id),
+    version INTEGER NOT NULL,
+    stage VARCHAR(20) NOT NULL,
+    fired_rules JSONB,
+    deduction BIGINT NOT NULL DEFAULT 0,
+    deductions JSONB,
+    flags JSONB,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_execution_logs_tenant ON rule_execution_logs(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_rule_execution_logs_claim ON rule_execution_logs(claim_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_rule_execution_logs_rule_set ON rule_execution_logs(rule_set_id);
@@ -0,0 +1,19 @@
+-- Rule set test cases
+-- A sample claim and the outcome expected from a rule set version. Every
+-- test of a draft must pass before the draft can be promoted.
+
+CREATE TABLE IF NOT EXISTS rule_tests (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    rule_set_id BIGINT NOT NULL REFERENCES rules(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    claim JSONB NOT NULL,
+    expected JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_tests_tenant ON rule_tests(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_rule_tests_rule_set ON rule_tests(rule_set_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_rule_tests_deleted_at ON rule_tests(deleted_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rule_simulation_claims CASCADE;
+DROP TABLE IF EXISTS rule_simulations CASCADE;
@@ -0,0 +1,53 @@
+-- Rule simulations
+-- Backtests of a candidate rule set over historical claims against the rule
+-- set in effect. Simulations only write these tables; claims and rule
+-- execution logs are left untouched.
+
+CREATE TABLE IF NOT EXISTS rule_simulations (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    rule_set_id BIGINT NOT NULL REFERENCES rules(id),
+    baseline_id BIGINT REFERENCES rules(id),
+    service_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    service_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    claim_types JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total_claims BIGINT NOT NULL DEFAULT 0,
+    processed BIGINT NOT NULL DEFAULT 0,
+    summary JSONB,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_simulations_tenant ON rule_simulations(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_rule_simulations_rule_set ON rule_simulations(rule_set_id);
+CREATE INDEX IF NOT EXISTS idx_rule_simulations_deleted_at ON rule_simulations(deleted_at);
+
+CREATE TABLE IF NOT EXISTS rule_simulation_claims (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    simulation_id BIGINT NOT NULL REFERENCES rule_simulations(id) ON DELETE CASCADE,
+    claim_id BIGINT NOT NULL,
+    tracking_code VARCHAR(50),
+    claim_type SMALLINT,
+    stage VARCHAR(20),
+    amount BIGINT,
+    historical_approved BIGINT,
+    baseline_deduction BIGINT,
+    candidate_deduction BIGINT,
+    baseline_approved BIGINT,
+    candidate_approved BIGINT,
+    approved_diff BIGINT,
+    baseline_rules JSONB,
+    candidate_rules JSONB,
+    changed BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_simulation_claims_tenant ON rule_simulation_claims(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_rule_simulation_claims_simulation ON rule_simulation_claims(simulation_id);
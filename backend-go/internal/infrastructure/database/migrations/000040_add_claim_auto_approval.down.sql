@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_claims_sample_review;
+
+ALTER TABLE claims DROP COLUMN IF EXISTS review_notes;
+ALTER TABLE claims DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE claims DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE claims DROP COLUMN IF EXISTS sample_review;
+ALTER TABLE claims DROP COLUMN IF EXISTS auto_approved;
@@ -0,0 +1,13 @@
+-- Automatic approval of low-value claims
+-- Claims under the tenant's claim_auto_approve_threshold (tenant_settings)
+-- that pass the rules and duplicate checks are examined and approved by the
+-- system actor (actor_id 0 in claim_status_history). A random sample of them
+-- is flagged for later review by a person.
+
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS auto_approved BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS sample_review VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS reviewed_by BIGINT;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS review_notes TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_claims_sample_review ON claims(sample_review) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE claims DROP COLUMN IF EXISTS assigned_at;
+
+DROP TABLE IF EXISTS claim_assignments CASCADE;
+DROP TABLE IF EXISTS examiner_profiles CASCADE;
+DROP TABLE IF EXISTS work_queues CASCADE;
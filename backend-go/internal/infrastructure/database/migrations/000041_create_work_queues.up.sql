@@ -0,0 +1,59 @@
+-- Examiner work queues
+-- Each claim type has a queue served by examiners of one role
+-- (claim_examiner, or drug_examiner for pharmacy claims). Claim types without
+-- a row use that default in pull mode. Claims are assigned through
+-- claims.handler_user_id, by examiners pulling the next claim or by the
+-- system pushing it to the most skilled available examiner with the least
+-- load; every assignment is recorded.
+
+CREATE TABLE IF NOT EXISTS work_queues (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_type SMALLINT NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    mode VARCHAR(10) NOT NULL DEFAULT 'pull',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_work_queues_tenant_type ON work_queues(tenant_id, claim_type) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_work_queues_deleted_at ON work_queues(deleted_at);
+
+-- Skills (claim type -> level 1..5), capacity and availability of examiners
+CREATE TABLE IF NOT EXISTS examiner_profiles (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    skills JSONB,
+    capacity INTEGER NOT NULL DEFAULT 20,
+    available BOOLEAN NOT NULL DEFAULT true,
+    away_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_examiner_profiles_tenant_user ON examiner_profiles(tenant_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_examiner_profiles_deleted_at ON examiner_profiles(deleted_at);
+
+-- One row per assignment, unassignment or reassignment; never updated
+CREATE TABLE IF NOT EXISTS claim_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    user_id BIGINT,
+    previous_user_id BIGINT,
+    mode VARCHAR(20) NOT NULL,
+    assigned_by BIGINT NOT NULL DEFAULT 0,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_assignments_tenant ON claim_assignments(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_claim_assignments_claim ON claim_assignments(claim_id, created_at);
+
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP WITH TIME ZONE;
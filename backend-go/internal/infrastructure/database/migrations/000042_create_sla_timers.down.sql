@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS sla_breaches CASCADE;
+DROP TABLE IF EXISTS holidays CASCADE;
+DROP TABLE IF EXISTS sla_policies CASCADE;
+
+DROP INDEX IF EXISTS idx_claims_status_changed;
+ALTER TABLE claims DROP COLUMN IF EXISTS status_changed_at;
@@ -0,0 +1,75 @@
+-- SLA timers for claims waiting in a status
+-- A policy caps, in business hours, how long claims of a type may stay in a
+-- status (claim_type 0 covers all types without a policy of their own).
+-- Business hours come from the tenant's work hours (tenant_settings
+-- sla_work_*) less its holidays. A background checker records a breach once
+-- per stay in a status and escalates it to the policy's role; the breach is
+-- resolved when the claim leaves the status.
+
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE claims SET status_changed_at = COALESCE(
+    (SELECT MAX(h.created_at) FROM claim_status_history h
+     WHERE h.claim_id = claims.id AND h.to_status = claims.status),
+    claims.created_at,
+    NOW()
+)
+WHERE status_changed_at IS NULL;
+
+ALTER TABLE claims ALTER COLUMN status_changed_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE claims ALTER COLUMN status_changed_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_claims_status_changed ON claims(status_changed_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS sla_policies (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    status SMALLINT NOT NULL,
+    claim_type SMALLINT NOT NULL DEFAULT 0,
+    target_hours INTEGER NOT NULL,
+    escalate_to VARCHAR(50) NOT NULL DEFAULT 'supervisor',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_policies_tenant_status_type ON sla_policies(tenant_id, status, claim_type) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sla_policies_deleted_at ON sla_policies(deleted_at);
+
+CREATE TABLE IF NOT EXISTS holidays (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    date DATE NOT NULL,
+    title VARCHAR(200),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holidays_tenant_date ON holidays(tenant_id, date) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_holidays_deleted_at ON holidays(deleted_at);
+
+-- One row per claim stay in a status that outlived its policy
+CREATE TABLE IF NOT EXISTS sla_breaches (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    claim_id BIGINT NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    policy_id BIGINT NOT NULL,
+    status SMALLINT NOT NULL,
+    claim_type SMALLINT NOT NULL,
+    entered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    target_hours INTEGER NOT NULL,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    breached_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    handler_user_id BIGINT,
+    escalated_to VARCHAR(50) NOT NULL,
+    acknowledged_by BIGINT,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sla_breaches_tenant ON sla_breaches(tenant_id, breached_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sla_breaches_claim_entry ON sla_breaches(claim_id, status, entered_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS attachments CASCADE;
+DROP TABLE IF EXISTS attachment_types CASCADE;
@@ -0,0 +1,49 @@
+-- Attachments of claims and packages
+-- Files live in the blob store (local filesystem or S3-compatible) under
+-- storage_key; rows keep the checksum, sniffed content type and scan result.
+-- Attachment types say which documents each claim type (0 for all) or
+-- packages take; tenants without rows use the built-in defaults.
+
+CREATE TABLE IF NOT EXISTS attachment_types (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    owner VARCHAR(20) NOT NULL,
+    claim_type SMALLINT NOT NULL DEFAULT 0,
+    code VARCHAR(50) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT false,
+    max_size BIGINT NOT NULL DEFAULT 0,
+    mime_types VARCHAR(500),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachment_types_tenant_code ON attachment_types(tenant_id, owner, claim_type, code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_attachment_types_deleted_at ON attachment_types(deleted_at);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    owner VARCHAR(20) NOT NULL,
+    owner_id BIGINT NOT NULL,
+    type_code VARCHAR(50) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    storage_key VARCHAR(300) NOT NULL,
+    scan_status VARCHAR(20) NOT NULL,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments(tenant_id, owner, owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachments_storage_key ON attachments(storage_key);
+CREATE INDEX IF NOT EXISTS idx_attachments_deleted_at ON attachments(deleted_at);
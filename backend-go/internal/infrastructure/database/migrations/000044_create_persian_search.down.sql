@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_claims_tracking_code_trgm;
+
+DROP INDEX IF EXISTS idx_centers_search_fts;
+DROP INDEX IF EXISTS idx_centers_search_trgm;
+ALTER TABLE centers DROP COLUMN IF EXISTS search_text;
+
+DROP INDEX IF EXISTS idx_employees_search_fts;
+DROP INDEX IF EXISTS idx_employees_search_trgm;
+ALTER TABLE employees DROP COLUMN IF EXISTS search_text;
+
+DROP FUNCTION IF EXISTS normalize_fa(TEXT);
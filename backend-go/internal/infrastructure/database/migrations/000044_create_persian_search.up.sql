@@ -0,0 +1,52 @@
+-- Persian-aware search over members, centers and claims
+-- normalize_fa folds text the way persian.SearchKey does in the application:
+-- Arabic yeh/kaf to Persian, Persian and Arabic digits to ASCII, alef and heh
+-- variants merged, zero-width characters, tatweel and diacritics dropped,
+-- spaces collapsed and lower case. The two must be changed together.
+-- search_text columns hold the folded text, indexed for full-text (simple
+-- configuration, no stemming) and trigram matching.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE OR REPLACE FUNCTION normalize_fa(input TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT lower(btrim(regexp_replace(
+        translate(input,
+            'يىك۰۱۲۳۴۵۶۷۸۹٠١٢٣٤٥٦٧٨٩ةۀأإآٱ' || U&'\200C\200B\200D\FEFF\0640\064B\064C\064D\064E\064F\0650\0651\0652\0670',
+            'ییک01234567890123456789ههاااا'),
+        '\s+', ' ', 'g')))
+$$;
+
+-- Existing names in standard spelling (persian.Canonical), as new writes are
+UPDATE employees SET
+    first_name = translate(first_name, 'يىك', 'ییک'),
+    last_name = translate(last_name, 'يىك', 'ییک'),
+    father_name = translate(father_name, 'يىك', 'ییک'),
+    personnel_code = translate(personnel_code, '۰۱۲۳۴۵۶۷۸۹٠١٢٣٤٥٦٧٨٩', '01234567890123456789'),
+    national_code = translate(national_code, '۰۱۲۳۴۵۶۷۸۹٠١٢٣٤٥٦٧٨٩', '01234567890123456789')
+WHERE first_name ~ '[يىك]' OR last_name ~ '[يىك]' OR father_name ~ '[يىك]'
+   OR personnel_code ~ '[۰-۹٠-٩]' OR national_code ~ '[۰-۹٠-٩]';
+
+UPDATE centers SET
+    title = translate(title, 'يىك', 'ییک'),
+    owner_name = translate(owner_name, 'يىك', 'ییک'),
+    manager_name = translate(manager_name, 'يىك', 'ییک')
+WHERE title ~ '[يىك]' OR owner_name ~ '[يىك]' OR manager_name ~ '[يىك]';
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    normalize_fa(coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' ||
+                 coalesce(personnel_code, '') || ' ' || coalesce(national_code, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_employees_search_trgm ON employees USING gin (search_text gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_employees_search_fts ON employees USING gin (to_tsvector('simple', search_text)) WHERE deleted_at IS NULL;
+
+ALTER TABLE centers ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    normalize_fa(coalesce(title, '') || ' ' || coalesce(code, '') || ' ' || coalesce(siam_id, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_centers_search_trgm ON centers USING gin (search_text gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_centers_search_fts ON centers USING gin (to_tsvector('simple', search_text)) WHERE deleted_at IS NULL;
+
+-- Tracking codes are searched by prefix and fragment
+CREATE INDEX IF NOT EXISTS idx_claims_tracking_code_trgm ON claims USING gin (tracking_code gin_trgm_ops) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- On databases created by 000031 these foreign keys come from 000031 itself,
+-- so rolling back leaves them in place.
+SELECT 1;
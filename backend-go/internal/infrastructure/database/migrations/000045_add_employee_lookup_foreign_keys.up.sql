@@ -0,0 +1,19 @@
+-- Foreign keys from employees to the lookup tables of 000031. Databases
+-- created by 000031 already have them; on those where 000016 added the
+-- columns they are missing. Dropping first replaces them with the same
+-- definition in both cases.
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_relation_type_id_fkey;
+ALTER TABLE employees ADD CONSTRAINT employees_relation_type_id_fkey
+    FOREIGN KEY (relation_type_id) REFERENCES relation_types(id);
+
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_custom_employee_code_id_fkey;
+ALTER TABLE employees ADD CONSTRAINT employees_custom_employee_code_id_fkey
+    FOREIGN KEY (custom_employee_code_id) REFERENCES custom_employee_codes(id);
+
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_special_employee_type_id_fkey;
+ALTER TABLE employees ADD CONSTRAINT employees_special_employee_type_id_fkey
+    FOREIGN KEY (special_employee_type_id) REFERENCES special_employee_types(id);
+
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_guardianship_type_id_fkey;
+ALTER TABLE employees ADD CONSTRAINT employees_guardianship_type_id_fkey
+    FOREIGN KEY (guardianship_type_id) REFERENCES guardianship_types(id);
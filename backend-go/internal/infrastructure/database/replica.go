@@ -0,0 +1,307 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Read routing: queries only go to a replica when their context was prepared
+// by TrackWrites (the HTTP middleware does this per request), the request has
+// not written yet, and a replica is within the configured lag. Everything else
+// (writes, transactions, locking reads, CLI and background work) uses the primary.
+
+// replicaLagQuery returns replication lag in seconds; 0 when fully replayed
+const replicaLagQuery = `
+SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+type ctxKey int
+
+const (
+	primaryKey ctxKey = iota
+	sessionKey
+	queryStatsKey
+)
+
+// readSession records whether a request has written to the primary
+type readSession struct {
+	wrote atomic.Bool
+}
+
+// TrackWrites returns a context whose reads may use replicas until the first write
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey, &readSession{})
+}
+
+// WithPrimary returns a context whose queries always use the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// usePrimary reports whether reads made with ctx must stay on the primary
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return true
+	}
+	if forced, _ := ctx.Value(primaryKey).(bool); forced {
+		return true
+	}
+	session, _ := ctx.Value(sessionKey).(*readSession)
+	return session == nil || session.wrote.Load()
+}
+
+// ReplicaStatus reports the health of one read replica
+type ReplicaStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Lag     time.Duration `json:"lag"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type replica struct {
+	name string
+	db   *sql.DB
+
+	mu     sync.RWMutex
+	status ReplicaStatus
+}
+
+func (r *replica) healthy() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status.Healthy
+}
+
+// replicaSet routes reads to healthy replicas and tracks their lag
+type replicaSet struct {
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint32
+	stop     chan struct{}
+}
+
+func newReplicaSet(cfg *config.DatabaseConfig) (*replicaSet, error) {
+	set := &replicaSet{
+		maxLag: cfg.ReplicaMaxLag,
+		stop:   make(chan struct{}),
+	}
+
+	for i, dsn := range cfg.ReplicaDSNs {
+		gdb, err := gorm.Open(postgres.Open(strings.TrimSpace(dsn)), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("failed to connect to replica %d: %w", i+1, err)
+		}
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("failed to get sql.DB for replica %d: %w", i+1, err)
+		}
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
+
+		name := fmt.Sprintf("replica-%d", i+1)
+		set.replicas = append(set.replicas, &replica{
+			name:   name,
+			db:     sqlDB,
+			status: ReplicaStatus{Name: name},
+		})
+	}
+
+	set.check()
+	go set.run(cfg.ReplicaCheckInterval)
+	return set, nil
+}
+
+// run re-checks replica lag until close
+func (s *replicaSet) run(interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.check()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *replicaSet) check() {
+	for _, r := range s.replicas {
+		status := ReplicaStatus{Name: r.name}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		var seconds float64
+		err := r.db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds)
+		cancel()
+
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Lag = time.Duration(seconds * float64(time.Second))
+			status.Healthy = status.Lag <= s.maxLag
+			if !status.Healthy {
+				status.Error = fmt.Sprintf("lag %s exceeds %s", status.Lag.Round(time.Millisecond), s.maxLag)
+			}
+		}
+
+		r.mu.Lock()
+		if r.status.Healthy != status.Healthy {
+			log.Printf("database: %s healthy=%t %s", r.name, status.Healthy, status.Error)
+		}
+		r.status = status
+		r.mu.Unlock()
+	}
+}
+
+// pick returns the next healthy replica round-robin, or nil
+func (s *replicaSet) pick() *replica {
+	n := len(s.replicas)
+	start := int(s.next.Add(1))
+	for i := 0; i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		if r.healthy() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *replicaSet) statuses() []ReplicaStatus {
+	result := make([]ReplicaStatus, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		r.mu.RLock()
+		result = append(result, r.status)
+		r.mu.RUnlock()
+	}
+	return result
+}
+
+func (s *replicaSet) close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	for _, r := range s.replicas {
+		r.db.Close()
+	}
+}
+
+// Name implements gorm.Plugin
+func (s *replicaSet) Name() string {
+	return "tpa:replicas"
+}
+
+// Initialize implements gorm.Plugin by hooking read and write callbacks
+func (s *replicaSet) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("tpa:replicas:route", s.route); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tpa:replicas:route", s.route); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("tpa:replicas:wrote", markWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("tpa:replicas:wrote", markWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("tpa:replicas:wrote", markWrite); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("tpa:replicas:wrote", markWrite)
+}
+
+// route points a read statement at a replica when it is safe to do so
+func (s *replicaSet) route(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || usePrimary(stmt.Context) {
+		return
+	}
+	// Transactions and locking reads stay on the primary
+	if _, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return
+	}
+	// Raw statements routed through Row/Scan may still modify or lock rows
+	if sql := stmt.SQL.String(); strings.TrimSpace(sql) != "" && !readOnlySQL(sql) {
+		return
+	}
+
+	if r := s.pick(); r != nil {
+		stmt.ConnPool = r.db
+	}
+}
+
+// writeKeyword matches row-modifying statements, including those inside a
+// CTE, and locking clauses (FOR UPDATE and FOR NO KEY UPDATE through UPDATE)
+var writeKeyword = regexp.MustCompile(`\b(INSERT|UPDATE|DELETE|MERGE|FOR\s+(KEY\s+)?SHARE)\b`)
+
+// readOnlySQL reports whether a raw statement is a SELECT, or a WITH query,
+// that neither modifies nor locks rows. A data-modifying CTE such as
+// WITH moved AS (DELETE ... RETURNING *) SELECT ... starts with WITH too, so
+// the whole statement is scanned; a keyword in a literal or identifier only
+// keeps the read on the primary.
+func readOnlySQL(sql string) bool {
+	sql = strings.ToUpper(strings.TrimSpace(sql))
+	if !strings.HasPrefix(sql, "SELECT") && !strings.HasPrefix(sql, "WITH") {
+		return false
+	}
+	return !writeKeyword.MatchString(sql)
+}
+
+// markWrite pins the rest of the request to the primary after a write
+func markWrite(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	if session, ok := db.Statement.Context.Value(sessionKey).(*readSession); ok {
+		session.wrote.Store(true)
+	}
+}
+
+// ReplicaStatus returns the health of configured read replicas
+func (db *Database) ReplicaStatus() []ReplicaStatus {
+	if db.replicas == nil {
+		return nil
+	}
+	return db.replicas.statuses()
+}
+
+// ReplicaHealth returns an error naming unhealthy replicas, from the last lag check
+func (db *Database) ReplicaHealth(ctx context.Context) error {
+	var unhealthy []string
+	for _, status := range db.ReplicaStatus() {
+		if !status.Healthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: %s", status.Name, status.Error))
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("reads fall back to primary; %s", strings.Join(unhealthy, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import "testing"
+
+func TestReadOnlySQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{name: "select", sql: "SELECT id, updated_at FROM claims WHERE tenant_id = $1", want: true},
+		{name: "leading whitespace and lower case", sql: "\n\tselect count(*) from claims", want: true},
+		{name: "read-only cte", sql: "WITH recent AS (SELECT * FROM claims) SELECT * FROM recent", want: true},
+		{name: "insert", sql: "INSERT INTO claims (id) VALUES ($1)"},
+		{name: "cte with delete", sql: "WITH moved AS (DELETE FROM claims WHERE id = $1 RETURNING *) SELECT * FROM moved"},
+		{name: "cte with update", sql: "with changed as (update claims set status = 2 returning id) select id from changed"},
+		{name: "cte with insert", sql: "WITH added AS (INSERT INTO claims (id) VALUES (1) RETURNING id) SELECT id FROM added"},
+		{name: "for update", sql: "SELECT * FROM claims WHERE id = $1 FOR UPDATE"},
+		{name: "for no key update", sql: "SELECT * FROM claims FOR NO KEY UPDATE"},
+		{name: "for share", sql: "SELECT * FROM claims FOR  SHARE"},
+		{name: "for key share", sql: "SELECT * FROM claims FOR KEY SHARE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readOnlySQL(tt.sql); got != tt.want {
+				t.Errorf("readOnlySQL(%q) = %v, want %v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Schema migrations are the numbered SQL files in migrations/. The tracking
+// table is compatible with golang-migrate (a single version row plus a dirty
+// flag), so databases migrated with that tool are picked up as-is. Versions
+// before 000017 were applied before the files were kept in this repository;
+// such databases are at version 16 or later. For the rest, fresh databases
+// and those created by the old startup AutoMigrate, 000016 creates the tables
+// that predate the repository, and 000016, 000032 and 000034 adopt tables
+// that already exist.
+
+//go:embed migrations/*.sql
+var schemaFiles embed.FS
+
+// schemaLockKey serialises schema migrations across processes ("TPS0")
+const schemaLockKey = 0x54505330
+
+var (
+	// ErrSchemaDirty is returned when a previous migration failed half-way
+	ErrSchemaDirty = errors.New("schema is dirty, fix the failed migration and reset schema_migrations")
+	// ErrSchemaOutdated is returned when the database is behind the embedded migrations
+	ErrSchemaOutdated = errors.New("schema is out of date, run `tpa migrate up`")
+)
+
+// SchemaMigration is one numbered SQL migration
+type SchemaMigration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// IndexIssue describes an entity index that does not match the database
+type IndexIssue struct {
+	Table   string `json:"table"`
+	Index   string `json:"index"`
+	Problem string `json:"problem"`
+}
+
+func (i IndexIssue) String() string {
+	return fmt.Sprintf("%s.%s: %s", i.Table, i.Index, i.Problem)
+}
+
+// SchemaReport is the result of VerifySchema
+type SchemaReport struct {
+	Version     uint         `json:"version"`
+	Latest      uint         `json:"latest"`
+	Dirty       bool         `json:"dirty"`
+	Pending     int          `json:"pending"`
+	IndexIssues []IndexIssue `json:"index_issues,omitempty"`
+}
+
+// schemaModels are the entities whose index tags are checked against the database
+var schemaModels = []interface{}{
+	&entity.Insurer{},
+	&entity.Employee{},
+	&entity.Role{},
+	&entity.Permission{},
+	&entity.User{},
+	&entity.UserRefreshToken{},
+	&entity.Claim{},
+	&entity.ClaimItem{},
+	&entity.ClaimDiagnosis{},
+	&entity.ClaimStatusHistory{},
+	&entity.ClaimDeductionReason{},
+	&entity.ReasonCode{},
+	&entity.RuleSet{},
+	&entity.RuleExecutionLog{},
+	&entity.RuleTest{},
+	&entity.RuleSimulation{},
+	&entity.RuleSimulationClaim{},
+	&entity.WorkQueue{},
+	&entity.ExaminerProfile{},
+	&entity.ClaimAssignment{},
+	&entity.SLAPolicy{},
+	&entity.Holiday{},
+	&entity.SLABreach{},
+	&entity.AttachmentType{},
+	&entity.Attachment{},
+}
+
+// LoadSchemaMigrations returns the embedded migrations ordered by version
+func LoadSchemaMigrations() ([]SchemaMigration, error) {
+	entries, err := fs.ReadDir(schemaFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*SchemaMigration)
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		num, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", name, err)
+		}
+
+		body, err := schemaFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[uint(version)]
+		if mig == nil {
+			mig = &SchemaMigration{Version: uint(version), Name: title}
+			byVersion[uint(version)] = mig
+		}
+		if direction == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]SchemaMigration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %06d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// SchemaVersion returns the applied schema version (0 if none) and the dirty flag
+func (db *Database) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return 0, false, err
+	}
+	return schemaVersion(ctx, sqlDB)
+}
+
+// PendingSchemaMigrations returns embedded migrations newer than the applied version
+func (db *Database) PendingSchemaMigrations(ctx context.Context) ([]SchemaMigration, error) {
+	version, _, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadSchemaMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return pendingAfter(migrations, version), nil
+}
+
+// MigrateSchema applies every pending migration, each in its own transaction
+func (db *Database) MigrateSchema(ctx context.Context) ([]SchemaMigration, error) {
+	migrations, err := LoadSchemaMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []SchemaMigration
+	err = db.withSchemaLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrSchemaDirty, version)
+		}
+
+		for _, mig := range pendingAfter(migrations, version) {
+			if err := applySchemaMigration(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// RollbackSchema runs down migrations until the schema is at target (0 rolls back everything)
+func (db *Database) RollbackSchema(ctx context.Context, target uint) ([]SchemaMigration, error) {
+	migrations, err := LoadSchemaMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []SchemaMigration
+	err = db.withSchemaLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrSchemaDirty, version)
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			mig := migrations[i]
+			if mig.Version > version || mig.Version <= target {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %06d_%s has no down file", mig.Version, mig.Name)
+			}
+
+			// The new version is the previous embedded migration above target, if any
+			var previous uint
+			if i > 0 && migrations[i-1].Version > target {
+				previous = migrations[i-1].Version
+			} else {
+				previous = target
+			}
+			if err := applySchemaMigration(ctx, conn, mig.Down, previous); err != nil {
+				return fmt.Errorf("rollback %06d_%s: %w", mig.Version, mig.Name, err)
+			}
+			rolledBack = append(rolledBack, mig)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// VerifySchema checks the database is at the latest embedded version and that
+// entity indexes (including partial ones) exist. It never modifies the schema.
+// The returned error is ErrSchemaDirty or ErrSchemaOutdated; index issues are
+// reported but are not an error.
+func (db *Database) VerifySchema(ctx context.Context) (*SchemaReport, error) {
+	migrations, err := LoadSchemaMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SchemaReport{
+		Version: version,
+		Dirty:   dirty,
+		Pending: len(pendingAfter(migrations, version)),
+	}
+	if len(migrations) > 0 {
+		report.Latest = migrations[len(migrations)-1].Version
+	}
+
+	if dirty {
+		return report, fmt.Errorf("%w (version %d)", ErrSchemaDirty, version)
+	}
+	if report.Pending > 0 {
+		return report, fmt.Errorf("%w: at version %d, latest is %d", ErrSchemaOutdated, version, report.Latest)
+	}
+
+	report.IndexIssues, err = db.checkIndexes(ctx)
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// checkIndexes compares index tags on schemaModels with pg_indexes
+func (db *Database) checkIndexes(ctx context.Context) ([]IndexIssue, error) {
+	var issues []IndexIssue
+	for _, model := range schemaModels {
+		stmt := db.DB.Session(&gorm.Session{}).Statement
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+
+		indexes := stmt.Schema.ParseIndexes()
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if problem := db.checkIndex(ctx, table, indexes[name]); problem != "" {
+				issues = append(issues, IndexIssue{Table: table, Index: name, Problem: problem})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// checkIndex returns what is wrong with idx in the database, or "" if it matches
+func (db *Database) checkIndex(ctx context.Context, table string, idx schema.Index) string {
+	var defs []string
+	err := db.DB.WithContext(ctx).Raw(
+		"SELECT indexdef FROM pg_indexes WHERE schemaname = CURRENT_SCHEMA() AND tablename = ? AND indexname = ?",
+		table, idx.Name,
+	).Scan(&defs).Error
+	if err != nil {
+		return "lookup failed: " + err.Error()
+	}
+	if len(defs) == 0 {
+		return "missing"
+	}
+
+	def := strings.ToUpper(defs[0])
+	wantUnique := strings.EqualFold(idx.Class, "UNIQUE")
+	isUnique := strings.HasPrefix(def, "CREATE UNIQUE INDEX")
+	isPartial := strings.Contains(def, " WHERE ")
+
+	switch {
+	case wantUnique && !isUnique:
+		return "expected unique index"
+	case !wantUnique && isUnique:
+		return "unexpected unique index"
+	case idx.Where != "" && !isPartial:
+		return "expected partial index WHERE " + idx.Where
+	case idx.Where == "" && isPartial:
+		return "unexpected partial index"
+	}
+	return ""
+}
+
+// withSchemaLock runs fn on a dedicated connection holding the schema advisory lock
+func (db *Database) withSchemaLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", schemaLockKey); err != nil {
+		return fmt.Errorf("acquire schema lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", schemaLockKey)
+
+	if err := ensureSchemaTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// sqlQuerier is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func ensureSchemaTable(ctx context.Context, q sqlQuerier) error {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`)
+	return err
+}
+
+func schemaVersion(ctx context.Context, q sqlQuerier) (uint, bool, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	var version int64
+	var dirty bool
+	err = q.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// applySchemaMigration runs body and records version in one transaction.
+// body is executed without arguments so multi-statement files are sent
+// through the simple query protocol.
+func applySchemaMigration(ctx context.Context, conn *sql.Conn, body string, version uint) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", int64(version)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func pendingAfter(migrations []SchemaMigration, version uint) []SchemaMigration {
+	var pending []SchemaMigration
+	for _, mig := range migrations {
+		if mig.Version > version {
+			pending = append(pending, mig)
+		}
+	}
+	return pending
+}
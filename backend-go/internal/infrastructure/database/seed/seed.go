@@ -0,0 +1,188 @@
+// Package seed loads declarative YAML fixtures (roles, permissions, lookups,
+// demo tenants) and upserts them idempotently.
+package seed
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed fixtures/*.yaml
+var fixtures embed.FS
+
+// FormatVersion is the fixture file format this package understands
+const FormatVersion = 1
+
+// Fixtures returns the fixture files shipped with the binary
+func Fixtures() fs.FS {
+	sub, _ := fs.Sub(fixtures, "fixtures")
+	return sub
+}
+
+// File is one fixture file. Sections are applied in the order of the fields.
+type File struct {
+	Name         string   `yaml:"-"`
+	Version      int      `yaml:"version"`
+	Environments []string `yaml:"environments"` // empty means every environment
+
+	Roles           []Role              `yaml:"roles"`
+	Permissions     []Permission        `yaml:"permissions"`
+	RolePermissions map[string][]string `yaml:"role_permissions"`
+	Lookups         []Lookup            `yaml:"lookups"`
+	Tenants         []Tenant            `yaml:"tenants"`
+	Users           []User              `yaml:"users"`
+}
+
+// Role fixture, keyed by name
+type Role struct {
+	Name     string `yaml:"name"`
+	TitleFa  string `yaml:"title_fa"`
+	Level    int    `yaml:"level"`
+	IsSystem bool   `yaml:"is_system"`
+}
+
+// Permission fixture, keyed by name
+type Permission struct {
+	Name    string `yaml:"name"`
+	TitleFa string `yaml:"title_fa"`
+	Module  string `yaml:"module"`
+}
+
+// Lookup fixture: rows for a lookup table, upserted on Key columns
+type Lookup struct {
+	Table string                   `yaml:"table"`
+	Key   []string                 `yaml:"key"`
+	Rows  []map[string]interface{} `yaml:"rows"`
+}
+
+// Tenant fixture (insurer), keyed by code
+type Tenant struct {
+	Code    string `yaml:"code"`
+	Title   string `yaml:"title"`
+	TitleEn string `yaml:"title_en"`
+	Email   string `yaml:"email"`
+	Phone   string `yaml:"phone"`
+}
+
+// User fixture, keyed by username. Password is only set when the user is created.
+type User struct {
+	Tenant    string `yaml:"tenant"` // tenant code
+	Username  string `yaml:"username"`
+	Email     string `yaml:"email"`
+	Password  string `yaml:"password"`
+	Role      string `yaml:"role"`
+	FirstName string `yaml:"first_name"`
+	LastName  string `yaml:"last_name"`
+	Mobile    string `yaml:"mobile"`
+}
+
+// identifier guards table and column names taken from fixture files
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Load parses every *.yaml / *.json file in fsys ordered by file name.
+// JSON is valid YAML, so both formats share one parser.
+func Load(fsys fs.FS) ([]*File, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		switch path.Ext(e.Name()) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	files := make([]*File, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		file := &File{Name: name}
+		if err := yaml.Unmarshal(data, file); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := file.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// AppliesTo reports whether the file should be loaded in env
+func (f *File) AppliesTo(env string) bool {
+	if len(f.Environments) == 0 {
+		return true
+	}
+	for _, e := range f.Environments {
+		if strings.EqualFold(e, env) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *File) validate() error {
+	if f.Version != FormatVersion {
+		return fmt.Errorf("unsupported fixture version %d (want %d)", f.Version, FormatVersion)
+	}
+	for _, r := range f.Roles {
+		if r.Name == "" {
+			return fmt.Errorf("role without name")
+		}
+	}
+	for _, p := range f.Permissions {
+		if p.Name == "" {
+			return fmt.Errorf("permission without name")
+		}
+	}
+	for _, l := range f.Lookups {
+		if !identifier.MatchString(l.Table) {
+			return fmt.Errorf("invalid lookup table %q", l.Table)
+		}
+		if len(l.Key) == 0 {
+			return fmt.Errorf("lookup %s: key columns are required", l.Table)
+		}
+		for _, col := range l.Key {
+			if !identifier.MatchString(col) {
+				return fmt.Errorf("lookup %s: invalid key column %q", l.Table, col)
+			}
+		}
+		for i, row := range l.Rows {
+			for col := range row {
+				if !identifier.MatchString(col) {
+					return fmt.Errorf("lookup %s row %d: invalid column %q", l.Table, i+1, col)
+				}
+			}
+			for _, col := range l.Key {
+				if _, ok := row[col]; !ok {
+					return fmt.Errorf("lookup %s row %d: missing key column %q", l.Table, i+1, col)
+				}
+			}
+		}
+	}
+	for _, t := range f.Tenants {
+		if t.Code == "" || t.Title == "" {
+			return fmt.Errorf("tenant needs code and title")
+		}
+	}
+	for _, u := range f.Users {
+		if u.Username == "" || u.Tenant == "" || u.Role == "" {
+			return fmt.Errorf("user needs username, tenant and role")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errDryRun rolls back the seed transaction after a dry run
+var errDryRun = errors.New("dry run")
+
+// SeederConfig configures the seeder
+type SeederConfig struct {
+	// Environment selects fixture files by their environments list (APP_ENV)
+	Environment string
+
+	// Fixtures is where fixture files are read from (default: embedded fixtures)
+	Fixtures fs.FS
+
+	// DryRun applies everything in a transaction that is rolled back
+	DryRun bool
+
+	Logger *log.Logger
+}
+
+// DefaultSeederConfig returns default seeder configuration
+func DefaultSeederConfig() SeederConfig {
+	return SeederConfig{
+		Environment: "development",
+		Fixtures:    Fixtures(),
+		Logger:      log.New(os.Stdout, "[seed] ", log.LstdFlags),
+	}
+}
+
+// Summary counts the rows a seed run upserted per section
+type Summary struct {
+	Files           []string `json:"files"`
+	Roles           int      `json:"roles"`
+	Permissions     int      `json:"permissions"`
+	RolePermissions int      `json:"role_permissions"`
+	LookupRows      int      `json:"lookup_rows"`
+	Tenants         int      `json:"tenants"`
+	Users           int      `json:"users"`
+}
+
+// Seeder applies fixture files to the database
+type Seeder struct {
+	db     *gorm.DB
+	config SeederConfig
+}
+
+// NewSeeder creates a seeder
+func NewSeeder(db *gorm.DB, config ...SeederConfig) *Seeder {
+	cfg := DefaultSeederConfig()
+	if len(config) > 0 {
+		c := config[0]
+		if c.Environment != "" {
+			cfg.Environment = c.Environment
+		}
+		if c.Fixtures != nil {
+			cfg.Fixtures = c.Fixtures
+		}
+		if c.Logger != nil {
+			cfg.Logger = c.Logger
+		}
+		cfg.DryRun = c.DryRun
+	}
+	return &Seeder{db: db, config: cfg}
+}
+
+// Run loads fixtures for the configured environment and upserts them in one transaction
+func (s *Seeder) Run(ctx context.Context) (*Summary, error) {
+	files, err := Load(s.config.Fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	var selected []*File
+	for _, f := range files {
+		if f.AppliesTo(s.config.Environment) {
+			selected = append(selected, f)
+			summary.Files = append(summary.Files, f.Name)
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, f := range selected {
+			s.config.Logger.Printf("applying %s", f.Name)
+			if err := s.apply(tx, f, summary); err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+		if s.config.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (s *Seeder) apply(tx *gorm.DB, f *File, summary *Summary) error {
+	for _, r := range f.Roles {
+		role := entity.Role{
+			Name:     entity.RoleName(r.Name),
+			TitleFa:  r.TitleFa,
+			Level:    r.Level,
+			IsSystem: r.IsSystem,
+			IsActive: true,
+		}
+		if err := upsert(tx, &role, []string{"name"}, "title_fa", "level", "is_system", "is_active"); err != nil {
+			return fmt.Errorf("role %s: %w", r.Name, err)
+		}
+		summary.Roles++
+	}
+
+	for _, p := range f.Permissions {
+		perm := entity.Permission{
+			Name:     entity.PermissionName(p.Name),
+			TitleFa:  p.TitleFa,
+			Module:   p.Module,
+			IsActive: true,
+		}
+		if err := upsert(tx, &perm, []string{"name"}, "title_fa", "module", "is_active"); err != nil {
+			return fmt.Errorf("permission %s: %w", p.Name, err)
+		}
+		summary.Permissions++
+	}
+
+	if len(f.RolePermissions) > 0 {
+		n, err := s.syncRolePermissions(tx, f.RolePermissions)
+		if err != nil {
+			return err
+		}
+		summary.RolePermissions += n
+	}
+
+	for _, l := range f.Lookups {
+		for _, row := range l.Rows {
+			if err := upsertLookup(tx, l, row); err != nil {
+				return fmt.Errorf("lookup %s: %w", l.Table, err)
+			}
+			summary.LookupRows++
+		}
+	}
+
+	for _, t := range f.Tenants {
+		insurer := entity.Insurer{
+			Code:     t.Code,
+			Title:    t.Title,
+			TitleEn:  t.TitleEn,
+			Email:    t.Email,
+			Phone:    t.Phone,
+			IsActive: true,
+		}
+		if err := upsert(tx, &insurer, []string{"code"}, "title", "title_en", "email", "phone", "is_active"); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.Code, err)
+		}
+		summary.Tenants++
+	}
+
+	for _, u := range f.Users {
+		if err := s.upsertUser(tx, u); err != nil {
+			return fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		summary.Users++
+	}
+	return nil
+}
+
+// upsert inserts model or updates the given columns when the key conflicts.
+// Soft-deleted rows are restored.
+func upsert(tx *gorm.DB, model interface{}, key []string, columns ...string) error {
+	conflict := clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns(append(columns, "updated_at", "deleted_at")),
+	}
+	for _, k := range key {
+		conflict.Columns = append(conflict.Columns, clause.Column{Name: k})
+	}
+	return tx.Clauses(conflict).Create(model).Error
+}
+
+// syncRolePermissions makes each listed role hold exactly the matching permissions
+func (s *Seeder) syncRolePermissions(tx *gorm.DB, matrix map[string][]string) (int, error) {
+	var perms []entity.Permission
+	if err := tx.Find(&perms).Error; err != nil {
+		return 0, err
+	}
+
+	roleNames := make([]string, 0, len(matrix))
+	for name := range matrix {
+		roleNames = append(roleNames, name)
+	}
+	sort.Strings(roleNames)
+
+	total := 0
+	for _, roleName := range roleNames {
+		var role entity.Role
+		if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+			return total, fmt.Errorf("role %s: %w", roleName, err)
+		}
+
+		var ids []uint
+		granted := make(map[uint]bool)
+		for _, pattern := range matrix[roleName] {
+			matched := false
+			for _, p := range perms {
+				if permissionMatches(pattern, string(p.Name)) {
+					if !granted[p.ID] {
+						granted[p.ID] = true
+						ids = append(ids, p.ID)
+					}
+					matched = true
+				}
+			}
+			if !matched {
+				return total, fmt.Errorf("role %s: no permission matches %q", roleName, pattern)
+			}
+		}
+
+		// Drop grants no longer in the matrix, then add missing ones
+		var del *gorm.DB
+		if len(ids) > 0 {
+			del = tx.Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id NOT IN ?", role.ID, ids)
+		} else {
+			del = tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", role.ID)
+		}
+		if del.Error != nil {
+			return total, del.Error
+		}
+		for _, id := range ids {
+			err := tx.Exec(
+				"INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				role.ID, id,
+			).Error
+			if err != nil {
+				return total, err
+			}
+		}
+		total += len(ids)
+	}
+	return total, nil
+}
+
+// permissionMatches supports "*" and "module.*" patterns
+func permissionMatches(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return false
+}
+
+// upsertLookup inserts row into a lookup table, updating non-key columns on conflict.
+// Table and column names were validated when the file was loaded.
+func upsertLookup(tx *gorm.DB, l Lookup, row map[string]interface{}) error {
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	isKey := make(map[string]bool, len(l.Key))
+	for _, k := range l.Key {
+		isKey[k] = true
+	}
+
+	values := make([]interface{}, 0, len(cols))
+	var updates []string
+	for _, col := range cols {
+		values = append(values, row[col])
+		if !isKey[col] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+		}
+	}
+
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		l.Table,
+		strings.Join(cols, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "),
+		strings.Join(l.Key, ", "),
+		action,
+	)
+	return tx.Exec(sql, values...).Error
+}
+
+// upsertUser creates or updates a user; the password hash is only written on create
+func (s *Seeder) upsertUser(tx *gorm.DB, u User) error {
+	var tenant entity.Insurer
+	if err := tx.Where("code = ?", u.Tenant).First(&tenant).Error; err != nil {
+		return fmt.Errorf("tenant %s: %w", u.Tenant, err)
+	}
+	var role entity.Role
+	if err := tx.Where("name = ?", u.Role).First(&role).Error; err != nil {
+		return fmt.Errorf("role %s: %w", u.Role, err)
+	}
+
+	user := entity.User{
+		TenantID:  tenant.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Mobile:    u.Mobile,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		RoleID:    role.ID,
+		IsActive:  true,
+	}
+	if u.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = string(hash)
+	}
+
+	return upsert(tx, &user, []string{"username"},
+		"tenant_id", "email", "mobile", "first_name", "last_name", "role_id", "is_active")
+}
@@ -0,0 +1,75 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type attachmentRepository struct {
+	*Repository[entity.Attachment]
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *gorm.DB) repository.AttachmentRepository {
+	return &attachmentRepository{Repository: NewRepository[entity.Attachment](db, repository.AttachmentFields)}
+}
+
+func (r *attachmentRepository) FindByOwner(ctx context.Context, tenantID uint, owner entity.AttachmentOwner, ownerID uint) ([]entity.Attachment, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var attachments []entity.Attachment
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("owner = ? AND owner_id = ?", owner, ownerID).
+		Order("created_at, id").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepository) FindTypes(ctx context.Context, tenantID uint, owner entity.AttachmentOwner, claimType entity.ClaimType) ([]entity.AttachmentType, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var types []entity.AttachmentType
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("owner = ? AND claim_type IN ?", owner, []entity.ClaimType{0, claimType}).
+		Order("claim_type, code").
+		Find(&types).Error
+	return types, err
+}
+
+func (r *attachmentRepository) SaveType(ctx context.Context, t *entity.AttachmentType) error {
+	if t.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "owner"}, {Name: "claim_type"}, {Name: "code"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"title", "required", "max_size", "mime_types", "is_active", "updated_by", "updated_at"}),
+		}).
+		Create(t).Error
+}
+
+func (r *attachmentRepository) DeleteType(ctx context.Context, tenantID uint, id uint) error {
+	if tenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	result := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Delete(&entity.AttachmentType{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
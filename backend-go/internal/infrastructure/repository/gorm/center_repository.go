@@ -0,0 +1,49 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type centerRepository struct {
+	*Repository[entity.Center]
+}
+
+// NewCenterRepository creates a new center repository
+func NewCenterRepository(db *gorm.DB) repository.CenterRepository {
+	return &centerRepository{Repository: NewRepository[entity.Center](db, repository.CenterFields)}
+}
+
+func (r *centerRepository) FindBySiamID(ctx context.Context, tenantID uint, siamID string) (*entity.Center, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "siam_id", Operator: repository.OpEq, Value: siamID}},
+	})
+}
+
+func (r *centerRepository) GetStatsByType(ctx context.Context, tenantID uint) (map[entity.CenterType]int64, error) {
+	type result struct {
+		Type  entity.CenterType
+		Count int64
+	}
+
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []result
+	err = query.Select("type, count(*) as count").Group("type").Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[entity.CenterType]int64)
+	for _, r := range results {
+		stats[r.Type] = r.Count
+	}
+	return stats, nil
+}
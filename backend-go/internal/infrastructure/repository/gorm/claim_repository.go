@@ -2,143 +2,36 @@ package gorm
 
 import (
 	"context"
+	"time"
 
 	"github.com/bank-melli/tpa/internal/domain/entity"
 	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type claimRepository struct {
-	db *gorm.DB
+	*Repository[entity.Claim]
 }
 
 // NewClaimRepository creates a new claim repository
 func NewClaimRepository(db *gorm.DB) repository.ClaimRepository {
-	return &claimRepository{db: db}
-}
-
-func (r *claimRepository) Create(ctx context.Context, claim *entity.Claim) error {
-	return r.db.WithContext(ctx).Create(claim).Error
-}
-
-func (r *claimRepository) Update(ctx context.Context, claim *entity.Claim) error {
-	return r.db.WithContext(ctx).Save(claim).Error
-}
-
-func (r *claimRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entity.Claim{}, id).Error
-}
-
-func (r *claimRepository) FindByID(ctx context.Context, id uint, opts ...repository.QueryOptions) (*entity.Claim, error) {
-	var claim entity.Claim
-	query := r.db.WithContext(ctx)
-
-	if len(opts) > 0 && opts[0].TenantID > 0 {
-		query = query.Where("tenant_id = ?", opts[0].TenantID)
-	}
-
-	if len(opts) > 0 && len(opts[0].Preloads) > 0 {
-		for _, preload := range opts[0].Preloads {
-			query = query.Preload(preload)
-		}
-	}
-
-	err := query.First(&claim, id).Error
-	if err != nil {
-		return nil, err
-	}
-	return &claim, nil
-}
-
-func (r *claimRepository) FindAll(ctx context.Context, opts ...repository.QueryOptions) ([]entity.Claim, error) {
-	var claims []entity.Claim
-	query := r.db.WithContext(ctx)
-
-	if len(opts) > 0 {
-		query = r.applyOptions(query, opts[0])
-	}
-
-	err := query.Find(&claims).Error
-	return claims, err
-}
-
-func (r *claimRepository) FindWithPagination(ctx context.Context, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
-	var claims []entity.Claim
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&entity.Claim{})
-	query = r.applyOptions(query, opts)
-
-	// Count total
-	if err := query.Count(&total).Error; err != nil {
-		return nil, err
-	}
-
-	// Apply pagination
-	if opts.Pagination != nil {
-		offset := (opts.Pagination.Page - 1) * opts.Pagination.PageSize
-		query = query.Offset(offset).Limit(opts.Pagination.PageSize)
-
-		if opts.Pagination.Sort != "" {
-			order := opts.Pagination.Sort
-			if opts.Pagination.Order == "desc" {
-				order += " DESC"
-			}
-			query = query.Order(order)
-		}
-	}
-
-	// Execute query
-	if err := query.Find(&claims).Error; err != nil {
-		return nil, err
-	}
-
-	totalPages := 1
-	if opts.Pagination != nil && opts.Pagination.PageSize > 0 {
-		totalPages = int((total + int64(opts.Pagination.PageSize) - 1) / int64(opts.Pagination.PageSize))
-	}
-
-	return &repository.PaginatedResult[entity.Claim]{
-		Items:      claims,
-		Total:      total,
-		Page:       opts.Pagination.Page,
-		PageSize:   opts.Pagination.PageSize,
-		TotalPages: totalPages,
-	}, nil
-}
-
-func (r *claimRepository) Count(ctx context.Context, opts ...repository.QueryOptions) (int64, error) {
-	var count int64
-	query := r.db.WithContext(ctx).Model(&entity.Claim{})
-
-	if len(opts) > 0 {
-		query = r.applyOptions(query, opts[0])
-	}
-
-	err := query.Count(&count).Error
-	return count, err
+	return &claimRepository{Repository: NewRepository[entity.Claim](db, repository.ClaimFields)}
 }
 
 func (r *claimRepository) FindByTrackingCode(ctx context.Context, tenantID uint, trackingCode string) (*entity.Claim, error) {
-	var claim entity.Claim
-	err := r.db.WithContext(ctx).
-		Where("tenant_id = ? AND tracking_code = ?", tenantID, trackingCode).
-		Preload("PolicyMember").
-		Preload("PolicyMember.Person").
-		Preload("Center").
-		Preload("Items").
-		Preload("Diagnoses").
-		First(&claim).Error
-	if err != nil {
-		return nil, err
-	}
-	return &claim, nil
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "tracking_code", Operator: repository.OpEq, Value: trackingCode}},
+		Preloads: repository.ClaimDetails,
+	})
 }
 
 func (r *claimRepository) FindByPolicyMember(ctx context.Context, tenantID uint, policyMemberID uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
 	opts.Filters = append(opts.Filters, repository.Filter{
 		Field:    "policy_member_id",
-		Operator: "eq",
+		Operator: repository.OpEq,
 		Value:    policyMemberID,
 	})
 	opts.TenantID = tenantID
@@ -148,7 +41,7 @@ func (r *claimRepository) FindByPolicyMember(ctx context.Context, tenantID uint,
 func (r *claimRepository) FindByPackage(ctx context.Context, tenantID uint, packageID uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
 	opts.Filters = append(opts.Filters, repository.Filter{
 		Field:    "package_id",
-		Operator: "eq",
+		Operator: repository.OpEq,
 		Value:    packageID,
 	})
 	opts.TenantID = tenantID
@@ -158,7 +51,7 @@ func (r *claimRepository) FindByPackage(ctx context.Context, tenantID uint, pack
 func (r *claimRepository) FindByStatus(ctx context.Context, tenantID uint, status entity.ClaimStatus, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
 	opts.Filters = append(opts.Filters, repository.Filter{
 		Field:    "status",
-		Operator: "eq",
+		Operator: repository.OpEq,
 		Value:    status,
 	})
 	opts.TenantID = tenantID
@@ -168,23 +61,46 @@ func (r *claimRepository) FindByStatus(ctx context.Context, tenantID uint, statu
 func (r *claimRepository) FindByCenter(ctx context.Context, tenantID uint, centerID uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
 	opts.Filters = append(opts.Filters, repository.Filter{
 		Field:    "center_id",
-		Operator: "eq",
+		Operator: repository.OpEq,
 		Value:    centerID,
 	})
 	opts.TenantID = tenantID
 	return r.FindWithPagination(ctx, opts)
 }
 
+// maxDuplicateCandidates bounds the claims compared by duplicate detection
+const maxDuplicateCandidates = 500
+
+func (r *claimRepository) FindByMemberBetween(ctx context.Context, tenantID uint, policyMemberID uint, from, to time.Time, excludeID uint) ([]entity.Claim, error) {
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims []entity.Claim
+	err = query.
+		Where("policy_member_id = ? AND id <> ?", policyMemberID, excludeID).
+		Where(
+			"service_date BETWEEN ? AND ? OR (admission_date <= ? AND COALESCE(discharge_date, admission_date) >= ?)",
+			from, to, to, from,
+		).
+		Preload("Items").
+		Order("service_date DESC, id DESC").
+		Limit(maxDuplicateCandidates).
+		Find(&claims).Error
+	return claims, err
+}
+
 func (r *claimRepository) FindPendingExamination(ctx context.Context, tenantID uint, examinerID *uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Claim], error) {
 	opts.Filters = append(opts.Filters, repository.Filter{
 		Field:    "status",
-		Operator: "eq",
+		Operator: repository.OpEq,
 		Value:    entity.ClaimStatusWaitCheck,
 	})
 	if examinerID != nil {
 		opts.Filters = append(opts.Filters, repository.Filter{
 			Field:    "handler_user_id",
-			Operator: "eq",
+			Operator: repository.OpEq,
 			Value:    *examinerID,
 		})
 	}
@@ -192,20 +108,19 @@ func (r *claimRepository) FindPendingExamination(ctx context.Context, tenantID u
 	return r.FindWithPagination(ctx, opts)
 }
 
-func (r *claimRepository) GetStatsByStatus(ctx context.Context, tenantID uint) (map[entity.ClaimStatus]int64, error) {
+func (r *claimRepository) GetStatsByStatus(ctx context.Context, tenantID uint, filters []repository.Filter) (map[entity.ClaimStatus]int64, error) {
 	type result struct {
 		Status entity.ClaimStatus
 		Count  int64
 	}
 
-	var results []result
-	err := r.db.WithContext(ctx).
-		Model(&entity.Claim{}).
-		Select("status, count(*) as count").
-		Where("tenant_id = ?", tenantID).
-		Group("status").
-		Scan(&results).Error
+	query, err := r.query(ctx, repository.QueryOptions{TenantID: tenantID, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
 
+	var results []result
+	err = query.Select("status, count(*) as count").Group("status").Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -217,20 +132,19 @@ func (r *claimRepository) GetStatsByStatus(ctx context.Context, tenantID uint) (
 	return stats, nil
 }
 
-func (r *claimRepository) GetStatsByType(ctx context.Context, tenantID uint) (map[entity.ClaimType]int64, error) {
+func (r *claimRepository) GetStatsByType(ctx context.Context, tenantID uint, filters []repository.Filter) (map[entity.ClaimType]int64, error) {
 	type result struct {
 		ClaimType entity.ClaimType
 		Count     int64
 	}
 
-	var results []result
-	err := r.db.WithContext(ctx).
-		Model(&entity.Claim{}).
-		Select("claim_type, count(*) as count").
-		Where("tenant_id = ?", tenantID).
-		Group("claim_type").
-		Scan(&results).Error
+	query, err := r.query(ctx, repository.QueryOptions{TenantID: tenantID, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
 
+	var results []result
+	err = query.Select("claim_type, count(*) as count").Group("claim_type").Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -249,55 +163,108 @@ func (r *claimRepository) GetTotalAmounts(ctx context.Context, tenantID uint, fi
 		Deduction      int64
 	}
 
-	var res result
-	query := r.db.WithContext(ctx).
-		Model(&entity.Claim{}).
-		Select("COALESCE(SUM(request_amount), 0) as request_amount, COALESCE(SUM(approved_amount), 0) as approved_amount, COALESCE(SUM(deduction), 0) as deduction").
-		Where("tenant_id = ?", tenantID)
-
-	for _, f := range filters {
-		query = r.applyFilter(query, f)
+	query, err := r.query(ctx, repository.QueryOptions{TenantID: tenantID, Filters: filters})
+	if err != nil {
+		return 0, 0, 0, err
 	}
 
-	err = query.Scan(&res).Error
+	var res result
+	err = query.
+		Select("COALESCE(SUM(request_amount), 0) as request_amount, COALESCE(SUM(approved_amount), 0) as approved_amount, COALESCE(SUM(deduction), 0) as deduction").
+		Scan(&res).Error
 	return res.RequestAmount, res.ApprovedAmount, res.Deduction, err
 }
 
-func (r *claimRepository) applyOptions(query *gorm.DB, opts repository.QueryOptions) *gorm.DB {
-	if opts.TenantID > 0 {
-		query = query.Where("tenant_id = ?", opts.TenantID)
+func (r *claimRepository) UpdateAmounts(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus) error {
+	if claim.TenantID == 0 {
+		return repository.ErrTenantRequired
 	}
+	result := r.DB().WithContext(ctx).Model(claim).
+		Scopes(tenant.TenantScope(claim.TenantID)).
+		Where("status = ?", from).
+		Select("request_amount", "basic_ins_share", "updated_by", "updated_at").
+		Updates(claim)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrStatusChanged
+	}
+	return nil
+}
 
-	for _, filter := range opts.Filters {
-		query = r.applyFilter(query, filter)
+func (r *claimRepository) Transition(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus, history *entity.ClaimStatusHistory) error {
+	if claim.TenantID == 0 {
+		return repository.ErrTenantRequired
 	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return transition(tx, claim, from, history)
+	})
+}
 
-	for _, preload := range opts.Preloads {
-		query = query.Preload(preload)
+func (r *claimRepository) Examine(ctx context.Context, claim *entity.Claim, from entity.ClaimStatus, history ...*entity.ClaimStatusHistory) error {
+	if claim.TenantID == 0 {
+		return repository.ErrTenantRequired
 	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transition(tx, claim, from, history...); err != nil {
+			return err
+		}
 
-	return query
+		for i := range claim.Items {
+			err := tx.Model(&claim.Items[i]).
+				Scopes(tenant.TenantScope(claim.TenantID)).
+				Select("confirmed_price", "deduction", "examiner_notes", "updated_at").
+				Updates(&claim.Items[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// A re-examination replaces the reasons of the previous one
+		err := tx.Scopes(tenant.TenantScope(claim.TenantID)).
+			Where("claim_id = ?", claim.ID).
+			Delete(&entity.ClaimDeductionReason{}).Error
+		if err != nil || len(claim.DeductionReasons) == 0 {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&claim.DeductionReasons).Error
+	})
+}
+
+// transition updates claim provided its stored status is still from, then appends history
+func transition(tx *gorm.DB, claim *entity.Claim, from entity.ClaimStatus, history ...*entity.ClaimStatusHistory) error {
+	// The status condition makes concurrent transitions of one claim fail
+	// instead of both being recorded
+	result := tx.Model(claim).
+		Scopes(tenant.TenantScope(claim.TenantID)).
+		Where("status = ?", from).
+		Select("*").Omit(clause.Associations, "id", "created_at", "created_by").
+		Updates(claim)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrStatusChanged
+	}
+	for _, h := range history {
+		if err := tx.Create(h).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *claimRepository) applyFilter(query *gorm.DB, filter repository.Filter) *gorm.DB {
-	switch filter.Operator {
-	case "eq":
-		return query.Where(filter.Field+" = ?", filter.Value)
-	case "ne":
-		return query.Where(filter.Field+" != ?", filter.Value)
-	case "gt":
-		return query.Where(filter.Field+" > ?", filter.Value)
-	case "gte":
-		return query.Where(filter.Field+" >= ?", filter.Value)
-	case "lt":
-		return query.Where(filter.Field+" < ?", filter.Value)
-	case "lte":
-		return query.Where(filter.Field+" <= ?", filter.Value)
-	case "like":
-		return query.Where(filter.Field+" LIKE ?", "%"+filter.Value.(string)+"%")
-	case "in":
-		return query.Where(filter.Field+" IN ?", filter.Value)
-	default:
-		return query
+func (r *claimRepository) FindStatusHistory(ctx context.Context, tenantID uint, claimID uint) ([]entity.ClaimStatusHistory, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
 	}
+
+	var history []entity.ClaimStatusHistory
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("claim_id = ?", claimID).
+		Order("created_at, id").
+		Find(&history).Error
+	return history, err
 }
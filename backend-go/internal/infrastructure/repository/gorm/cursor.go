@@ -0,0 +1,140 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cursorTimeLayout keeps microseconds and the offset, so the bound value
+// matches the stored one for both timestamp and timestamptz columns
+const cursorTimeLayout = "2006-01-02 15:04:05.999999Z07:00"
+
+// FindWithCursor returns the rows after opts.Cursor ordered by (created_at, id).
+// Unlike FindWithPagination it never scans skipped rows and only counts when asked.
+func (r *Repository[T]) FindWithCursor(ctx context.Context, opts repository.QueryOptions) (*repository.CursorResult[T], error) {
+	page := repository.CursorPage{Desc: true}
+	if opts.Cursor != nil {
+		page = *opts.Cursor
+	}
+	page.Normalize()
+
+	query, err := r.query(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &repository.CursorResult[T]{Limit: page.Limit}
+	switch page.Total {
+	case repository.TotalExact:
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	case repository.TotalEstimate:
+		total, err := r.estimate(ctx, query.Session(&gorm.Session{}))
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+		result.TotalEstimated = true
+	}
+
+	if page.After != "" {
+		after, err := repository.DecodeCursor(page.After, page.Desc)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if page.Desc {
+			op = "<"
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s, id) %s (?, ?)", repository.CursorColumn, op),
+			after.CreatedAt.Format(cursorTimeLayout), after.ID,
+		)
+	}
+
+	// One extra row tells whether another page exists
+	items := make([]T, 0, page.Limit+1)
+	err = preload(query, opts.Preloads).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: repository.CursorColumn}, Desc: page.Desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: page.Desc}).
+		Limit(page.Limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		last, err := r.cursorOf(ctx, &items[len(items)-1])
+		if err != nil {
+			return nil, err
+		}
+		last.Desc = page.Desc
+		result.NextCursor = last.Encode()
+		result.HasMore = true
+	}
+	result.Items = items
+	return result, nil
+}
+
+// cursorOf reads the keyset columns of item
+func (r *Repository[T]) cursorOf(ctx context.Context, item *T) (repository.Cursor, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(item); err != nil {
+		return repository.Cursor{}, err
+	}
+	value := reflect.ValueOf(item).Elem()
+
+	var c repository.Cursor
+	if field := stmt.Schema.LookUpField(repository.CursorColumn); field != nil {
+		v, _ := field.ValueOf(ctx, value)
+		c.CreatedAt, _ = v.(time.Time)
+	}
+	if field := stmt.Schema.PrioritizedPrimaryField; field != nil {
+		v, _ := field.ValueOf(ctx, value)
+		c.ID, _ = v.(uint)
+	}
+	if c.ID == 0 {
+		return c, fmt.Errorf("%s has no keyset columns", stmt.Schema.Name)
+	}
+	return c, nil
+}
+
+// estimate returns the planner's row estimate for query instead of counting
+func (r *Repository[T]) estimate(ctx context.Context, query *gorm.DB) (int64, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(new([]T)).Statement
+	if stmt.Error != nil {
+		return 0, stmt.Error
+	}
+
+	var raw string
+	err := r.db.WithContext(ctx).
+		Raw("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).
+		Row().Scan(&raw)
+	if err != nil {
+		return 0, err
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(raw), &plans); err != nil {
+		return 0, err
+	}
+	if len(plans) == 0 {
+		return 0, fmt.Errorf("empty EXPLAIN output")
+	}
+	return int64(plans[0].Plan.Rows), nil
+}
@@ -0,0 +1,39 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type employeeRepository struct {
+	*Repository[entity.Employee]
+}
+
+// NewEmployeeRepository creates a new employee repository
+func NewEmployeeRepository(db *gorm.DB) repository.EmployeeRepository {
+	return &employeeRepository{Repository: NewRepository[entity.Employee](db, repository.EmployeeFields)}
+}
+
+func (r *employeeRepository) FindByNationalCode(ctx context.Context, tenantID uint, nationalCode string) (*entity.Employee, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "national_code", Operator: repository.OpEq, Value: nationalCode}},
+	})
+}
+
+func (r *employeeRepository) FindByPersonnelCode(ctx context.Context, tenantID uint, personnelCode string) (*entity.Employee, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "personnel_code", Operator: repository.OpEq, Value: personnelCode}},
+	})
+}
+
+func (r *employeeRepository) FindFamilyMembers(ctx context.Context, tenantID uint, parentID uint) ([]entity.Employee, error) {
+	return r.FindAll(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "parent_id", Operator: repository.OpEq, Value: parentID}},
+	})
+}
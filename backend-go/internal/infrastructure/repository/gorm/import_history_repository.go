@@ -0,0 +1,25 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type importHistoryRepository struct {
+	*Repository[entity.EmployeeImportHistory]
+}
+
+// NewImportHistoryRepository creates a new employee import history repository
+func NewImportHistoryRepository(db *gorm.DB) repository.ImportHistoryRepository {
+	return &importHistoryRepository{Repository: NewRepository[entity.EmployeeImportHistory](db, repository.ImportHistoryFields)}
+}
+
+func (r *importHistoryRepository) FindByBatchID(ctx context.Context, tenantID uint, batchID string) (*entity.EmployeeImportHistory, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "batch_id", Operator: repository.OpEq, Value: batchID}},
+	})
+}
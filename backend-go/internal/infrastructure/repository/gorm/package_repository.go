@@ -0,0 +1,62 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type packageRepository struct {
+	*Repository[entity.Package]
+}
+
+// NewPackageRepository creates a new package repository
+func NewPackageRepository(db *gorm.DB) repository.PackageRepository {
+	return &packageRepository{Repository: NewRepository[entity.Package](db, repository.PackageFields)}
+}
+
+func (r *packageRepository) FindByCenter(ctx context.Context, tenantID uint, centerID uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Package], error) {
+	opts.Filters = append(opts.Filters, repository.Filter{
+		Field:    "center_id",
+		Operator: repository.OpEq,
+		Value:    centerID,
+	})
+	opts.TenantID = tenantID
+	return r.FindWithPagination(ctx, opts)
+}
+
+func (r *packageRepository) FindByStatus(ctx context.Context, tenantID uint, status entity.PackageStatus, opts repository.QueryOptions) (*repository.PaginatedResult[entity.Package], error) {
+	opts.Filters = append(opts.Filters, repository.Filter{
+		Field:    "status",
+		Operator: repository.OpEq,
+		Value:    status,
+	})
+	opts.TenantID = tenantID
+	return r.FindWithPagination(ctx, opts)
+}
+
+func (r *packageRepository) GetStatsByStatus(ctx context.Context, tenantID uint) (map[entity.PackageStatus]int64, error) {
+	type result struct {
+		Status entity.PackageStatus
+		Count  int64
+	}
+
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []result
+	err = query.Select("status, count(*) as count").Group("status").Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[entity.PackageStatus]int64)
+	for _, r := range results {
+		stats[r.Status] = r.Count
+	}
+	return stats, nil
+}
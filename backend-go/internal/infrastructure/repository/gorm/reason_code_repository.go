@@ -0,0 +1,38 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type reasonCodeRepository struct {
+	*Repository[entity.ReasonCode]
+}
+
+// NewReasonCodeRepository creates a new reason code repository
+func NewReasonCodeRepository(db *gorm.DB) repository.ReasonCodeRepository {
+	return &reasonCodeRepository{Repository: NewRepository[entity.ReasonCode](db, repository.ReasonCodeFields)}
+}
+
+func (r *reasonCodeRepository) FindActiveByIDs(ctx context.Context, tenantID uint, ids []uint) ([]entity.ReasonCode, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return r.FindAll(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters: []repository.Filter{
+			{Field: "id", Operator: repository.OpIn, Value: ids},
+			{Field: "is_active", Operator: repository.OpEq, Value: true},
+		},
+	})
+}
+
+func (r *reasonCodeRepository) FindByCode(ctx context.Context, tenantID uint, code string) (*entity.ReasonCode, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "code", Operator: repository.OpEq, Value: code}},
+	})
+}
@@ -0,0 +1,266 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository implements repository.Repository[T] for tenant-owned entities.
+// Every statement is scoped to a tenant; calls without one fail with
+// repository.ErrTenantRequired. Filters and sorts are resolved through the
+// entity's field whitelist and never reach SQL otherwise.
+type Repository[T any] struct {
+	db     *gorm.DB
+	fields repository.FieldSet
+}
+
+// NewRepository creates a generic repository for T filtered and sorted by fields
+func NewRepository[T any](db *gorm.DB, fields repository.FieldSet) *Repository[T] {
+	return &Repository[T]{db: db, fields: fields}
+}
+
+// DB returns the underlying connection for entity specific queries
+func (r *Repository[T]) DB() *gorm.DB {
+	return r.db
+}
+
+// Scoped returns a session for ctx restricted to tenantID
+func (r *Repository[T]) Scoped(ctx context.Context, tenantID uint) (*gorm.DB, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	return r.db.WithContext(ctx).Model(new(T)).Scopes(tenant.TenantScope(tenantID)), nil
+}
+
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	tenantID, err := tenantOf(entity)
+	if err != nil {
+		return err
+	}
+	if tenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.db.WithContext(ctx).Create(entity).Error
+}
+
+// Update writes every column of entity (not its associations); the row must
+// belong to the entity's tenant
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	tenantID, err := tenantOf(entity)
+	if err != nil {
+		return err
+	}
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// Save would fall back to an upsert when no row matches, which could
+	// overwrite another tenant's row; Updates only touches matching rows.
+	result := query.Model(entity).Select("*").Omit(clause.Associations, "id", "created_at", "created_by").Updates(entity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, tenantID uint, id uint) error {
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	result := query.Delete(new(T), id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) FindByID(ctx context.Context, id uint, opts repository.QueryOptions) (*T, error) {
+	query, err := r.Scoped(ctx, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity T
+	if err := preload(query, opts.Preloads).First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// FindOne returns the first row matching the options' filters
+func (r *Repository[T]) FindOne(ctx context.Context, opts repository.QueryOptions) (*T, error) {
+	query, err := r.query(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity T
+	if err := preload(query, opts.Preloads).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *Repository[T]) FindAll(ctx context.Context, opts repository.QueryOptions) ([]T, error) {
+	query, err := r.query(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Pagination != nil && opts.Pagination.Sort != "" {
+		query = r.order(query, opts.Pagination)
+	}
+
+	var items []T
+	err = preload(query, opts.Preloads).Find(&items).Error
+	return items, err
+}
+
+func (r *Repository[T]) FindWithPagination(ctx context.Context, opts repository.QueryOptions) (*repository.PaginatedResult[T], error) {
+	query, err := r.query(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := repository.Pagination{}
+	if opts.Pagination != nil {
+		page = *opts.Pagination
+	}
+	page.Normalize()
+
+	// Count total
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// Apply pagination
+	query = r.order(query.Offset(page.Offset()).Limit(page.PageSize), &page)
+
+	items := make([]T, 0, page.PageSize)
+	if err := preload(query, opts.Preloads).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	return &repository.PaginatedResult[T]{
+		Items:      items,
+		Total:      total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: int((total + int64(page.PageSize) - 1) / int64(page.PageSize)),
+	}, nil
+}
+
+func (r *Repository[T]) Count(ctx context.Context, opts repository.QueryOptions) (int64, error) {
+	query, err := r.query(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = query.Count(&count).Error
+	return count, err
+}
+
+// query builds a tenant scoped query with the options' filters
+func (r *Repository[T]) query(ctx context.Context, opts repository.QueryOptions) (*gorm.DB, error) {
+	if err := r.fields.Validate(opts); err != nil {
+		return nil, err
+	}
+	query, err := r.Scoped(ctx, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, filter := range opts.Filters {
+		field, _ := r.fields.Filterable(filter.Field, filter.Operator)
+		query = applyFilter(query, field.Column, filter)
+	}
+	return query, nil
+}
+
+// order sorts by the validated sort column with id as a tie-breaker
+func (r *Repository[T]) order(query *gorm.DB, p *repository.Pagination) *gorm.DB {
+	desc := p.Order == "desc"
+	if p.Sort != "" {
+		field, _ := r.fields.Sortable(p.Sort)
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: field.Column}, Desc: desc})
+		if field.Column == "id" {
+			return query
+		}
+	}
+	return query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+}
+
+// preload is applied only when loading rows; COUNT cannot preload
+func preload(query *gorm.DB, preloads []string) *gorm.DB {
+	for _, p := range preloads {
+		query = query.Preload(p)
+	}
+	return query
+}
+
+// applyFilter adds filter on column; column comes from the whitelist, values are bound
+func applyFilter(query *gorm.DB, column string, filter repository.Filter) *gorm.DB {
+	col := clause.Column{Name: column}
+	switch filter.Operator {
+	case repository.OpEq:
+		return query.Where(clause.Eq{Column: col, Value: filter.Value})
+	case repository.OpNe:
+		return query.Where(clause.Neq{Column: col, Value: filter.Value})
+	case repository.OpGt:
+		return query.Where(clause.Gt{Column: col, Value: filter.Value})
+	case repository.OpGte:
+		return query.Where(clause.Gte{Column: col, Value: filter.Value})
+	case repository.OpLt:
+		return query.Where(clause.Lt{Column: col, Value: filter.Value})
+	case repository.OpLte:
+		return query.Where(clause.Lte{Column: col, Value: filter.Value})
+	case repository.OpLike:
+		return query.Where(clause.Like{Column: col, Value: "%" + escapeLike(fmt.Sprint(filter.Value)) + "%"})
+	case repository.OpIn:
+		return query.Where(clause.IN{Column: col, Values: inValues(filter.Value)})
+	default:
+		return query
+	}
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// inValues flattens a slice value into IN arguments
+func inValues(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// tenantOf reads the owning tenant of entity
+func tenantOf(entity interface{}) (uint, error) {
+	scoped, ok := entity.(repository.TenantScoped)
+	if !ok {
+		return 0, repository.ErrTenantRequired
+	}
+	return scoped.GetTenantID(), nil
+}
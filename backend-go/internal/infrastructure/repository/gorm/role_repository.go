@@ -0,0 +1,30 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) repository.RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) PermissionsOf(ctx context.Context, role entity.RoleName) ([]entity.PermissionName, error) {
+	var perms []entity.PermissionName
+	err := r.db.WithContext(ctx).
+		Table("permissions p").
+		Joins("JOIN role_permissions rp ON rp.permission_id = p.id").
+		Joins("JOIN roles r ON r.id = rp.role_id").
+		Where("r.name = ? AND r.is_active AND p.is_active", role).
+		Where("r.deleted_at IS NULL AND p.deleted_at IS NULL").
+		Pluck("p.name", &perms).Error
+	return perms, err
+}
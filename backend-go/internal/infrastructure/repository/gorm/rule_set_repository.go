@@ -0,0 +1,191 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type ruleSetRepository struct {
+	*Repository[entity.RuleSet]
+}
+
+// NewRuleSetRepository creates a new rule set repository
+func NewRuleSetRepository(db *gorm.DB) repository.RuleSetRepository {
+	return &ruleSetRepository{Repository: NewRepository[entity.RuleSet](db, repository.RuleSetFields)}
+}
+
+func (r *ruleSetRepository) FindInEffect(ctx context.Context, tenantID uint, at time.Time) (*entity.RuleSet, error) {
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var set entity.RuleSet
+	err = query.
+		Where("status = ?", entity.RuleSetActive).
+		Where("(effective_from IS NULL OR effective_from <= ?)", at).
+		Order("effective_from DESC NULLS LAST, version DESC").
+		First(&set).Error
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (r *ruleSetRepository) FindScheduled(ctx context.Context, tenantID uint, after time.Time) ([]entity.RuleSet, error) {
+	query, err := r.Scoped(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []entity.RuleSet
+	err = query.
+		Where("status = ? AND effective_from > ?", entity.RuleSetActive, after).
+		Order("effective_from, version").
+		Find(&sets).Error
+	return sets, err
+}
+
+func (r *ruleSetRepository) NextVersion(ctx context.Context, tenantID uint) (uint, error) {
+	if tenantID == 0 {
+		return 0, repository.ErrTenantRequired
+	}
+
+	// Deleted drafts keep their number so versions are never reused
+	var last uint
+	err := r.DB().WithContext(ctx).Unscoped().
+		Model(&entity.RuleSet{}).
+		Scopes(tenant.TenantScope(tenantID)).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&last).Error
+	return last + 1, err
+}
+
+func (r *ruleSetRepository) CreateWithTests(ctx context.Context, set *entity.RuleSet, tests []entity.RuleTest) error {
+	if set.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(set).Error; err != nil {
+			return err
+		}
+		if len(tests) == 0 {
+			return nil
+		}
+		for i := range tests {
+			tests[i].ID = 0
+			tests[i].TenantID = set.TenantID
+			tests[i].RuleSetID = set.ID
+		}
+		return tx.Create(&tests).Error
+	})
+}
+
+func (r *ruleSetRepository) Rollback(ctx context.Context, set *entity.RuleSet) error {
+	if set.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.RuleSet{}).
+			Scopes(tenant.TenantScope(set.TenantID)).
+			Where("status = ? AND id <> ? AND effective_from >= ?", entity.RuleSetActive, set.ID, set.EffectiveFrom).
+			Updates(map[string]interface{}{
+				"status":     entity.RuleSetRetired,
+				"updated_by": set.UpdatedBy,
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(set).
+			Scopes(tenant.TenantScope(set.TenantID)).
+			Select("status", "activated_at", "activated_by", "updated_by", "updated_at").
+			Updates(set).Error
+	})
+}
+
+func (r *ruleSetRepository) FindTests(ctx context.Context, tenantID uint, ruleSetID uint) ([]entity.RuleTest, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+
+	var tests []entity.RuleTest
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("rule_set_id = ?", ruleSetID).
+		Order("id").
+		Find(&tests).Error
+	return tests, err
+}
+
+func (r *ruleSetRepository) CreateTest(ctx context.Context, test *entity.RuleTest) error {
+	if test.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Create(test).Error
+}
+
+func (r *ruleSetRepository) DeleteTest(ctx context.Context, tenantID uint, ruleSetID uint, testID uint) error {
+	if tenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	result := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("rule_set_id = ?", ruleSetID).
+		Delete(&entity.RuleTest{}, testID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ruleSetRepository) CreateExecutionLog(ctx context.Context, log *entity.RuleExecutionLog) error {
+	if log.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Create(log).Error
+}
+
+func (r *ruleSetRepository) FindExecutionLogs(ctx context.Context, tenantID uint, claimID uint) ([]entity.RuleExecutionLog, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+
+	var logs []entity.RuleExecutionLog
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("claim_id = ?", claimID).
+		Order("created_at, id").
+		Find(&logs).Error
+	return logs, err
+}
+
+func (r *ruleSetRepository) FindLatestExecutionLogs(ctx context.Context, tenantID uint, claimIDs []uint, stage string) (map[uint]entity.RuleExecutionLog, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	latest := make(map[uint]entity.RuleExecutionLog, len(claimIDs))
+	if len(claimIDs) == 0 {
+		return latest, nil
+	}
+
+	var logs []entity.RuleExecutionLog
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Select("DISTINCT ON (claim_id) *").
+		Where("claim_id IN ? AND stage = ? AND (error IS NULL OR error = '')", claimIDs, stage).
+		Order("claim_id, created_at DESC, id DESC").
+		Find(&logs).Error
+	for _, log := range logs {
+		latest[log.ClaimID] = log
+	}
+	return latest, err
+}
@@ -0,0 +1,46 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ruleSimulationRepository struct {
+	*Repository[entity.RuleSimulation]
+	claims *Repository[entity.RuleSimulationClaim]
+}
+
+// NewRuleSimulationRepository creates a new rule simulation repository
+func NewRuleSimulationRepository(db *gorm.DB) repository.RuleSimulationRepository {
+	return &ruleSimulationRepository{
+		Repository: NewRepository[entity.RuleSimulation](db, repository.RuleSimulationFields),
+		claims:     NewRepository[entity.RuleSimulationClaim](db, repository.RuleSimulationClaimFields),
+	}
+}
+
+func (r *ruleSimulationRepository) SaveProgress(ctx context.Context, sim *entity.RuleSimulation, results []entity.RuleSimulationClaim) error {
+	if sim.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(results) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&results).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(sim).
+			Scopes(tenant.TenantScope(sim.TenantID)).
+			Select("status", "total_claims", "processed", "summary", "error", "started_at", "finished_at", "updated_at").
+			Updates(sim).Error
+	})
+}
+
+func (r *ruleSimulationRepository) FindClaims(ctx context.Context, simulationID uint, opts repository.QueryOptions) (*repository.PaginatedResult[entity.RuleSimulationClaim], error) {
+	opts.Filters = append(opts.Filters, repository.Filter{Field: "simulation_id", Operator: repository.OpEq, Value: simulationID})
+	return r.claims.FindWithPagination(ctx, opts)
+}
@@ -0,0 +1,124 @@
+package gorm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// Searches match search_text, the normalize_fa folded text maintained by the
+// database, two ways: full text, for words typed from their start, and
+// trigram word similarity, for misspellings and words typed together or
+// apart. The rank adds both, plus a bonus for an exact code.
+
+// claimMemberCandidates caps the members whose claims a claim search ranks
+const claimMemberCandidates = 200
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *gorm.DB) repository.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+const searchEmployeesSQL = `
+SELECT e.id, e.parent_id, e.first_name, e.last_name, e.personnel_code, e.national_code, e.is_active,
+	ts_rank(to_tsvector('simple', e.search_text), to_tsquery('simple', @tsquery))
+	+ word_similarity(@key, e.search_text)
+	+ CASE WHEN @code <> '' AND (e.national_code = @code OR e.personnel_code = @code) THEN 1 ELSE 0 END AS rank
+FROM employees e
+WHERE e.tenant_id = @tenant AND e.deleted_at IS NULL
+	AND (to_tsvector('simple', e.search_text) @@ to_tsquery('simple', @tsquery) OR @key <% e.search_text)
+ORDER BY rank DESC, e.id
+LIMIT @limit`
+
+func (r *searchRepository) SearchEmployees(ctx context.Context, q repository.SearchQuery) ([]repository.EmployeeHit, error) {
+	if q.TenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var hits []repository.EmployeeHit
+	err := r.db.WithContext(ctx).Raw(searchEmployeesSQL, searchArgs(q)).Scan(&hits).Error
+	return hits, err
+}
+
+const searchCentersSQL = `
+SELECT c.id, c.title, c.siam_id, c.code, c.type, c.is_active,
+	ts_rank(to_tsvector('simple', c.search_text), to_tsquery('simple', @tsquery))
+	+ word_similarity(@key, c.search_text)
+	+ CASE WHEN @code <> '' AND (c.siam_id = @code OR c.code = @code) THEN 1 ELSE 0 END AS rank
+FROM centers c
+WHERE c.tenant_id = @tenant AND c.deleted_at IS NULL
+	AND (to_tsvector('simple', c.search_text) @@ to_tsquery('simple', @tsquery) OR @key <% c.search_text)
+ORDER BY rank DESC, c.id
+LIMIT @limit`
+
+func (r *searchRepository) SearchCenters(ctx context.Context, q repository.SearchQuery) ([]repository.CenterHit, error) {
+	if q.TenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var hits []repository.CenterHit
+	err := r.db.WithContext(ctx).Raw(searchCentersSQL, searchArgs(q)).Scan(&hits).Error
+	return hits, err
+}
+
+// Claims match by tracking code prefix or through their member. The two are
+// separate branches so each can use its own index.
+const searchClaimsSQL = `
+WITH members AS (
+	SELECT e.id,
+		ts_rank(to_tsvector('simple', e.search_text), to_tsquery('simple', @tsquery))
+		+ word_similarity(@key, e.search_text) AS rank
+	FROM employees e
+	WHERE e.tenant_id = @tenant AND e.deleted_at IS NULL
+		AND (to_tsvector('simple', e.search_text) @@ to_tsquery('simple', @tsquery) OR @key <% e.search_text)
+	ORDER BY rank DESC
+	LIMIT @members
+), matches AS (
+	SELECT c.id, CASE WHEN c.tracking_code = @code THEN 2 ELSE 1 END AS rank
+	FROM claims c
+	WHERE c.tenant_id = @tenant AND c.deleted_at IS NULL AND @code <> '' AND c.tracking_code LIKE @prefix
+	UNION ALL
+	SELECT c.id, m.rank
+	FROM claims c JOIN members m ON m.id = c.policy_member_id
+	WHERE c.tenant_id = @tenant AND c.deleted_at IS NULL
+), ranked AS (
+	SELECT id, sum(rank) AS rank FROM matches GROUP BY id ORDER BY rank DESC, id DESC LIMIT @limit
+)
+SELECT c.id, c.tracking_code, c.claim_type, c.status, c.service_date, c.policy_member_id,
+	concat_ws(' ', e.first_name, e.last_name) AS member_name, e.national_code, r.rank
+FROM ranked r
+JOIN claims c ON c.id = r.id
+LEFT JOIN employees e ON e.id = c.policy_member_id
+ORDER BY r.rank DESC, c.id DESC`
+
+func (r *searchRepository) SearchClaims(ctx context.Context, q repository.SearchQuery) ([]repository.ClaimHit, error) {
+	if q.TenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	args := searchArgs(q)
+	args["members"] = claimMemberCandidates
+	args["prefix"] = q.Code + "%"
+	var hits []repository.ClaimHit
+	err := r.db.WithContext(ctx).Raw(searchClaimsSQL, args).Scan(&hits).Error
+	return hits, err
+}
+
+// searchArgs returns the named arguments the searches share
+func searchArgs(q repository.SearchQuery) map[string]interface{} {
+	// Words are letters and digits only, so they need no escaping
+	prefixes := make([]string, len(q.Words))
+	for i, w := range q.Words {
+		prefixes[i] = w + ":*"
+	}
+	return map[string]interface{}{
+		"tenant":  q.TenantID,
+		"key":     q.Key,
+		"tsquery": strings.Join(prefixes, " & "),
+		"code":    q.Code,
+		"limit":   q.Limit,
+	}
+}
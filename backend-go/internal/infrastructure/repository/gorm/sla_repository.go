@@ -0,0 +1,188 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type slaPolicyRepository struct {
+	*Repository[entity.SLAPolicy]
+}
+
+// NewSLAPolicyRepository creates a new SLA policy repository
+func NewSLAPolicyRepository(db *gorm.DB) repository.SLAPolicyRepository {
+	return &slaPolicyRepository{Repository: NewRepository[entity.SLAPolicy](db, repository.SLAPolicyFields)}
+}
+
+func (r *slaPolicyRepository) SavePolicy(ctx context.Context, policy *entity.SLAPolicy) error {
+	if policy.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "status"}, {Name: "claim_type"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"target_hours", "escalate_to", "is_active", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+}
+
+func (r *slaPolicyRepository) FindActivePolicies(ctx context.Context, tenantID uint) ([]entity.SLAPolicy, error) {
+	return r.FindAll(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "is_active", Operator: repository.OpEq, Value: true}},
+	})
+}
+
+func (r *slaPolicyRepository) FindTenantIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := r.DB().WithContext(ctx).
+		Model(&entity.SLAPolicy{}).
+		Where("is_active").
+		Distinct("tenant_id").
+		Order("tenant_id").
+		Pluck("tenant_id", &ids).Error
+	return ids, err
+}
+
+func (r *slaPolicyRepository) FindHolidays(ctx context.Context, tenantID uint, from time.Time) ([]entity.Holiday, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var holidays []entity.Holiday
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("date >= ?", from.Format("2006-01-02")).
+		Order("date").
+		Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *slaPolicyRepository) CreateHoliday(ctx context.Context, holiday *entity.Holiday) error {
+	if holiday.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Create(holiday).Error
+}
+
+func (r *slaPolicyRepository) DeleteHoliday(ctx context.Context, tenantID uint, id uint) error {
+	if tenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	result := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Delete(&entity.Holiday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *slaPolicyRepository) FindClocks(ctx context.Context, tenantID uint, q repository.ClockQuery) ([]repository.ClaimClock, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	query := r.DB().WithContext(ctx).
+		Model(&entity.Claim{}).
+		Scopes(tenant.TenantScope(tenantID)).
+		Select("id, tenant_id, claim_type, status, status_changed_at, handler_user_id").
+		Where("status IN ? AND status_changed_at <= ? AND id > ?", q.Statuses, q.EnteredBefore, q.AfterID)
+	if q.Unbreached {
+		query = query.Where(`NOT EXISTS (SELECT 1 FROM sla_breaches b WHERE b.claim_id = claims.id
+			AND b.status = claims.status AND b.entered_at = claims.status_changed_at)`)
+	}
+	var clocks []repository.ClaimClock
+	err := query.Order("id").Limit(q.Limit).Scan(&clocks).Error
+	return clocks, err
+}
+
+type slaBreachRepository struct {
+	*Repository[entity.SLABreach]
+}
+
+// NewSLABreachRepository creates a new SLA breach repository
+func NewSLABreachRepository(db *gorm.DB) repository.SLABreachRepository {
+	return &slaBreachRepository{Repository: NewRepository[entity.SLABreach](db, repository.SLABreachFields)}
+}
+
+func (r *slaBreachRepository) CreateMissing(ctx context.Context, breaches []entity.SLABreach) ([]entity.SLABreach, error) {
+	var created []entity.SLABreach
+	for i := range breaches {
+		if breaches[i].TenantID == 0 {
+			return created, repository.ErrTenantRequired
+		}
+		// Row by row, since RETURNING skips conflicting rows and gorm would
+		// hand their IDs to the wrong breaches
+		result := r.DB().WithContext(ctx).
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "claim_id"}, {Name: "status"}, {Name: "entered_at"}},
+				DoNothing: true,
+			}).
+			Omit(clause.Associations).
+			Create(&breaches[i])
+		if result.Error != nil {
+			return created, result.Error
+		}
+		if result.RowsAffected > 0 {
+			created = append(created, breaches[i])
+		}
+	}
+	return created, nil
+}
+
+func (r *slaBreachRepository) Resolve(ctx context.Context, tenantID uint, at time.Time) (int64, error) {
+	if tenantID == 0 {
+		return 0, repository.ErrTenantRequired
+	}
+	result := r.DB().WithContext(ctx).
+		Model(&entity.SLABreach{}).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("resolved_at IS NULL").
+		Where(`NOT EXISTS (SELECT 1 FROM claims c WHERE c.id = sla_breaches.claim_id
+			AND c.status = sla_breaches.status AND c.status_changed_at = sla_breaches.entered_at
+			AND c.deleted_at IS NULL)`).
+		Update("resolved_at", at)
+	return result.RowsAffected, result.Error
+}
+
+func (r *slaBreachRepository) Acknowledge(ctx context.Context, breach *entity.SLABreach, userID uint, at time.Time) error {
+	if breach.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	result := r.DB().WithContext(ctx).
+		Model(breach).
+		Scopes(tenant.TenantScope(breach.TenantID)).
+		Where("acknowledged_at IS NULL").
+		Updates(map[string]interface{}{"acknowledged_by": userID, "acknowledged_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrAlreadyAcknowledged
+	}
+	breach.AcknowledgedBy = &userID
+	breach.AcknowledgedAt = &at
+	return nil
+}
+
+func (r *slaBreachRepository) FindByClaim(ctx context.Context, tenantID uint, claimID uint) ([]entity.SLABreach, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var breaches []entity.SLABreach
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("claim_id = ?", claimID).
+		Order("breached_at, id").
+		Find(&breaches).Error
+	return breaches, err
+}
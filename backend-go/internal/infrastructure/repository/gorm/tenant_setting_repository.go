@@ -0,0 +1,52 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantSettingRepository struct {
+	*Repository[entity.TenantSetting]
+}
+
+// NewTenantSettingRepository creates a new tenant setting repository
+func NewTenantSettingRepository(db *gorm.DB) repository.TenantSettingRepository {
+	return &tenantSettingRepository{Repository: NewRepository[entity.TenantSetting](db, repository.TenantSettingFields)}
+}
+
+func (r *tenantSettingRepository) FindValues(ctx context.Context, tenantID uint, keys ...string) (map[string]string, error) {
+	settings, err := r.FindAll(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "setting_key", Operator: repository.OpIn, Value: keys}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.SettingKey] = s.SettingValue
+	}
+	return values, nil
+}
+
+func (r *tenantSettingRepository) Upsert(ctx context.Context, tenantID uint, settings []entity.TenantSetting) error {
+	if tenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	for i := range settings {
+		settings[i].TenantID = tenantID
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "setting_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"setting_value", "setting_type", "updated_at"}),
+		}).
+		Create(&settings).Error
+}
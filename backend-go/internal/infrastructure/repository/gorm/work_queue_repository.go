@@ -0,0 +1,217 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/domain/entity"
+	"github.com/bank-melli/tpa/internal/domain/repository"
+	"github.com/bank-melli/tpa/internal/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type workQueueRepository struct {
+	*Repository[entity.WorkQueue]
+}
+
+// NewWorkQueueRepository creates a new work queue repository
+func NewWorkQueueRepository(db *gorm.DB) repository.WorkQueueRepository {
+	return &workQueueRepository{Repository: NewRepository[entity.WorkQueue](db, repository.WorkQueueFields)}
+}
+
+func (r *workQueueRepository) FindByClaimType(ctx context.Context, tenantID uint, claimType entity.ClaimType) (*entity.WorkQueue, error) {
+	return r.FindOne(ctx, repository.QueryOptions{
+		TenantID: tenantID,
+		Filters:  []repository.Filter{{Field: "claim_type", Operator: repository.OpEq, Value: claimType}},
+	})
+}
+
+func (r *workQueueRepository) SaveQueue(ctx context.Context, queue *entity.WorkQueue) error {
+	if queue.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "claim_type"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"role", "mode", "is_active", "updated_by", "updated_at"}),
+		}).
+		Create(queue).Error
+}
+
+func (r *workQueueRepository) FindExaminers(ctx context.Context, tenantID uint, roles []entity.RoleName) ([]entity.User, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var users []entity.User
+	err := r.DB().WithContext(ctx).
+		Joins("Role").
+		Where("users.tenant_id = ? AND users.is_active AND \"Role\".name IN ?", tenantID, roles).
+		Order("users.id").
+		Find(&users).Error
+	return users, err
+}
+
+func (r *workQueueRepository) FindProfiles(ctx context.Context, tenantID uint, userIDs []uint) (map[uint]entity.ExaminerProfile, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	profiles := make(map[uint]entity.ExaminerProfile, len(userIDs))
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+	var found []entity.ExaminerProfile
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("user_id IN ?", userIDs).
+		Find(&found).Error
+	for _, p := range found {
+		profiles[p.UserID] = p
+	}
+	return profiles, err
+}
+
+func (r *workQueueRepository) SaveProfile(ctx context.Context, profile *entity.ExaminerProfile) error {
+	if profile.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"skills", "capacity", "available", "away_until", "updated_at"}),
+		}).
+		Omit(clause.Associations).
+		Create(profile).Error
+}
+
+func (r *workQueueRepository) Loads(ctx context.Context, tenantID uint, statuses []entity.ClaimStatus) (map[uint]int64, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var rows []struct {
+		HandlerUserID uint
+		Count         int64
+	}
+	err := r.DB().WithContext(ctx).
+		Model(&entity.Claim{}).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("handler_user_id IS NOT NULL AND status IN ?", statuses).
+		Select("handler_user_id, count(*) as count").
+		Group("handler_user_id").
+		Scan(&rows).Error
+	loads := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		loads[row.HandlerUserID] = row.Count
+	}
+	return loads, err
+}
+
+func (r *workQueueRepository) Backlog(ctx context.Context, tenantID uint, statuses []entity.ClaimStatus) ([]repository.QueueBacklog, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var backlog []repository.QueueBacklog
+	err := r.DB().WithContext(ctx).
+		Model(&entity.Claim{}).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("status IN ?", statuses).
+		Select("claim_type, " +
+			"count(*) FILTER (WHERE handler_user_id IS NULL) as unassigned, " +
+			"count(*) FILTER (WHERE handler_user_id IS NOT NULL) as assigned, " +
+			"min(created_at) FILTER (WHERE handler_user_id IS NULL) as oldest_waiting").
+		Group("claim_type").
+		Order("claim_type").
+		Scan(&backlog).Error
+	return backlog, err
+}
+
+func (r *workQueueRepository) Assign(ctx context.Context, claim *entity.Claim, statuses []entity.ClaimStatus, record *entity.ClaimAssignment) error {
+	if claim.TenantID == 0 {
+		return repository.ErrTenantRequired
+	}
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(claim).
+			Scopes(tenant.TenantScope(claim.TenantID)).
+			Where("status IN ?", statuses)
+		if record.PreviousUserID == nil {
+			query = query.Where("handler_user_id IS NULL")
+		} else {
+			query = query.Where("handler_user_id = ?", *record.PreviousUserID)
+		}
+		result := query.Updates(map[string]interface{}{
+			"handler_user_id": record.UserID,
+			"assigned_at":     assignedAt(record),
+			"updated_at":      record.CreatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrAlreadyAssigned
+		}
+		claim.HandlerUserID = record.UserID
+		claim.AssignedAt = assignedAt(record)
+		return tx.Create(record).Error
+	})
+}
+
+func (r *workQueueRepository) AssignNext(ctx context.Context, tenantID uint, claimTypes []entity.ClaimType, statuses []entity.ClaimStatus, record *entity.ClaimAssignment) (*entity.Claim, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var claim entity.Claim
+	err := r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets examiners pulling at the same moment get different claims
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Scopes(tenant.TenantScope(tenantID)).
+			Where("handler_user_id IS NULL AND status IN ? AND claim_type IN ?", statuses, claimTypes).
+			Order("created_at, id").
+			First(&claim).Error
+		if err != nil {
+			return err
+		}
+
+		claim.HandlerUserID = record.UserID
+		claim.AssignedAt = assignedAt(record)
+		err = tx.Model(&claim).
+			Updates(map[string]interface{}{
+				"handler_user_id": claim.HandlerUserID,
+				"assigned_at":     claim.AssignedAt,
+				"updated_at":      record.CreatedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+		record.TenantID = tenantID
+		record.ClaimID = claim.ID
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *workQueueRepository) FindAssignments(ctx context.Context, tenantID uint, claimID uint) ([]entity.ClaimAssignment, error) {
+	if tenantID == 0 {
+		return nil, repository.ErrTenantRequired
+	}
+	var assignments []entity.ClaimAssignment
+	err := r.DB().WithContext(ctx).
+		Scopes(tenant.TenantScope(tenantID)).
+		Where("claim_id = ?", claimID).
+		Order("created_at, id").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// assignedAt is when the claim of record got its examiner; nil once it is back in the queue
+func assignedAt(record *entity.ClaimAssignment) *time.Time {
+	if record.UserID == nil {
+		return nil
+	}
+	at := record.CreatedAt
+	return &at
+}
@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// TCPCheck returns a check that dials the host of rawURL (or a host:port address)
+func TCPCheck(rawURL string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		addr, err := dialAddr(rawURL)
+		if err != nil {
+			return err
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// dialAddr turns a URL or host:port into a dialable address
+func dialAddr(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		if _, _, splitErr := net.SplitHostPort(rawURL); splitErr == nil {
+			return rawURL, nil
+		}
+		return "", fmt.Errorf("invalid address %q", rawURL)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,235 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Build information, injected at build time:
+//
+//	go build -ldflags "-X github.com/bank-melli/tpa/internal/pkg/health.Version=1.4.0 \
+//	  -X github.com/bank-melli/tpa/internal/pkg/health.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = ""
+)
+
+// Status is the state of a check or of the whole service
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded" // a non-critical check failed
+	StatusDown     Status = "down"     // a critical check failed
+)
+
+// Check is a named dependency probe registered by a component
+type Check struct {
+	Name     string
+	Critical bool          // failing critical checks make the service not ready
+	Timeout  time.Duration // 0 uses RegistryConfig.DefaultTimeout
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Critical bool          `json:"critical"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report aggregates check results with build information
+type Report struct {
+	Status    Status    `json:"status"`
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	BuildTime string    `json:"build_time,omitempty"`
+	Uptime    string    `json:"uptime"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks,omitempty"`
+}
+
+// RegistryConfig configures the health registry
+type RegistryConfig struct {
+	// DefaultTimeout bounds checks that don't set their own
+	DefaultTimeout time.Duration
+}
+
+// DefaultRegistryConfig returns default registry configuration
+func DefaultRegistryConfig() RegistryConfig {
+	return RegistryConfig{
+		DefaultTimeout: 2 * time.Second,
+	}
+}
+
+// Registry holds the checks components registered and serves health endpoints
+type Registry struct {
+	config   RegistryConfig
+	started  time.Time
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewRegistry creates an empty health registry
+func NewRegistry(config ...RegistryConfig) *Registry {
+	cfg := DefaultRegistryConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return &Registry{config: cfg, started: time.Now()}
+}
+
+// Register adds checks; a check with an existing name replaces it
+func (r *Registry) Register(checks ...Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, check := range checks {
+		replaced := false
+		for i := range r.checks {
+			if r.checks[i].Name == check.Name {
+				r.checks[i] = check
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			r.checks = append(r.checks, check)
+		}
+	}
+}
+
+// Drain marks the service as shutting down so readiness fails before the listener closes
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Run executes every check concurrently and aggregates the results
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]Check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := r.report(StatusUp)
+	report.Checks = results
+	for _, res := range results {
+		if res.Status == StatusUp {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, check Check) (res Result) {
+	res = Result{Name: check.Name, Critical: check.Critical, Status: StatusUp}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = r.config.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func (r *Registry) report(status Status) Report {
+	return Report{
+		Status:    status,
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		Uptime:    time.Since(r.started).Round(time.Second).String(),
+		CheckedAt: time.Now(),
+	}
+}
+
+// Routes mounts /health, /health/ready and /health/live on router
+func (r *Registry) Routes(router fiber.Router) {
+	router.Get("/health", r.HealthHandler())
+	router.Get("/health/ready", r.ReadyHandler())
+	router.Get("/health/live", r.LiveHandler())
+}
+
+// HealthHandler runs every check and reports details; 503 only when a critical check fails
+func (r *Registry) HealthHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := r.Run(c.UserContext())
+		if report.Status == StatusDown {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+		}
+		return c.JSON(report)
+	}
+}
+
+// ReadyHandler reports whether the service can take traffic (critical checks only decide)
+func (r *Registry) ReadyHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if r.draining.Load() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(r.report(StatusDown))
+		}
+		report := r.Run(c.UserContext())
+		if report.Status == StatusDown {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+		}
+		return c.JSON(report)
+	}
+}
+
+// LiveHandler reports that the process is serving requests; it checks no dependencies
+func (r *Registry) LiveHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(r.report(StatusUp))
+	}
+}
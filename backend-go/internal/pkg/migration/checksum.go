@@ -0,0 +1,145 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DriftMode controls how the migrator reacts to changed migrations
+type DriftMode string
+
+const (
+	DriftFail DriftMode = "fail" // refuse to migrate
+	DriftWarn DriftMode = "warn" // log and continue
+)
+
+// DriftReason explains why an applied migration was flagged
+type DriftReason string
+
+const (
+	DriftChanged    DriftReason = "changed"     // definition differs from what was applied
+	DriftUnknown    DriftReason = "unknown"     // applied but no longer registered
+	DriftNoChecksum DriftReason = "no_checksum" // applied before checksums were recorded
+)
+
+// ErrDrift is returned when applied migrations no longer match their definitions
+var ErrDrift = errors.New("applied migrations have drifted from their definitions")
+
+// Drift describes an applied migration whose definition no longer matches
+type Drift struct {
+	TenantID uint        `json:"tenant_id"`
+	Version  string      `json:"version"`
+	Name     string      `json:"name"`
+	Reason   DriftReason `json:"reason"`
+	Recorded string      `json:"recorded_checksum"`
+	Current  string      `json:"current_checksum"`
+}
+
+// Blocking reports whether the drift should stop migrations in DriftFail mode.
+// Legacy records without a checksum are reported but never block.
+func (d Drift) Blocking() bool {
+	return d.Reason == DriftChanged
+}
+
+// Checksum returns a deterministic SHA-256 of the migration definition.
+// Whitespace in Source is normalised so re-indenting SQL is not drift.
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	h.Write([]byte(m.Version))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Name))
+	h.Write([]byte{0})
+	if m.Source != "" {
+		h.Write([]byte(strings.Join(strings.Fields(m.Source), " ")))
+	} else {
+		// Without a source only the identity can be verified
+		h.Write([]byte(m.Description))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SQLSource builds a Migration.Source from the SQL its Up and Down execute
+func SQLSource(up, down string) string {
+	return "-- up\n" + up + "\n-- down\n" + down
+}
+
+// Verify compares completed migrations of a tenant against registered definitions
+func (m *Migrator) Verify(ctx context.Context, tenantID uint) ([]Drift, error) {
+	var records []MigrationRecord
+	err := m.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, MigrationCompleted).
+		Order("version ASC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []Drift
+	for _, record := range records {
+		drift := Drift{
+			TenantID: tenantID,
+			Version:  record.Version,
+			Name:     record.Name,
+			Recorded: record.Checksum,
+		}
+
+		migration := m.find(record.Version)
+		switch {
+		case migration == nil:
+			drift.Reason = DriftUnknown
+		case record.Checksum == "":
+			drift.Current = migration.Checksum()
+			drift.Reason = DriftNoChecksum
+		case record.Checksum != migration.Checksum():
+			drift.Current = migration.Checksum()
+			drift.Reason = DriftChanged
+		default:
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// DriftReport verifies every tenant and returns drifts keyed by tenant ID
+func (m *Migrator) DriftReport(ctx context.Context, tenantIDs []uint) (map[uint][]Drift, error) {
+	report := make(map[uint][]Drift)
+	for _, tenantID := range tenantIDs {
+		drifts, err := m.Verify(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+		if len(drifts) > 0 {
+			report[tenantID] = drifts
+		}
+	}
+	return report, nil
+}
+
+// checkDrift verifies a tenant before migrating, honouring the drift mode
+func (m *Migrator) checkDrift(ctx context.Context, tenantID uint) error {
+	drifts, err := m.Verify(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	var blocking []string
+	for _, d := range drifts {
+		if d.Blocking() {
+			blocking = append(blocking, d.Version)
+		}
+	}
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	if m.config.DriftMode == DriftWarn {
+		m.config.Logger.Printf("warning: tenant %d has drifted migrations: %s", tenantID, strings.Join(blocking, ", "))
+		return nil
+	}
+	return fmt.Errorf("%w: tenant %d: %s", ErrDrift, tenantID, strings.Join(blocking, ", "))
+}
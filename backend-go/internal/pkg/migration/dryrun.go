@@ -0,0 +1,120 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PlannedStatement is a SQL statement a migration would execute
+type PlannedStatement struct {
+	SQL          string        `json:"sql"`
+	RowsAffected int64         `json:"rows_affected"`
+	Duration     time.Duration `json:"duration"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// MigrationPlan lists what one pending migration would do
+type MigrationPlan struct {
+	Version    string             `json:"version"`
+	Name       string             `json:"name"`
+	Statements []PlannedStatement `json:"statements"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// TenantPlan lists what pending migrations would do for one tenant
+type TenantPlan struct {
+	TenantID   uint            `json:"tenant_id"`
+	Migrations []MigrationPlan `json:"migrations"`
+}
+
+// HasErrors reports whether any planned migration failed
+func (p *TenantPlan) HasErrors() bool {
+	for _, mig := range p.Migrations {
+		if mig.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DryRun executes pending Up migrations for a tenant inside a transaction that
+// is always rolled back, recording each statement and the rows it affected.
+// No MigrationRecord rows are written.
+func (m *Migrator) DryRun(ctx context.Context, tenantID uint) (*TenantPlan, error) {
+	plan := &TenantPlan{TenantID: tenantID}
+	pending := m.Pending(ctx, tenantID)
+	if len(pending) == 0 {
+		return plan, nil
+	}
+
+	tx := m.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer tx.Rollback()
+
+	for i, migration := range pending {
+		recorder := &statementRecorder{Interface: logger.Discard}
+		migPlan := MigrationPlan{Version: migration.Version, Name: migration.Name}
+
+		// Savepoints keep later migrations runnable after one fails
+		savepoint := fmt.Sprintf("dry_run_%d", i)
+		if err := tx.SavePoint(savepoint).Error; err != nil {
+			return nil, err
+		}
+
+		err := migration.Up(ctx, tx.Session(&gorm.Session{Logger: recorder}), tenantID)
+		if err != nil {
+			migPlan.Error = err.Error()
+			if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+				return nil, rbErr
+			}
+		}
+
+		migPlan.Statements = recorder.statements()
+		plan.Migrations = append(plan.Migrations, migPlan)
+	}
+
+	return plan, nil
+}
+
+// statementRecorder is a GORM logger that captures executed statements
+type statementRecorder struct {
+	logger.Interface
+	mu      sync.Mutex
+	records []PlannedStatement
+}
+
+// LogMode keeps the recorder in place when GORM changes the log level
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+// Trace records every statement regardless of log level
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, rows := fc()
+	stmt := PlannedStatement{
+		SQL:          strings.Join(strings.Fields(sql), " "),
+		RowsAffected: rows,
+		Duration:     time.Since(begin),
+	}
+	if err != nil {
+		stmt.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.records = append(r.records, stmt)
+	r.mu.Unlock()
+}
+
+func (r *statementRecorder) statements() []PlannedStatement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlannedStatement(nil), r.records...)
+}
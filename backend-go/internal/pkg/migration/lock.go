@@ -0,0 +1,71 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// advisoryLockClass namespaces tenant migration locks ("TPM1") from other advisory lock users
+const advisoryLockClass int32 = 0x54504d31
+
+// lockPollInterval is how often a busy tenant lock is retried
+const lockPollInterval = 500 * time.Millisecond
+
+// ErrLockTimeout is returned when another process keeps a tenant's migration lock
+var ErrLockTimeout = errors.New("timed out waiting for tenant migration lock")
+
+// lockTenant takes a Postgres session advisory lock for the tenant so that two
+// deploying replicas never migrate the same tenant at once. The lock lives on a
+// dedicated connection and is released by the returned function.
+func (m *Migrator) lockTenant(ctx context.Context, tenantID uint) (func(), error) {
+	if m.db.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock connection: %w", err)
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.config.LockTimeout)
+	defer cancel()
+
+	for {
+		var locked bool
+		err := conn.QueryRowContext(lockCtx, "SELECT pg_try_advisory_lock($1, $2)", advisoryLockClass, int32(tenantID)).Scan(&locked)
+		if err != nil {
+			conn.Close()
+			if lockCtx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("%w: tenant %d", ErrLockTimeout, tenantID)
+			}
+			return nil, fmt.Errorf("failed to lock tenant %d: %w", tenantID, err)
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-lockCtx.Done():
+			conn.Close()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: tenant %d", ErrLockTimeout, tenantID)
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, $2)", advisoryLockClass, int32(tenantID)); err != nil {
+			m.config.Logger.Printf("failed to unlock tenant %d: %v", tenantID, err)
+		}
+		conn.Close()
+	}, nil
+}
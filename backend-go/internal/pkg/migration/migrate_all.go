@@ -0,0 +1,123 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TenantResult reports the outcome of migrating one tenant
+type TenantResult struct {
+	TenantID uint          `json:"tenant_id"`
+	Applied  int           `json:"applied"`
+	Duration time.Duration `json:"duration"`
+	Skipped  bool          `json:"skipped"` // not attempted because an earlier tenant failed
+	Err      error         `json:"-"`
+}
+
+// MigrateAllSummary aggregates the per-tenant results of MigrateAll
+type MigrateAllSummary struct {
+	Results   []TenantResult `json:"results"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Duration  time.Duration  `json:"duration"`
+}
+
+// Err joins the errors of all failed tenants
+func (s *MigrateAllSummary) Err() error {
+	var errs []error
+	for _, r := range s.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("tenant %d: %w", r.TenantID, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MigrateAll runs all pending migrations for all tenants using a bounded worker pool.
+// Unless ContinueOnError is set, no new tenants are started after the first failure.
+func (m *Migrator) MigrateAll(ctx context.Context) (*MigrateAllSummary, error) {
+	tenantIDs, err := m.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.MigrateTenants(ctx, tenantIDs), nil
+}
+
+// MigrateTenants migrates the given tenants in parallel and summarises the results
+func (m *Migrator) MigrateTenants(ctx context.Context, tenantIDs []uint) *MigrateAllSummary {
+	start := time.Now()
+	results := make([]TenantResult, len(tenantIDs))
+
+	workers := m.config.Concurrency
+	if workers > len(tenantIDs) {
+		workers = len(tenantIDs)
+	}
+
+	var failed atomic.Bool
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := TenantResult{TenantID: tenantIDs[i]}
+				if (failed.Load() && !m.config.ContinueOnError) || ctx.Err() != nil {
+					result.Skipped = true
+					results[i] = result
+					continue
+				}
+
+				tenantStart := time.Now()
+				result.Applied, result.Err = m.migrateTenant(ctx, tenantIDs[i])
+				result.Duration = time.Since(tenantStart)
+				if result.Err != nil {
+					failed.Store(true)
+				}
+				results[i] = result
+			}
+		}()
+	}
+
+	for i := range tenantIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := &MigrateAllSummary{Results: results, Duration: time.Since(start)}
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			summary.Skipped++
+		case r.Err != nil:
+			summary.Failed++
+		default:
+			summary.Succeeded++
+		}
+	}
+	return summary
+}
+
+// Tenants returns the IDs of all tenants that migrations run for.
+// Insurers are the tenants, so their primary keys are the tenant IDs.
+func (m *Migrator) Tenants(ctx context.Context) ([]uint, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable("insurers") {
+		// Fresh database without insurers: use default tenant
+		return []uint{1}, nil
+	}
+
+	var tenantIDs []uint
+	err := db.Raw("SELECT id FROM insurers WHERE deleted_at IS NULL ORDER BY id").
+		Scan(&tenantIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return tenantIDs, nil
+}
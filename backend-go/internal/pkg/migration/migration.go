@@ -3,6 +3,7 @@ package migration
 import (
 	"context"
 	"fmt"
+	"log"
 	"sort"
 	"time"
 
@@ -46,65 +47,121 @@ type Migration struct {
 	Version     string
 	Name        string
 	Description string
+	Source      string // canonical definition (e.g. SQL run by Up/Down) used for the checksum
 	Up          func(ctx context.Context, db *gorm.DB, tenantID uint) error
 	Down        func(ctx context.Context, db *gorm.DB, tenantID uint) error
 }
 
+// MigratorConfig configures the migrator
+type MigratorConfig struct {
+	// DriftMode decides what happens when an applied migration's definition changed
+	DriftMode DriftMode
+
+	// ExecutedBy is recorded on every migration record
+	ExecutedBy string
+
+	// Logger receives drift warnings
+	Logger *log.Logger
+
+	// Concurrency bounds how many tenants MigrateAll migrates at once
+	Concurrency int
+
+	// ContinueOnError keeps MigrateAll going after a tenant fails
+	ContinueOnError bool
+
+	// LockTimeout bounds how long to wait for another process holding a tenant's lock
+	LockTimeout time.Duration
+}
+
+// DefaultMigratorConfig returns default migrator configuration
+func DefaultMigratorConfig() MigratorConfig {
+	return MigratorConfig{
+		DriftMode:   DriftFail,
+		ExecutedBy:  "system",
+		Logger:      log.Default(),
+		Concurrency: 4,
+		LockTimeout: 5 * time.Minute,
+	}
+}
+
 // Migrator handles per-tenant migrations
 type Migrator struct {
 	db         *gorm.DB
 	migrations []Migration
+	config     MigratorConfig
 }
 
 // NewMigrator creates a new migrator
-func NewMigrator(db *gorm.DB) *Migrator {
+func NewMigrator(db *gorm.DB, config ...MigratorConfig) *Migrator {
+	cfg := DefaultMigratorConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.DriftMode == "" {
+			cfg.DriftMode = DriftFail
+		}
+		if cfg.ExecutedBy == "" {
+			cfg.ExecutedBy = "system"
+		}
+		if cfg.Logger == nil {
+			cfg.Logger = log.Default()
+		}
+		if cfg.Concurrency <= 0 {
+			cfg.Concurrency = 1
+		}
+		if cfg.LockTimeout <= 0 {
+			cfg.LockTimeout = 5 * time.Minute
+		}
+	}
 	return &Migrator{
 		db:         db,
 		migrations: make([]Migration, 0),
+		config:     cfg,
 	}
 }
 
 // Register adds a migration to the migrator
 func (m *Migrator) Register(migration Migration) {
 	m.migrations = append(m.migrations, migration)
+	m.sortMigrations()
 }
 
 // RegisterMany adds multiple migrations
 func (m *Migrator) RegisterMany(migrations []Migration) {
 	m.migrations = append(m.migrations, migrations...)
+	m.sortMigrations()
 }
 
-// Initialize creates the migrations tracking table
-func (m *Migrator) Initialize(ctx context.Context) error {
-	return m.db.WithContext(ctx).AutoMigrate(&MigrationRecord{})
+// Migrations returns registered migrations ordered by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
 }
 
-// MigrateAll runs all pending migrations for all tenants
-func (m *Migrator) MigrateAll(ctx context.Context) error {
-	// Get all tenant IDs
-	var tenantIDs []uint
-	err := m.db.WithContext(ctx).
-		Raw("SELECT DISTINCT tenant_id FROM insurers WHERE deleted_at IS NULL").
-		Pluck("tenant_id", &tenantIDs).Error
-	if err != nil {
-		// If insurers table doesn't exist, use default tenant
-		tenantIDs = []uint{1}
-	}
-
-	for _, tenantID := range tenantIDs {
-		if err := m.Migrate(ctx, tenantID); err != nil {
-			return fmt.Errorf("migration failed for tenant %d: %w", tenantID, err)
-		}
-	}
-	return nil
+// sortMigrations orders registered migrations by version.
+// Sorting happens at registration so concurrent tenant runs only read the slice.
+func (m *Migrator) sortMigrations() {
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
 }
 
 // Migrate runs all pending migrations for a specific tenant
 func (m *Migrator) Migrate(ctx context.Context, tenantID uint) error {
-	// Sort migrations by version
-	sort.Slice(m.migrations, func(i, j int) bool {
-		return m.migrations[i].Version < m.migrations[j].Version
-	})
+	_, err := m.migrateTenant(ctx, tenantID)
+	return err
+}
+
+// migrateTenant runs pending migrations under the tenant lock and returns how many were applied
+func (m *Migrator) migrateTenant(ctx context.Context, tenantID uint) (int, error) {
+	unlock, err := m.lockTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// Refuse to build on migrations whose definition changed since they ran
+	if err := m.checkDrift(ctx, tenantID); err != nil {
+		return 0, err
+	}
 
 	// Get last batch number
 	var lastBatch int
@@ -116,17 +173,19 @@ func (m *Migrator) Migrate(ctx context.Context, tenantID uint) error {
 	newBatch := lastBatch + 1
 
 	// Run each migration
+	applied := 0
 	for _, migration := range m.migrations {
 		if m.hasRun(ctx, tenantID, migration.Version) {
 			continue
 		}
 
 		if err := m.runMigration(ctx, tenantID, migration, newBatch); err != nil {
-			return err
+			return applied, fmt.Errorf("%s: %w", migration.Version, err)
 		}
+		applied++
 	}
 
-	return nil
+	return applied, nil
 }
 
 // hasRun checks if a migration has already been executed for a tenant
@@ -149,8 +208,9 @@ func (m *Migrator) runMigration(ctx context.Context, tenantID uint, migration Mi
 		Description: migration.Description,
 		Status:      MigrationRunning,
 		StartedAt:   time.Now(),
+		Checksum:    migration.Checksum(),
 		BatchNo:     batchNo,
-		ExecutedBy:  "system",
+		ExecutedBy:  m.config.ExecutedBy,
 	}
 	if err := m.db.WithContext(ctx).Create(record).Error; err != nil {
 		return err
@@ -177,13 +237,22 @@ func (m *Migrator) runMigration(ctx context.Context, tenantID uint, migration Mi
 
 // Rollback rolls back the last batch of migrations for a tenant
 func (m *Migrator) Rollback(ctx context.Context, tenantID uint) error {
+	unlock, err := m.lockTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Get last batch
 	var lastBatch int
-	m.db.WithContext(ctx).
+	err = m.db.WithContext(ctx).
 		Model(&MigrationRecord{}).
 		Where("tenant_id = ? AND status = ?", tenantID, MigrationCompleted).
 		Select("COALESCE(MAX(batch_no), 0)").
-		Scan(&lastBatch)
+		Scan(&lastBatch).Error
+	if err != nil {
+		return err
+	}
 
 	if lastBatch == 0 {
 		return nil // Nothing to rollback
@@ -191,22 +260,47 @@ func (m *Migrator) Rollback(ctx context.Context, tenantID uint) error {
 
 	// Get migrations in this batch (reverse order)
 	var records []MigrationRecord
-	m.db.WithContext(ctx).
+	err = m.db.WithContext(ctx).
 		Where("tenant_id = ? AND batch_no = ? AND status = ?", tenantID, lastBatch, MigrationCompleted).
 		Order("version DESC").
-		Find(&records)
+		Find(&records).Error
+	if err != nil {
+		return err
+	}
+
+	return m.rollbackRecords(ctx, tenantID, records)
+}
+
+// RollbackTo rolls back every applied migration newer than the target version.
+// An empty target rolls back everything.
+func (m *Migrator) RollbackTo(ctx context.Context, tenantID uint, target string) error {
+	if target != "" && m.find(target) == nil {
+		return fmt.Errorf("unknown migration version: %s", target)
+	}
+
+	unlock, err := m.lockTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var records []MigrationRecord
+	err = m.db.WithContext(ctx).
+		Where("tenant_id = ? AND version > ? AND status = ?", tenantID, target, MigrationCompleted).
+		Order("version DESC").
+		Find(&records).Error
+	if err != nil {
+		return err
+	}
 
-	// Rollback each
+	return m.rollbackRecords(ctx, tenantID, records)
+}
+
+// rollbackRecords runs Down for each record in the given order
+func (m *Migrator) rollbackRecords(ctx context.Context, tenantID uint, records []MigrationRecord) error {
 	for _, record := range records {
 		// Find migration definition
-		var migration *Migration
-		for _, mig := range m.migrations {
-			if mig.Version == record.Version {
-				migration = &mig
-				break
-			}
-		}
-
+		migration := m.find(record.Version)
 		if migration == nil || migration.Down == nil {
 			continue // Skip if no down migration
 		}
@@ -228,13 +322,23 @@ func (m *Migrator) Rollback(ctx context.Context, tenantID uint) error {
 		m.db.WithContext(ctx).Save(&record)
 
 		if err != nil {
-			return err
+			return fmt.Errorf("rollback of %s failed: %w", record.Version, err)
 		}
 	}
 
 	return nil
 }
 
+// find returns the registered migration with the given version
+func (m *Migrator) find(version string) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
 // Status returns migration status for a tenant
 func (m *Migrator) Status(ctx context.Context, tenantID uint) ([]MigrationRecord, error) {
 	var records []MigrationRecord
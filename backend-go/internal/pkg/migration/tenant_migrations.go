@@ -2,10 +2,87 @@ package migration
 
 import (
 	"context"
+	"fmt"
 
 	"gorm.io/gorm"
 )
 
+// Tenant migration SQL, shared by Up/Down and the migration checksum
+const (
+	createTenantSettingsUp = `
+		INSERT INTO tenant_settings (tenant_id, setting_key, setting_value, created_at, updated_at)
+		VALUES (?, 'claim_auto_approve_threshold', '0', NOW(), NOW())
+		ON CONFLICT (tenant_id, setting_key) DO NOTHING
+	`
+	createTenantSettingsDown = `
+		DELETE FROM tenant_settings
+		WHERE tenant_id = ? AND setting_key = 'claim_auto_approve_threshold'
+	`
+
+	initializeTenantSequencesUp = `
+		INSERT INTO tenant_sequences (tenant_id, sequence_name, current_value, prefix, created_at, updated_at)
+		VALUES
+			(?, 'claim', 0, 'CLM', NOW(), NOW()),
+			(?, 'package', 0, 'PKG', NOW(), NOW()),
+			(?, 'settlement', 0, 'STL', NOW(), NOW())
+		ON CONFLICT (tenant_id, sequence_name) DO NOTHING
+	`
+	initializeTenantSequencesDown = `
+		DELETE FROM tenant_sequences WHERE tenant_id = ?
+	`
+
+	addDefaultReasonCodesUp = `
+		INSERT INTO reason_codes (tenant_id, code, title_fa, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, true, NOW(), NOW())
+		ON CONFLICT DO NOTHING
+	`
+	addDefaultReasonCodesDown = `
+		DELETE FROM reason_codes
+		WHERE tenant_id = ? AND code IN ('R001', 'R002', 'R003', 'R004', 'R005')
+	`
+
+	addAutoApproveSettingsUp = `
+		INSERT INTO tenant_settings (tenant_id, setting_key, setting_value, setting_type, description, created_at, updated_at)
+		VALUES
+			(?, 'claim_auto_approve_thresholds', '{}', 'json', 'سقف تایید خودکار به تفکیک نوع پرونده (ریال)', NOW(), NOW()),
+			(?, 'claim_auto_approve_sample_rate', '0', 'float', 'درصد پرونده‌های تایید خودکار که برای بازبینی انتخاب می‌شوند', NOW(), NOW())
+		ON CONFLICT (tenant_id, setting_key) DO NOTHING
+	`
+	describeAutoApproveThresholdUp = `
+		UPDATE tenant_settings SET setting_type = 'int', description = 'سقف مبلغ درخواستی برای تایید خودکار (ریال)؛ صفر یعنی غیرفعال'
+		WHERE tenant_id = ? AND setting_key = 'claim_auto_approve_threshold'
+	`
+	addAutoApproveSettingsDown = `
+		DELETE FROM tenant_settings
+		WHERE tenant_id = ? AND setting_key IN ('claim_auto_approve_thresholds', 'claim_auto_approve_sample_rate')
+	`
+
+	addSLAWorkHoursUp = `
+		INSERT INTO tenant_settings (tenant_id, setting_key, setting_value, setting_type, description, created_at, updated_at)
+		VALUES
+			(?, 'sla_work_start', '08:00', 'string', 'شروع ساعت کاری برای محاسبه مهلت پرونده‌ها', NOW(), NOW()),
+			(?, 'sla_work_end', '16:00', 'string', 'پایان ساعت کاری برای محاسبه مهلت پرونده‌ها', NOW(), NOW()),
+			(?, 'sla_work_days', '6,0,1,2,3', 'string', 'روزهای کاری هفته (۰ یکشنبه تا ۶ شنبه)', NOW(), NOW())
+		ON CONFLICT (tenant_id, setting_key) DO NOTHING
+	`
+	addSLAWorkHoursDown = `
+		DELETE FROM tenant_settings
+		WHERE tenant_id = ? AND setting_key IN ('sla_work_start', 'sla_work_end', 'sla_work_days')
+	`
+)
+
+// defaultReasonCodes are the deduction reason codes seeded for every tenant
+var defaultReasonCodes = []struct {
+	Code  string
+	Title string
+}{
+	{"R001", "خارج از تعهد بیمه"},
+	{"R002", "مدارک ناقص"},
+	{"R003", "عدم تطابق با تعرفه"},
+	{"R004", "تکراری بودن خدمت"},
+	{"R005", "عدم پوشش بیمه‌ای"},
+}
+
 // GetTenantMigrations returns all tenant-specific migrations
 func GetTenantMigrations() []Migration {
 	return []Migration{
@@ -13,64 +90,36 @@ func GetTenantMigrations() []Migration {
 			Version:     "2024_01_01_000001",
 			Name:        "create_tenant_settings",
 			Description: "Creates per-tenant settings table",
+			Source:      SQLSource(createTenantSettingsUp, createTenantSettingsDown),
 			Up: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
 				// This runs within the tenant's schema context
-				return db.Exec(`
-					INSERT INTO tenant_settings (tenant_id, setting_key, setting_value, created_at, updated_at)
-					VALUES (?, 'claim_auto_approve_threshold', '0', NOW(), NOW())
-					ON CONFLICT (tenant_id, setting_key) DO NOTHING
-				`, tenantID).Error
+				return db.Exec(createTenantSettingsUp, tenantID).Error
 			},
 			Down: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
-				return db.Exec(`
-					DELETE FROM tenant_settings
-					WHERE tenant_id = ? AND setting_key = 'claim_auto_approve_threshold'
-				`, tenantID).Error
+				return db.Exec(createTenantSettingsDown, tenantID).Error
 			},
 		},
 		{
 			Version:     "2024_01_01_000002",
 			Name:        "initialize_tenant_sequences",
 			Description: "Initializes sequence numbers for claims, packages per tenant",
+			Source:      SQLSource(initializeTenantSequencesUp, initializeTenantSequencesDown),
 			Up: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
-				return db.Exec(`
-					INSERT INTO tenant_sequences (tenant_id, sequence_name, current_value, prefix, created_at, updated_at)
-					VALUES
-						(?, 'claim', 0, 'CLM', NOW(), NOW()),
-						(?, 'package', 0, 'PKG', NOW(), NOW()),
-						(?, 'settlement', 0, 'STL', NOW(), NOW())
-					ON CONFLICT (tenant_id, sequence_name) DO NOTHING
-				`, tenantID, tenantID, tenantID).Error
+				return db.Exec(initializeTenantSequencesUp, tenantID, tenantID, tenantID).Error
 			},
 			Down: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
-				return db.Exec(`
-					DELETE FROM tenant_sequences WHERE tenant_id = ?
-				`, tenantID).Error
+				return db.Exec(initializeTenantSequencesDown, tenantID).Error
 			},
 		},
 		{
 			Version:     "2024_01_02_000001",
 			Name:        "add_default_reason_codes",
 			Description: "Adds default deduction reason codes for tenant",
+			Source:      SQLSource(addDefaultReasonCodesUp, addDefaultReasonCodesDown) + fmt.Sprint(defaultReasonCodes),
 			Up: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
 				// Insert default reason codes if not exists
-				reasonCodes := []struct {
-					Code    string
-					Title   string
-				}{
-					{"R001", "خارج از تعهد بیمه"},
-					{"R002", "مدارک ناقص"},
-					{"R003", "عدم تطابق با تعرفه"},
-					{"R004", "تکراری بودن خدمت"},
-					{"R005", "عدم پوشش بیمه‌ای"},
-				}
-
-				for _, rc := range reasonCodes {
-					err := db.Exec(`
-						INSERT INTO reason_codes (tenant_id, code, title_fa, is_active, created_at, updated_at)
-						VALUES (?, ?, ?, true, NOW(), NOW())
-						ON CONFLICT DO NOTHING
-					`, tenantID, rc.Code, rc.Title).Error
+				for _, rc := range defaultReasonCodes {
+					err := db.Exec(addDefaultReasonCodesUp, tenantID, rc.Code, rc.Title).Error
 					if err != nil {
 						return err
 					}
@@ -78,52 +127,35 @@ func GetTenantMigrations() []Migration {
 				return nil
 			},
 			Down: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
-				return db.Exec(`
-					DELETE FROM reason_codes
-					WHERE tenant_id = ? AND code IN ('R001', 'R002', 'R003', 'R004', 'R005')
-				`, tenantID).Error
+				return db.Exec(addDefaultReasonCodesDown, tenantID).Error
+			},
+		},
+		{
+			Version:     "2024_01_03_000001",
+			Name:        "add_auto_approve_settings",
+			Description: "Adds per claim type thresholds and review sampling for auto-approval",
+			Source:      SQLSource(addAutoApproveSettingsUp+describeAutoApproveThresholdUp, addAutoApproveSettingsDown),
+			Up: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
+				if err := db.Exec(addAutoApproveSettingsUp, tenantID, tenantID).Error; err != nil {
+					return err
+				}
+				return db.Exec(describeAutoApproveThresholdUp, tenantID).Error
+			},
+			Down: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
+				return db.Exec(addAutoApproveSettingsDown, tenantID).Error
+			},
+		},
+		{
+			Version:     "2024_01_04_000001",
+			Name:        "add_sla_work_hours",
+			Description: "Adds the work hours SLA timers count business time in",
+			Source:      SQLSource(addSLAWorkHoursUp, addSLAWorkHoursDown),
+			Up: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
+				return db.Exec(addSLAWorkHoursUp, tenantID, tenantID, tenantID).Error
+			},
+			Down: func(ctx context.Context, db *gorm.DB, tenantID uint) error {
+				return db.Exec(addSLAWorkHoursDown, tenantID).Error
 			},
 		},
 	}
 }
-
-// TenantSettingsTable SQL for tenant settings table
-const TenantSettingsTable = `
-CREATE TABLE IF NOT EXISTS tenant_settings (
-	id SERIAL PRIMARY KEY,
-	tenant_id INTEGER NOT NULL,
-	setting_key VARCHAR(100) NOT NULL,
-	setting_value TEXT,
-	setting_type VARCHAR(50) DEFAULT 'string',
-	description TEXT,
-	created_at TIMESTAMP DEFAULT NOW(),
-	updated_at TIMESTAMP DEFAULT NOW(),
-	UNIQUE(tenant_id, setting_key)
-);
-CREATE INDEX IF NOT EXISTS idx_tenant_settings_tenant ON tenant_settings(tenant_id);
-`
-
-// TenantSequencesTable SQL for tenant sequences table
-const TenantSequencesTable = `
-CREATE TABLE IF NOT EXISTS tenant_sequences (
-	id SERIAL PRIMARY KEY,
-	tenant_id INTEGER NOT NULL,
-	sequence_name VARCHAR(100) NOT NULL,
-	current_value BIGINT DEFAULT 0,
-	prefix VARCHAR(20),
-	suffix VARCHAR(20),
-	pad_length INTEGER DEFAULT 6,
-	created_at TIMESTAMP DEFAULT NOW(),
-	updated_at TIMESTAMP DEFAULT NOW(),
-	UNIQUE(tenant_id, sequence_name)
-);
-CREATE INDEX IF NOT EXISTS idx_tenant_sequences_tenant ON tenant_sequences(tenant_id);
-`
-
-// InitializeTenantTables creates base tables for tenant migrations
-func InitializeTenantTables(db *gorm.DB) error {
-	if err := db.Exec(TenantSettingsTable).Error; err != nil {
-		return err
-	}
-	return db.Exec(TenantSequencesTable).Error
-}
@@ -0,0 +1,65 @@
+// Package persian normalizes Persian text typed on different keyboards.
+// Arabic keyboards produce ي and ك where Persian uses ی and ک, digits come
+// in three scripts, and zero-width non-joiners are typed by some and left
+// out by others, so the same name is stored and searched in several forms.
+package persian
+
+import (
+	"strings"
+	"unicode"
+)
+
+// canonical maps characters to their standard Persian form
+var canonical = map[rune]rune{
+	'ي': 'ی', // Arabic yeh
+	'ى': 'ی', // alef maksura
+	'ك': 'ک', // Arabic kaf
+	'۰': '0', '۱': '1', '۲': '2', '۳': '3', '۴': '4', '۵': '5', '۶': '6', '۷': '7', '۸': '8', '۹': '9',
+	'٠': '0', '١': '1', '٢': '2', '٣': '3', '٤': '4', '٥': '5', '٦': '6', '٧': '7', '٨': '8', '٩': '9',
+}
+
+// folded maps letters a search treats as the same
+var folded = map[rune]rune{
+	'ة': 'ه', 'ۀ': 'ه',
+	'أ': 'ا', 'إ': 'ا', 'آ': 'ا', 'ٱ': 'ا',
+}
+
+// Canonical returns s in standard Persian spelling, for storing: Persian yeh
+// and kaf, ASCII digits and single spaces. It keeps what is spelling rather
+// than keyboard noise, such as آ and the zero-width non-joiner.
+func Canonical(s string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if c, ok := canonical[r]; ok {
+			return c
+		}
+		return r
+	}, s)), " ")
+}
+
+// SearchKey returns s folded for matching: Canonical, without zero-width
+// characters, tatweel and diacritics, with alef and heh variants merged and
+// lower case. normalize_fa in the database schema must fold the same way.
+func SearchKey(s string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if c, ok := canonical[r]; ok {
+			return c
+		}
+		if c, ok := folded[r]; ok {
+			return c
+		}
+		switch {
+		case r == '\u200c', r == '\u200b', r == '\u200d', r == '\ufeff', r == '\u0640': // ZWNJ, ZWSP, ZWJ, BOM, tatweel
+			return -1
+		case r >= '\u064b' && r <= '\u0652', r == '\u0670': // harakat, superscript alef
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)), " ")
+}
+
+// Words splits a search key into its words of letters and digits
+func Words(key string) []string {
+	return strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
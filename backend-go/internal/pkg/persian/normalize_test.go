@@ -0,0 +1,80 @@
+package persian
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "arabic yeh and kaf", in: "علي كريمي", want: "علی کریمی"},
+		{name: "alef maksura", in: "موسى", want: "موسی"},
+		{name: "persian and arabic digits", in: "۱۲۳٤٥٦", want: "123456"},
+		{name: "keeps zwnj", in: "مهدي\u200cزاده", want: "مهدی\u200cزاده"},
+		{name: "keeps alef madda", in: "آرين", want: "آرین"},
+		{name: "collapses spaces", in: "  علی \t  احمدی ", want: "علی احمدی"},
+		{name: "empty", in: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Canonical(tt.in); got != tt.want {
+				t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchKey(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "arabic yeh and kaf", in: "علي كريمي", want: "علی کریمی"},
+		{name: "arabic yeh and kaf with zwnj", in: "كاظمي\u200cنيا", want: "کاظمینیا"},
+		{name: "zwnj and no zwnj match", in: "کاظمی\u200cنیا", want: "کاظمینیا"},
+		{name: "other zero-width characters", in: "\ufeffعلی\u200bرضا\u200d", want: "علیرضا"},
+		{name: "tatweel and diacritics", in: "مُحَمَّـــد", want: "محمد"},
+		{name: "alef variants", in: "آرين أحمد إسلامي", want: "ارین احمد اسلامی"},
+		{name: "heh variants", in: "فاطمة خانۀ", want: "فاطمه خانه"},
+		{name: "digits and latin case", in: "TRK-۱۴۰۳", want: "trk-1403"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchKey(tt.in); got != tt.want {
+				t.Errorf("SearchKey(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchKeyMatchesSpellings(t *testing.T) {
+	spellings := []string{"علی کاظمی\u200cنیا", "علي كاظمي\u200cنيا", "علی  کاظمینیا", "عَلی کاظمی\u200cنیا"}
+	want := SearchKey(spellings[0])
+	for _, s := range spellings[1:] {
+		if got := SearchKey(s); got != want {
+			t.Errorf("SearchKey(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{key: "علی احمدی", want: []string{"علی", "احمدی"}},
+		{key: "trk-1403/12", want: []string{"trk", "1403", "12"}},
+		{key: "(علی)، رضا", want: []string{"علی", "رضا"}},
+		{key: " - ", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Scope identifies what a rate limit bucket is keyed by
+type Scope string
+
+const (
+	ScopeTenant Scope = "tenant"
+	ScopeUser   Scope = "user"
+	ScopeIP     Scope = "ip"
+)
+
+// Errors
+var (
+	ErrQuotaExceeded = errors.New("usage quota exceeded")
+)
+
+// Limit defines a token bucket: Rate tokens are added per second up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// IsZero reports whether the limit disables limiting
+func (l Limit) IsZero() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token is available (only when denied)
+}
+
+// Store persists token buckets and counters
+type Store interface {
+	// Take removes one token from the bucket at key
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+
+	// IncrBy adds n to the counter at key, expiring it at expireAt, and returns the new value
+	IncrBy(ctx context.Context, key string, n int64, expireAt time.Time) (int64, error)
+}
+
+// newResult builds a Result from the token count left after a take
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	res := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: durationForTokens(float64(limit.Burst)-tokens, limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = durationForTokens(1-tokens, limit.Rate)
+	}
+	return res
+}
+
+// durationForTokens returns how long the bucket needs to refill n tokens
+func durationForTokens(n, rate float64) time.Duration {
+	if n <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(n / rate * float64(time.Second))
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval controls how often idle buckets and expired counters are dropped
+const sweepInterval = 5 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	idleTill time.Time
+}
+
+type counter struct {
+	value    int64
+	expireAt time.Time
+}
+
+// MemoryStore is an in-process Store, suitable for single replica deployments
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		counters:  make(map[string]*counter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take removes one token from the bucket at key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	// Refill since last take
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.idleTill = now.Add(durationForTokens(float64(limit.Burst), limit.Rate))
+
+	return newResult(allowed, b.tokens, limit), nil
+}
+
+// IncrBy adds n to the counter at key and returns the new value
+func (s *MemoryStore) IncrBy(ctx context.Context, key string, n int64, expireAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expireAt) {
+		c = &counter{}
+		s.counters[key] = c
+	}
+	c.value += n
+	c.expireAt = expireAt
+	return c.value, nil
+}
+
+// sweep drops full buckets and expired counters; caller must hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.idleTill) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if now.After(c.expireAt) {
+			delete(s.counters, key)
+		}
+	}
+}
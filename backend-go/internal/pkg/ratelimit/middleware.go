@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limiter applies per-tenant, per-user and per-IP token buckets to requests
+type Limiter struct {
+	store           Store
+	limits          map[Scope]Limit
+	tenantOverrides map[uint]Limit
+}
+
+// NewLimiter creates a limiter from configuration
+func NewLimiter(cfg *config.RateLimitConfig, store Store) *Limiter {
+	overrides := make(map[uint]Limit, len(cfg.TenantOverrides))
+	for tenantID, o := range cfg.TenantOverrides {
+		overrides[tenantID] = Limit{Rate: o.Rate, Burst: o.Burst}
+	}
+
+	return &Limiter{
+		store: store,
+		limits: map[Scope]Limit{
+			ScopeTenant: {Rate: cfg.TenantRate, Burst: cfg.TenantBurst},
+			ScopeUser:   {Rate: cfg.UserRate, Burst: cfg.UserBurst},
+			ScopeIP:     {Rate: cfg.IPRate, Burst: cfg.IPBurst},
+		},
+		tenantOverrides: overrides,
+	}
+}
+
+// NewStore creates the store selected by RateLimitConfig.Backend
+func NewStore(cfg *config.RateLimitConfig, redisCfg *config.RedisConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(redisCfg)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+}
+
+// limitFor returns the limit for a scope, honouring tenant overrides
+func (l *Limiter) limitFor(scope Scope, tenantID uint) Limit {
+	if scope == ScopeTenant {
+		if override, ok := l.tenantOverrides[tenantID]; ok {
+			return override
+		}
+	}
+	return l.limits[scope]
+}
+
+// Middleware returns Fiber middleware enforcing the configured limits.
+// The tightest bucket is reported via RateLimit-* headers.
+func (l *Limiter) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		now := time.Now()
+		tenantID := tenantIDFrom(c)
+
+		keys := map[Scope]string{
+			ScopeIP: fmt.Sprintf("ip:%s", c.IP()),
+		}
+		if tenantID > 0 {
+			keys[ScopeTenant] = fmt.Sprintf("tenant:%d", tenantID)
+		}
+		if userID, ok := c.Locals("user_id").(uint); ok && userID > 0 {
+			keys[ScopeUser] = fmt.Sprintf("tenant:%d:user:%d", tenantID, userID)
+		}
+
+		var tightest *Result
+		for _, scope := range []Scope{ScopeTenant, ScopeUser, ScopeIP} {
+			key, ok := keys[scope]
+			if !ok {
+				continue
+			}
+			limit := l.limitFor(scope, tenantID)
+			if limit.IsZero() {
+				continue
+			}
+
+			res, err := l.store.Take(ctx, key, limit, now)
+			if err != nil {
+				// Fail open: a broken limiter must not take the API down
+				log.Printf("rate limit store error for %s: %v", key, err)
+				continue
+			}
+
+			if !res.Allowed {
+				setHeaders(c, res)
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				return fiber.NewError(fiber.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded for %s", scope))
+			}
+			if tightest == nil || res.Remaining < tightest.Remaining {
+				r := res
+				tightest = &r
+			}
+		}
+
+		if tightest != nil {
+			setHeaders(c, *tightest)
+		}
+		return c.Next()
+	}
+}
+
+// setHeaders writes the standard RateLimit response headers
+func setHeaders(c *fiber.Ctx, res Result) {
+	c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+}
+
+// tenantIDFrom reads the authenticated tenant from JWT locals. The
+// X-Tenant-ID header is not trusted here: a client could rotate it to
+// escape its own buckets or drain another tenant's, so unauthenticated
+// requests are limited by IP only.
+func tenantIDFrom(c *fiber.Ctx) uint {
+	tenantID, _ := c.Locals("tenant_id").(uint)
+	return tenantID
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,156 @@
+package ratelimit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"github.com/bank-melli/tpa/internal/delivery/http/middleware"
+	"github.com/bank-melli/tpa/internal/pkg/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+const testSecret = "test-secret"
+
+// newApp mounts the limiter and the import quota behind the auth middleware,
+// as the API does, so both see the tenant and user of the token
+func newApp(cfg *config.RateLimitConfig) *fiber.App {
+	store := ratelimit.NewMemoryStore()
+	quota := ratelimit.NewQuota(cfg, store)
+
+	app := fiber.New()
+	protected := app.Group("/api",
+		middleware.AuthMiddleware(&config.JWTConfig{Secret: testSecret}),
+		ratelimit.NewLimiter(cfg, store).Middleware(),
+	)
+	protected.Get("/quotas", quota.UsageHandler())
+	protected.Post("/import", quota.Middleware(ratelimit.OperationImport), func(c *fiber.Ctx) error {
+		if c.Query("fail") != "" {
+			return fiber.NewError(fiber.StatusInternalServerError, "import failed")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+// request sends a request signed in as user of tenant
+func request(t *testing.T, app *fiber.App, method, path string, tenantID, userID uint) *http.Response {
+	t.Helper()
+	token, err := middleware.GenerateToken(&middleware.JWTClaims{UserID: userID, TenantID: tenantID}, testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	type step struct {
+		method       string
+		path         string
+		tenant, user uint
+		want         int
+	}
+	tests := []struct {
+		name  string
+		cfg   config.RateLimitConfig
+		steps []step
+	}{
+		{
+			name: "tenant bucket shared by its users",
+			cfg:  config.RateLimitConfig{TenantRate: 0.001, TenantBurst: 2},
+			steps: []step{
+				{"GET", "/api/quotas", 1, 1, http.StatusOK},
+				{"GET", "/api/quotas", 1, 2, http.StatusOK},
+				{"GET", "/api/quotas", 1, 1, http.StatusTooManyRequests},
+				{"GET", "/api/quotas", 1, 3, http.StatusTooManyRequests},
+				{"GET", "/api/quotas", 2, 4, http.StatusOK},
+			},
+		},
+		{
+			name: "user bucket",
+			cfg:  config.RateLimitConfig{UserRate: 0.001, UserBurst: 1},
+			steps: []step{
+				{"GET", "/api/quotas", 1, 1, http.StatusOK},
+				{"GET", "/api/quotas", 1, 1, http.StatusTooManyRequests},
+				{"GET", "/api/quotas", 1, 2, http.StatusOK},
+			},
+		},
+		{
+			name: "monthly quota per tenant, refunded on failure",
+			cfg:  config.RateLimitConfig{MonthlyImportQuota: 2},
+			steps: []step{
+				{"POST", "/api/import", 1, 1, http.StatusOK},
+				{"POST", "/api/import?fail=1", 1, 1, http.StatusInternalServerError},
+				{"POST", "/api/import", 1, 2, http.StatusOK},
+				{"POST", "/api/import", 1, 1, http.StatusTooManyRequests},
+				{"POST", "/api/import", 2, 3, http.StatusOK},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp(&tt.cfg)
+			for i, s := range tt.steps {
+				resp := request(t, app, s.method, s.path, s.tenant, s.user)
+				resp.Body.Close()
+				if resp.StatusCode != s.want {
+					t.Errorf("step %d: %s %s as tenant %d user %d = %d, want %d", i+1, s.method, s.path, s.tenant, s.user, resp.StatusCode, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUsageHandlerReportsConsumedQuota(t *testing.T) {
+	app := newApp(&config.RateLimitConfig{MonthlyImportQuota: 5})
+	for i := 0; i < 2; i++ {
+		request(t, app, "POST", "/api/import", 1, 1).Body.Close()
+	}
+	request(t, app, "POST", "/api/import", 2, 2).Body.Close()
+
+	resp := request(t, app, "GET", "/api/quotas", 1, 1)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /quotas = %d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Data []ratelimit.QuotaUsage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range body.Data {
+		if u.Operation == ratelimit.OperationImport && (u.Used != 2 || u.Limit != 5) {
+			t.Errorf("import usage = %d of %d, want 2 of 5", u.Used, u.Limit)
+		}
+	}
+}
+
+func TestUnauthenticatedRequestsNeverReachTheBuckets(t *testing.T) {
+	app := newApp(&config.RateLimitConfig{MonthlyImportQuota: 1})
+	req := httptest.NewRequest("POST", "/api/import", nil)
+	req.Header.Set("X-Tenant-ID", "1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("POST without a token = %d, want 401", resp.StatusCode)
+	}
+	// The header did not consume tenant 1's quota
+	resp = request(t, app, "POST", "/api/import", 1, 1)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("first import of tenant 1 = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bank-melli/tpa/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operation names an expensive operation metered by monthly quotas
+type Operation string
+
+const (
+	OperationImport       Operation = "import"
+	OperationReportExport Operation = "report_export"
+)
+
+// QuotaUsage reports a tenant's consumption of one operation in the current month
+type QuotaUsage struct {
+	Operation Operation `json:"operation"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"` // 0 means unlimited
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// Remaining returns how many operations are left this month (-1 if unlimited)
+func (u QuotaUsage) Remaining() int64 {
+	if u.Limit == 0 {
+		return -1
+	}
+	if u.Used >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Used
+}
+
+// Quota enforces monthly per-tenant limits on operations
+type Quota struct {
+	store  Store
+	limits map[Operation]int64
+}
+
+// NewQuota creates a quota enforcer from configuration
+func NewQuota(cfg *config.RateLimitConfig, store Store) *Quota {
+	return &Quota{
+		store: store,
+		limits: map[Operation]int64{
+			OperationImport:       cfg.MonthlyImportQuota,
+			OperationReportExport: cfg.MonthlyReportExportQuota,
+		},
+	}
+}
+
+// Consume records one use of op for the tenant, returning ErrQuotaExceeded when over the limit
+func (q *Quota) Consume(ctx context.Context, tenantID uint, op Operation) (QuotaUsage, error) {
+	usage := q.usage(tenantID, op, time.Now())
+	used, err := q.store.IncrBy(ctx, q.key(tenantID, op, usage.ResetAt), 1, usage.ResetAt.Add(24*time.Hour))
+	if err != nil {
+		return usage, err
+	}
+	usage.Used = used
+
+	if usage.Limit > 0 && used > usage.Limit {
+		// Give back the slot we just took so the counter stays at the limit
+		q.Refund(ctx, tenantID, usage)
+		usage.Used = usage.Limit
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// Refund returns one use to the tenant, e.g. when the operation failed. It
+// takes the usage returned by Consume so the use goes back to the month it
+// was taken from, even when the month has turned since.
+func (q *Quota) Refund(ctx context.Context, tenantID uint, usage QuotaUsage) {
+	resetAt := usage.ResetAt
+	if _, err := q.store.IncrBy(ctx, q.key(tenantID, usage.Operation, resetAt), -1, resetAt.Add(24*time.Hour)); err != nil {
+		log.Printf("quota refund failed for tenant %d %s: %v", tenantID, usage.Operation, err)
+	}
+}
+
+// Usage returns current month usage for every metered operation
+func (q *Quota) Usage(ctx context.Context, tenantID uint) ([]QuotaUsage, error) {
+	now := time.Now()
+	result := make([]QuotaUsage, 0, len(q.limits))
+	for _, op := range []Operation{OperationImport, OperationReportExport} {
+		usage := q.usage(tenantID, op, now)
+		used, err := q.store.IncrBy(ctx, q.key(tenantID, op, usage.ResetAt), 0, usage.ResetAt.Add(24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		usage.Used = used
+		result = append(result, usage)
+	}
+	return result, nil
+}
+
+// Middleware consumes one unit of op per request and refunds it if the request fails
+func (q *Quota) Middleware(op Operation) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID := tenantIDFrom(c)
+		if tenantID == 0 || q.limits[op] == 0 {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		usage, err := q.Consume(ctx, tenantID, op)
+		if err == ErrQuotaExceeded {
+			setQuotaHeaders(c, usage)
+			return fiber.NewError(fiber.StatusTooManyRequests, fmt.Sprintf("monthly %s quota exceeded", op))
+		}
+		if err != nil {
+			log.Printf("quota store error for tenant %d %s: %v", tenantID, op, err)
+			return c.Next()
+		}
+		setQuotaHeaders(c, usage)
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			q.Refund(ctx, tenantID, usage)
+		}
+		return err
+	}
+}
+
+// UsageHandler returns a Fiber handler reporting the current tenant's quota usage
+func (q *Quota) UsageHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID := tenantIDFrom(c)
+		if tenantID == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "tenant ID is required")
+		}
+		usage, err := q.Usage(c.UserContext(), tenantID)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"data": usage})
+	}
+}
+
+func (q *Quota) usage(tenantID uint, op Operation, now time.Time) QuotaUsage {
+	return QuotaUsage{
+		Operation: op,
+		Limit:     q.limits[op],
+		ResetAt:   nextMonth(now),
+	}
+}
+
+// key is unique per tenant, operation and month (identified by its reset time)
+func (q *Quota) key(tenantID uint, op Operation, resetAt time.Time) string {
+	month := resetAt.AddDate(0, -1, 0).Format("2006-01")
+	return fmt.Sprintf("quota:tenant:%d:%s:%s", tenantID, op, month)
+}
+
+func setQuotaHeaders(c *fiber.Ctx, usage QuotaUsage) {
+	c.Set("X-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+	c.Set("X-Quota-Remaining", strconv.FormatInt(usage.Remaining(), 10))
+	c.Set("X-Quota-Reset", usage.ResetAt.Format(time.RFC3339))
+}
+
+// nextMonth returns the start of the month after now
+func nextMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Scanner checks content for malware before it is stored
+type Scanner interface {
+	// Scan returns an *InfectedError if r carries malware
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// InfectedError reports the signature a scanner found
+type InfectedError struct {
+	Signature string
+}
+
+func (e *InfectedError) Error() string {
+	return "storage: infected content: " + e.Signature
+}
+
+// NewScanner returns a clamd scanner at addr, or nil to skip scanning when addr is empty
+func NewScanner(addr string) Scanner {
+	if addr == "" {
+		return nil
+	}
+	return &ClamdScanner{addr: addr, timeout: 2 * time.Minute}
+}
+
+// ClamdScanner streams content to a clamd daemon over its INSTREAM command
+type ClamdScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// clamdChunk is the size of the chunks sent to clamd; it must stay below its StreamMaxLength
+const clamdChunk = 64 << 10
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("storage: clamd: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("storage: clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamdChunk)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := conn.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("storage: clamd: %w", werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// A zero length chunk ends the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("storage: clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("storage: clamd: %w", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream:"), "FOUND")
+		return &InfectedError{Signature: strings.TrimSpace(signature)}
+	default:
+		return fmt.Errorf("storage: clamd: %s", reply)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrInvalidToken = errors.New("storage: invalid download token")
+	ErrExpiredToken = errors.New("storage: download token expired")
+)
+
+// URLSigner issues and checks download tokens. A token names the tenant and
+// the attachment, so it opens that one file of that tenant until it expires.
+type URLSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewURLSigner creates a signer with key
+func NewURLSigner(key string) *URLSigner {
+	return &URLSigner{key: []byte(key), now: time.Now}
+}
+
+// Sign returns a token for attachment id of tenantID valid until expires
+func (s *URLSigner) Sign(tenantID, id uint, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d.%d", tenantID, id, expires.Unix())
+	return payload + "." + s.signature(payload)
+}
+
+// Verify returns the tenant and attachment of a valid, unexpired token
+func (s *URLSigner) Verify(token string) (tenantID, id uint, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, 0, ErrInvalidToken
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(s.signature(payload))) {
+		return 0, 0, ErrInvalidToken
+	}
+
+	values := make([]uint64, 3)
+	for i, part := range parts[:3] {
+		if values[i], err = strconv.ParseUint(part, 10, 64); err != nil {
+			return 0, 0, ErrInvalidToken
+		}
+	}
+	if s.now().Unix() >= int64(values[2]) {
+		return 0, 0, ErrExpiredToken
+	}
+	return uint(values[0]), uint(values[1]), nil
+}
+
+func (s *URLSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
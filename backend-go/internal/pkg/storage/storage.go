@@ -0,0 +1,81 @@
+// Package storage keeps attachment files in a blob store, either the local
+// filesystem or an S3-compatible object store, and signs download links.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bank-melli/tpa/internal/config"
+)
+
+// Errors
+var (
+	ErrNotFound         = errors.New("storage: object not found")
+	ErrInvalidKey       = errors.New("storage: invalid object key")
+	ErrChecksumMismatch = errors.New("storage: content does not match its checksum")
+)
+
+// Object describes a blob being stored
+type Object struct {
+	Key         string // slash separated, e.g. tenants/3/claims/12/<uuid>.pdf
+	Size        int64
+	ContentType string
+	SHA256      string // hex checksum of the content, verified by the store
+}
+
+// Store persists blobs by key
+type Store interface {
+	// Put stores the content of obj read from r, replacing any blob at its key
+	Put(ctx context.Context, obj Object, r io.Reader) error
+	// Open returns the content at key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob at key; a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// Ping checks that the store is reachable and writable
+	Ping(ctx context.Context) error
+}
+
+// NewStore creates the store selected by cfg.Backend
+func NewStore(cfg *config.StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
+
+// validKey reports whether key is a relative slash separated path without
+// empty, dot or dot-dot segments
+func validKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, "\\\x00") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectContentType sniffs the media type of content from its first bytes
+// (up to 512), without parameters. It adds TIFF, common for scanned
+// documents, to the types net/http knows.
+func DetectContentType(head []byte) string {
+	if len(head) >= 4 && (string(head[:4]) == "II*\x00" || string(head[:4]) == "MM\x00*") {
+		return "image/tiff"
+	}
+	mediaType := http.DetectContentType(head)
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+	return mediaType
+}
@@ -15,10 +15,27 @@ DB_USER=postgres
 DB_PASSWORD=postgres
 DB_NAME=tpa
 JWT_SECRET=your-secure-jwt-secret-change-this
+STORAGE_SIGNING_KEY=your-secure-signing-key-change-this
 ENVEOF
     echo "Created .env file - edit with your settings"
 fi
 
+# Run database migrations before switching traffic to the new release
+echo "Starting database..."
+docker-compose up -d postgres redis
+
+echo "Running migrations..."
+if ! docker-compose run --rm tpa-api ./tpa migrate up; then
+    echo "Migrations failed - aborting deployment"
+    exit 1
+fi
+
+echo "Seeding reference data..."
+if ! docker-compose run --rm tpa-api ./tpa seed; then
+    echo "Seeding failed - aborting deployment"
+    exit 1
+fi
+
 # Start services
 echo "Starting services..."
 docker-compose up -d